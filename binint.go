@@ -58,6 +58,9 @@ func (x *BinInt) Format(s fmt.State, format rune) {
 	case FORMAT_DUMP: // dump
 		fmt.Fprintf(s, "(bin %v %d)", x.n, x.m)
 		return
+	case FORMAT_SMT2, FORMAT_QEPCAD, FORMAT_REDLOG:
+		x.ToIntRat().Format(s, format)
+		return
 	case FORMAT_TEX:
 		if x.m > 0 {
 			if x.m == 1 {
@@ -287,6 +290,8 @@ func (z *BinInt) Cmp(xx NObj) int {
 	case *Rat:
 		zz := z.ToIntRat()
 		return zz.Cmp(x)
+	case *RealAlg:
+		return -x.Cmp(z)
 	}
 	fmt.Printf("z=%v\n", z)
 	fmt.Printf("x=%v\n", xx)
@@ -18,6 +18,8 @@ type sign_t int8
 type mult_t int8
 
 var CAD_NO_WO = errors.New("NOT well-oriented")
+var CAD_NO_PDF = errors.New("NOT projection definable")
+var CAD_TOO_MANY_CELLS = errors.New("too many cells")
 
 const (
 	t_undef  = -1 // まだ評価していない
@@ -90,6 +92,23 @@ type CADStat struct {
 	tm           []time.Duration
 }
 
+func (stat CADStat) clone() CADStat {
+	ret := stat
+	for _, v := range []*[]int{&ret.cell, &ret.true_cell, &ret.false_cell, &ret.lift, &ret.rlift} {
+		*v = append([]int(nil), (*v)...)
+	}
+	ret.tm = append([]time.Duration(nil), stat.tm...)
+	return ret
+}
+
+// CADProgress は射影と持ち上げの進捗
+type CADProgress struct {
+	Stage int8  // CAD_STAGE_INITED なら射影中, CAD_STAGE_PROJED なら持ち上げ中
+	Proj  []int // [level] 射影因子の数
+	Cell  []int // [level] セルの数
+	Lift  []int // [level] 持ち上げたセルの数
+}
+
 type CAD struct {
 	qfml     Fof           // quantified formula: input
 	fml      Fof           // qff
@@ -107,6 +126,13 @@ type CAD struct {
 	nwo      bool // well-oriented
 	stage    int8
 	palgo    ProjectionAlgo
+
+	progress func(*CADProgress)
+	maxcells int // 持ち上げで作るセルの数の上限. 0 なら無制限
+
+	ckpt      string        // 持ち上げ中に保存するファイル
+	ckpt_intv time.Duration // 保存間隔
+	ckpt_tm   time.Time     // 最後に保存した時刻
 }
 
 func qeCAD(fml Fof) Fof {
@@ -183,6 +209,46 @@ func (c *CAD) String() string {
 	return fmt.Sprintf("CAD[%v]", c.fml)
 }
 
+// SetProgress sets a callback function which is called
+// after each projection step and each cell lift.
+func (c *CAD) SetProgress(f func(*CADProgress)) {
+	c.progress = f
+}
+
+// SetMaxCells limits the number of cells.
+// Lift returns CAD_TOO_MANY_CELLS if the limit is exceeded.
+// n = 0 means unlimited.
+func (c *CAD) SetMaxCells(n int) {
+	c.maxcells = n
+}
+
+// tooManyCells はセルの数が上限を超えたか
+func (c *CAD) tooManyCells() bool {
+	if c.maxcells <= 0 {
+		return false
+	}
+	n := 0
+	for _, m := range c.stat.cell {
+		n += m
+	}
+	return n > c.maxcells
+}
+
+func (c *CAD) progressed() {
+	if c.progress == nil {
+		return
+	}
+	p := new(CADProgress)
+	p.Stage = c.stage
+	p.Proj = make([]int, len(c.proj))
+	for lv, pf := range c.proj {
+		p.Proj[lv] = pf.Len()
+	}
+	p.Cell = append([]int(nil), c.stat.cell...)
+	p.Lift = append([]int(nil), c.stat.lift...)
+	c.progress(p)
+}
+
 func (c *CAD) log(lv int, format string, a ...interface{}) {
 	if lv <= c.g.verbose_cad {
 		fmt.Printf(format, a...)
@@ -208,34 +274,64 @@ func NewCAD(prenex_formula Fof, g *Ganrac) (*CAD, error) {
 	///////////////////////////////////
 	// 変数順序の妥当性チェック
 	///////////////////////////////////
-	v := prenex_formula.maxVar()
-	c.q = make([]int8, v)
-	for i := 0; i < len(c.q); i++ {
-		c.q[i] = -1
-	}
+	var err error
 	c.qfml = prenex_formula
-	c.fml = prenex_formula
+	c.q, c.fml, err = cadQuantifiers(prenex_formula, prenex_formula.maxVar())
+	if err != nil {
+		return nil, err
+	}
+	// 隙間があると面倒なのでエラーにする
+	for i := Level(0); int(i) < len(c.q); i++ {
+		if !c.fml.hasVar(i) {
+			return nil, fmt.Errorf("CAD: invalid variable order [%d,%d]", i, len(c.q))
+		}
+	}
+
+	c.root = NewCell(c, nil, 0)
+	c.rootp = NewCellmod(c.root)
+	c.stack = newCellStack()
+	c.stack.push(c.root)
+	c.stat.cell = make([]int, len(c.q))
+	c.stat.true_cell = make([]int, len(c.q))
+	c.stat.false_cell = make([]int, len(c.q))
+	c.stat.lift = make([]int, len(c.q))
+	c.stat.rlift = make([]int, len(c.q))
+	c.stat.tm = make([]time.Duration, 3)
+
+	return c, nil
+}
+
+// cadQuantifiers は n 変数の冠頭形の論理式 f の各変数の限量子と,
+// 限量子のない部分を返す. 限量子のつく変数は, 自由変数より後ろに限る
+func cadQuantifiers(f Fof, n Level) ([]int8, Fof, error) {
+	q := make([]int8, n)
+	for i := 0; i < len(q); i++ {
+		q[i] = -1
+	}
 	vmax := Level(0)
 	for cnt := 0; ; cnt++ {
 		var qq []Level
 		var qval int8
-		switch f := c.fml.(type) {
+		switch ff := f.(type) {
 		case *ForAll:
-			qq = f.q
+			qq = ff.q
 			qval = q_forall
-			c.fml = f.fml
+			f = ff.fml
 		case *Exists:
-			qq = f.q
+			qq = ff.q
 			qval = q_exists
-			c.fml = f.fml
+			f = ff.fml
 		default:
 			goto _NEXT
 		}
 
 		max := vmax
-		min := v
+		min := n
 		for _, qi := range qq {
-			c.q[qi] = qval
+			if qi >= n {
+				return nil, nil, fmt.Errorf("CAD: invalid variable order [%d,%d]", qi, n)
+			}
+			q[qi] = qval
 			if min > qi {
 				min = qi
 			}
@@ -244,44 +340,26 @@ func NewCAD(prenex_formula Fof, g *Ganrac) (*CAD, error) {
 			}
 		}
 		if int(max-min) != len(qq)-1 || (cnt > 0 && min != vmax+1) {
-			return nil, fmt.Errorf("CAD: invalid variable order [%d,%d,%d]", min, max, vmax)
+			return nil, nil, fmt.Errorf("CAD: invalid variable order [%d,%d,%d]", min, max, vmax)
 		}
 
 		vmax = max
 	}
 _NEXT:
 
-	if !c.fml.IsQff() {
-		return nil, fmt.Errorf("prenex formula is expected")
-	}
-	// 隙間があると面倒なのでエラーにする
-	for i := Level(0); int(i) < len(c.q); i++ {
-		if !c.fml.hasVar(i) {
-			return nil, fmt.Errorf("CAD: invalid variable order [%d,%d]", i, vmax)
-		}
+	if !f.IsQff() {
+		return nil, nil, fmt.Errorf("prenex formula is expected")
 	}
 
 	qdx := false
-	for i := Level(0); int(i) < len(c.q); i++ {
-		if c.q[i] >= 0 {
+	for i := Level(0); int(i) < len(q); i++ {
+		if q[i] >= 0 {
 			qdx = true
 		} else if qdx {
-			return nil, fmt.Errorf("CAD: invalid variable order [%d,%d]", i, vmax)
+			return nil, nil, fmt.Errorf("CAD: invalid variable order [%d,%d]", i, vmax)
 		}
 	}
-
-	c.root = NewCell(c, nil, 0)
-	c.rootp = NewCellmod(c.root)
-	c.stack = newCellStack()
-	c.stack.push(c.root)
-	c.stat.cell = make([]int, len(c.q))
-	c.stat.true_cell = make([]int, len(c.q))
-	c.stat.false_cell = make([]int, len(c.q))
-	c.stat.lift = make([]int, len(c.q))
-	c.stat.rlift = make([]int, len(c.q))
-	c.stat.tm = make([]time.Duration, 3)
-
-	return c, nil
+	return q, f, nil
 }
 
 func (c *CAD) initProj(algo ProjectionAlgo) {
@@ -394,6 +472,8 @@ func (cad *CAD) Fprint(b io.Writer, args ...interface{}) error {
 	switch s.s {
 	case "stat":
 		cad.stat.Fprint(b, cad)
+	case "json":
+		return cad.FprintJSON(b)
 	case "proj":
 		return cad.FprintProj(b, args[1:]...)
 	case "proji":
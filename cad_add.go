@@ -0,0 +1,235 @@
+package ganrac
+
+// 構築済みの CAD への多項式の追加.
+//
+// 追加した多項式の既約因子を射影因子の末尾に加え, その射影だけを計算する.
+// 既存の射影因子のインデックスは変わらないので, セルの signature は延長すればよい.
+// 持ち上げ済みのセルは, 新しい射影因子が根をもつスタックだけ作り直す.
+// 作り直したスタックのセルのうち, 元のセルと同じものは子供と真偽値を引き継ぎ,
+// セクタが分割された場合は, 元の標本点を含むセルが子供を引き継ぐ.
+// 論理式は変わらないので, 分割されたセルの真偽値は元のセルと同じである.
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// AddPolys は ps を CAD に追加する.
+// 射影が終わっていなければ, 射影のときに追加する.
+// エラーのときは CAD を追加前の状態に戻す
+func (cad *CAD) AddPolys(ps []*Poly) error {
+	for _, p := range ps {
+		if int(p.maxVar()) > len(cad.q) {
+			return fmt.Errorf("invalid variable: %v", p)
+		}
+	}
+	if cad.stage == CAD_STAGE_INITED {
+		cad.apppoly = append(cad.apppoly, ps...)
+		return nil
+	}
+	if cad.stage != CAD_STAGE_LIFTED && cad.root.children != nil {
+		return fmt.Errorf("partially lifted CAD")
+	}
+
+	proj_num := make([]int, len(cad.proj))
+	for lv := range cad.proj {
+		proj_num[lv] = cad.proj[lv].Len()
+	}
+	stat := cad.stat.clone()
+	for _, p := range ps {
+		cad.addPoly(p, false)
+	}
+
+	// 射影因子は上のレベルから射影すると, 下のレベルに増える.
+	// 符号を cad.u で決める numEval は入力に依存するので行わない
+	for lv := len(cad.proj) - 1; lv >= 0; lv-- {
+		for i := proj_num[lv]; i < cad.proj[lv].Len(); i++ {
+			cad.proj[lv].get(uint(i)).SetIndex(uint(i))
+			if lv > 0 {
+				cad.proj[lv].doProj(cad, i)
+			}
+		}
+		cad.log(2, "cad.AddPolys lv=%d: %d => %d\n", lv, proj_num[lv], cad.proj[lv].Len())
+	}
+
+	if cad.root.children != nil {
+		// 細分に失敗したら, セルと射影因子を元に戻す
+		undo := make([]func(), 0)
+		if err := cad.root.refine(cad, proj_num, &undo); err != nil {
+			for i := len(undo) - 1; i >= 0; i-- {
+				undo[i]()
+			}
+			for lv, n := range proj_num {
+				cad.proj[lv].truncate(n)
+			}
+			cad.stat = stat
+			return err
+		}
+	}
+	cad.apppoly = append(cad.apppoly, ps...)
+	return nil
+}
+
+// refine は cell の子孫のスタックを, 追加した射影因子で細分する.
+// proj_num は追加前の射影因子の数. 変更を元に戻す関数を undo に積む
+func (cell *Cell) refine(cad *CAD, proj_num []int, undo *[]func()) error {
+	lv := cell.lv + 1
+	pfs := cad.proj[lv]
+	if n := pfs.Len(); n > proj_num[lv] {
+		// 新しい射影因子が根をもたなければ, signature を延長するだけ
+		signs := make([]sign_t, 0, n-proj_num[lv])
+		restack := false
+		for i := proj_num[lv]; i < n; i++ {
+			pf := pfs.get(uint(i))
+			cs, s := cell.make_cells(cad, pf)
+			if s == 0 && !pf.vanishChk(cad, cell) {
+				return CAD_NO_WO
+			}
+			if len(cs) > 0 {
+				restack = true
+				break
+			}
+			signs = append(signs, s)
+		}
+		if restack {
+			if err := cell.restack(cad, proj_num[lv], undo); err != nil {
+				return err
+			}
+		} else {
+			for _, c := range cell.children {
+				c := c
+				sig, mul := c.signature, c.multiplicity
+				*undo = append(*undo, func() {
+					c.signature, c.multiplicity = sig, mul
+				})
+				c.signature = append(c.signature, signs...)
+				c.multiplicity = append(c.multiplicity, make([]mult_t, len(signs))...)
+			}
+		}
+	}
+
+	for _, c := range cell.children {
+		if c.children != nil {
+			if err := c.refine(cad, proj_num, undo); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// refineOwners は作り直したスタック cs の各セルが含まれる元のセルの位置を返す.
+// 元のセルのセクションでは, 最初の num 個の射影因子のどれかが 0 になる.
+func refineOwners(cs []*Cell, num int) []int {
+	owner := make([]int, len(cs))
+	m := 0
+	for i, c := range cs {
+		if c.isSection() && c.vanishFirst(num) {
+			m++
+			owner[i] = m
+			m++
+		} else {
+			owner[i] = m
+		}
+	}
+	return owner
+}
+
+// vanishFirst は最初の num 個の射影因子のどれかが cell で 0 になるか
+func (cell *Cell) vanishFirst(num int) bool {
+	for j := 0; j < num; j++ {
+		if cell.signature[j] == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// restack は cell の上のスタックを作り直し, 元の子供から子供と真偽値を引き継ぐ.
+// num は追加前の射影因子の数
+func (cell *Cell) restack(cad *CAD, num int, undo *[]func()) error {
+	lv := cell.lv + 1
+	oldcs := cell.children
+	if err := cell.makeStack(cad); err != nil {
+		cell.children = oldcs
+		return err
+	}
+	cad.stat.rlift[lv]++
+	cs := cell.children
+	owner := refineOwners(cs, num)
+	if owner[len(owner)-1] != len(oldcs)-1 {
+		cell.children = oldcs
+		return fmt.Errorf("restack: invalid # of sections %v", cell.Index())
+	}
+	*undo = append(*undo, func() {
+		cell.children = oldcs
+		for _, o := range oldcs {
+			o.setParentChildren()
+		}
+	})
+
+	for i := 0; i < len(cs); {
+		o := oldcs[owner[i]]
+		j := i + 1
+		for j < len(cs) && owner[j] == owner[i] {
+			j++
+		}
+		for k := i; k < j; k++ {
+			cs[k].truth = o.truth
+		}
+
+		heir := -1
+		if j == i+1 {
+			heir = i
+		} else if r, ok := o.ratCoord(); ok && o.children != nil {
+			// 分割されたセクタ. 元の標本点を含むセルを探す
+			heir = refineHeir(cs, i, j, toBigRat(r))
+		}
+		if heir >= 0 {
+			c := cs[heir]
+			c.children = o.children
+			c.setParentChildren()
+			if !c.isSection() {
+				c.intv = o.intv
+			}
+		}
+		i = j
+	}
+
+	for _, t := range []int8{t_true, t_false} {
+		n := 0
+		for _, c := range cs {
+			if c.truth == t {
+				n++
+			}
+		}
+		for _, c := range oldcs {
+			if c.truth == t {
+				n--
+			}
+		}
+		if t == t_true {
+			cad.stat.true_cell[lv] += n
+		} else {
+			cad.stat.false_cell[lv] += n
+		}
+	}
+	cad.stat.cell[lv] += len(cs) - len(oldcs)
+	return nil
+}
+
+// refineHeir は cs[i:j] のうち, 有理数 r を含むセクタの位置を返す.
+// 分離区間が r を含むなどで決まらなければ -1
+func refineHeir(cs []*Cell, i, j int, r *big.Rat) int {
+	k := i
+	for ; k+1 < j; k += 2 {
+		// cs[k] はセクタ, cs[k+1] はセクション
+		s := cs[k+1]
+		if r.Cmp(s.isoBound(false)) < 0 {
+			return k
+		} else if r.Cmp(s.isoBound(true)) <= 0 {
+			return -1
+		}
+	}
+	return k
+}
@@ -0,0 +1,129 @@
+package ganrac
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestRefineOwners(t *testing.T) {
+	// 元の射影因子 2 個, 追加 1 個.
+	// 元のスタック: (-inf, a), a, (a, b), b, (b, inf)
+	// 追加した因子の根 c で (a, b) が分割される
+	sigs := [][]sign_t{
+		{-1, 1, 1},
+		{0, 1, 1}, // a
+		{1, 1, 1},
+		{1, 1, 0}, // c
+		{1, 1, -1},
+		{1, 0, -1}, // b
+		{1, 1, -1},
+	}
+	cs := make([]*Cell, len(sigs))
+	for i, s := range sigs {
+		cs[i] = &Cell{lv: 0, index: uint(i), signature: s}
+	}
+	owner := refineOwners(cs, 2)
+	expect := []int{0, 1, 2, 2, 2, 3, 4}
+	for i := range expect {
+		if owner[i] != expect[i] {
+			t.Errorf("expect=%v, actual=%v", expect, owner)
+			break
+		}
+	}
+}
+
+func TestRefineHeir(t *testing.T) {
+	// (a, b) が c=1 と d=[2,3] で 5 つに分割される
+	cs := make([]*Cell, 7)
+	for i := range cs {
+		cs[i] = &Cell{lv: 0, index: uint(i)}
+	}
+	cs[3].intv.inf = NewInt(1)
+	cs[3].intv.sup = cs[3].intv.inf
+	cs[5].defpoly = NewPolyInts(0, -5, 0, 1)
+	cs[5].intv.inf = NewInt(2)
+	cs[5].intv.sup = NewInt(3)
+
+	for _, s := range []struct {
+		r      *big.Rat
+		expect int
+	}{
+		{big.NewRat(1, 2), 2},
+		{big.NewRat(1, 1), -1},
+		{big.NewRat(3, 2), 4},
+		{big.NewRat(5, 2), -1},
+		{big.NewRat(4, 1), 6},
+	} {
+		if h := refineHeir(cs, 2, 7, s.r); h != s.expect {
+			t.Errorf("r=%v: expect=%d, actual=%d", s.r, s.expect, h)
+		}
+	}
+}
+
+func TestCADAdd(t *testing.T) {
+	g := NewGANRAC()
+	connc, connd := testConnectOx(g)
+	if g.ox == nil {
+		fmt.Printf("skip TestCADAdd... (no ox)\n")
+		return
+	}
+	defer connc.Close()
+	defer connd.Close()
+
+	c, err := g.Eval(strings.NewReader("C = cadinit(ex([y], x^2+y^2 < 1));"))
+	if err != nil {
+		t.Fatalf("cadinit: %v", err)
+	}
+	cad := c.(*CAD)
+	if _, err = cad.Projection(PROJ_McCallum); err != nil {
+		t.Fatalf("proj: %v", err)
+	}
+	if err = cad.Lift(); err != nil {
+		t.Fatalf("lift: %v", err)
+	}
+	n0 := cad.proj[0].Len()
+	if _, err = g.Eval(strings.NewReader("cadadd(C, 2*x-1);")); err != nil {
+		t.Fatalf("cadadd: %v", err)
+	}
+	if cad.proj[0].Len() != n0+1 {
+		t.Fatalf("cadadd: # of proj. factors expect=%d, actual=%d", n0+1, cad.proj[0].Len())
+	}
+	if len(cad.root.children) != 7 {
+		t.Errorf("cadadd: # of cells expect=7, actual=%d", len(cad.root.children))
+	}
+
+	// 持ち上げ済みのセクタは子供を引き継ぐ
+	for i, s := range []struct {
+		p     []NObj
+		index []uint
+		truth bool
+	}{
+		{[]NObj{zero}, []uint{2}, true},
+		{[]NObj{NewRatInt64(1, 2)}, []uint{3}, true},
+		{[]NObj{NewRatInt64(3, 4)}, []uint{4}, true},
+		{[]NObj{NewInt(2)}, []uint{6}, false},
+	} {
+		cell, err := cad.Locate(s.p)
+		if err != nil {
+			t.Errorf("%d: %v: %v", i, s.p, err)
+			continue
+		}
+		if idx := cell.Index(); idx[0] != s.index[0] {
+			t.Errorf("%d: %v: expect=%v, actual=%v", i, s.p, s.index, idx)
+		}
+		if v, ok := cell.Truth(); !ok || v != s.truth {
+			t.Errorf("%d: %v: truth expect=%v, actual=(%v,%v)", i, s.p, s.truth, v, ok)
+		}
+		if len(cell.signature) != n0+1 {
+			t.Errorf("%d: %v: signature %v", i, s.p, cell.signature)
+		}
+	}
+	if cad.root.children[2].children == nil {
+		t.Errorf("cadadd: lifted cell is not reused")
+	}
+	if err = cad.root.valid(cad); err != nil {
+		t.Errorf("cadadd: %v", err)
+	}
+}
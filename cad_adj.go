@@ -0,0 +1,903 @@
+package ganrac
+
+// CAD のセルの隣接関係と, 論理式が真となる領域の連結成分.
+//
+// セル C が D の閉包と交わり dim C < dim D のとき, (C, D) は隣接するという.
+// 隣接する組 (C, D) から, その上のスタックのセルの隣接関係を求める.
+// C と D が x_k で分かれ, C の x_k 座標が a, D の標本点の x_k 座標が u のとき,
+// x_k を u から a に近づけたときの D のセクションの極限を,
+// C のセクションを分離する有理数の間にある根の数から決める.
+// 分離する有理数を通る直線を途中で横切らないことを Sturm 列で確認する.
+//
+// 3 変数以上で x_k 以外の座標が無理数の場合は, 射影因子との終結式で消去し,
+// 道の上で D のセクションに沿うか, D の境界のセクションから
+// a で 0 となる多項式だけずらして動かす.
+// 道の端点で D のスタックを作り直し, D のセクションの極限を決める.
+// 求められなかった組があれば incomplete とする.
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// cellPair は隣接するセルの組. c は d の閉包と交わり, dim(c) < dim(d)
+type cellPair struct {
+	c, d *Cell
+}
+
+type cadAdj struct {
+	cad        *CAD
+	pairs      []cellPair         // 最後のレベルの隣接するセルの組
+	prod       []RObj             // [level] 射影因子の積
+	hasTrue    map[*Cell]bool     // 真となるセルを含むか
+	lims       map[cellPair][]int // 持ち上げた組の D のセクションの極限
+	incomplete bool               // 求められなかった隣接関係がある
+}
+
+// adjBase は基点 a. q = nil なら a = lo = hi は有理数,
+// そうでなければ a は q の [lo, hi] 内の唯一の根
+type adjBase struct {
+	q      *Poly
+	lo, hi *big.Rat
+}
+
+// liftAll は真となるセルを最後のレベルまで持ち上げ,
+// セクタに標本点を設定する.
+func (cad *CAD) liftAll() error {
+	n := Level(len(cad.q))
+	stack := []*Cell{cad.root}
+	for len(stack) > 0 {
+		cell := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if cell.lv == n-1 {
+			continue
+		}
+		if cell.children == nil {
+			if cell.truth != t_true {
+				continue
+			}
+			cad.stat.lift[cell.lv+1]++
+			if err := cell.makeStack(cad); err != nil {
+				return err
+			}
+			for _, c := range cell.children {
+				c.truth = t_true
+				cad.stat.true_cell[c.lv]++
+				cad.stat.cell[c.lv]++
+			}
+		}
+		for i, c := range cell.children {
+			if i%2 == 0 {
+				cad.setSamplePoint(cell.children, i)
+			}
+			stack = append(stack, c)
+		}
+	}
+	return nil
+}
+
+// projProduct は level lv の射影因子の積を返す
+func (cad *CAD) projProduct(lv Level) RObj {
+	var p RObj = one
+	for _, pf := range cad.proj[lv].gets() {
+		p = Mul(p, pf.P())
+	}
+	return p
+}
+
+// substFixed は lv より小さいレベルの変数に fixed の値を代入する
+func substFixed(p RObj, fixed []NObj, lv Level) RObj {
+	for i := Level(0); i < lv && int(i) < len(fixed); i++ {
+		if fixed[i] != nil {
+			p = p.Subst(fixed[i], i)
+		}
+	}
+	return p
+}
+
+// ratCoord は cell の座標が有理数ならそれを返す
+func (cell *Cell) ratCoord() (NObj, bool) {
+	if cell.defpoly == nil && cell.intv.inf != nil {
+		return cell.intv.inf, true
+	}
+	return nil, false
+}
+
+func newAdjBase(c *Cell) (*adjBase, bool) {
+	if r, ok := c.ratCoord(); ok {
+		x := toBigRat(r)
+		return &adjBase{lo: x, hi: x}, true
+	}
+	q := c.defpoly
+	if q == nil || q.lv != c.lv || !q.isUnivariate() {
+		return nil, false
+	}
+	b := &adjBase{q: q, lo: new(big.Rat).Set(c.isoBound(false)), hi: new(big.Rat).Set(c.isoBound(true))}
+	for _, x := range []*big.Rat{b.lo, b.hi} {
+		if q.Subst(newRatBig(x), q.lv).IsZero() {
+			return &adjBase{lo: x, hi: x}, true
+		}
+	}
+	return b, true
+}
+
+// refine は分離区間を半分にする
+func (b *adjBase) refine() {
+	if b.q == nil {
+		return
+	}
+	m := new(big.Rat).Add(b.lo, b.hi)
+	m.Quo(m, big.NewRat(2, 1))
+	mm := newRatBig(m)
+	if b.q.Subst(mm, b.q.lv).IsZero() {
+		b.q = nil
+		b.lo = m
+		b.hi = m
+	} else if n, _ := b.q.NumRealRoots(b.q.lv, newRatBig(b.lo), mm); n > 0 {
+		b.hi = m
+	} else {
+		b.lo = m
+	}
+}
+
+// isRoot は a が1変数多項式 f の根かどうかを返す
+func (b *adjBase) isRoot(f *Poly) bool {
+	if b.q == nil {
+		return f.Subst(newRatBig(b.lo), f.lv).IsZero()
+	}
+	g, ok := uniGcd(f, b.q).(*Poly)
+	if !ok {
+		return false
+	}
+	n, _ := g.NumRealRoots(g.lv, newRatBig(b.lo), newRatBig(b.hi))
+	return n > 0
+}
+
+// noRootNear は x_k の多項式 f が a と u の間 (u を含み, a を含まない) に
+// 根をもたないことを確認する. strict なら a も根であってはならない.
+// a の分離区間にある a 以外の根も含めて調べるので, 偽になっても根があるとは限らない.
+func (b *adjBase) noRootNear(f RObj, k Level, u *big.Rat, strict bool) bool {
+	switch ff := f.(type) {
+	case NObj:
+		return !ff.IsZero()
+	case *Poly:
+		if ff.lv != k || !ff.isUnivariate() {
+			return false
+		}
+		if ff.Subst(newRatBig(u), k).IsZero() {
+			return false
+		}
+		root := b.isRoot(ff)
+		if root && strict {
+			return false
+		}
+		lo, hi := b.lo, u
+		left := u.Cmp(b.lo) < 0
+		if left {
+			lo, hi = u, b.hi
+		}
+		// 区間 (lo, hi] に a が含まれるなら, a の分だけ根が多い
+		m := 0
+		if root && (b.q != nil || left) {
+			m = 1
+		}
+		n, err := ff.NumRealRoots(k, newRatBig(lo), newRatBig(hi))
+		return err == nil && n == m
+	}
+	return false
+}
+
+// adjSeparators は C のスタックのセクションを分離する有理数の列を返す.
+// セクションが n 個なら n+1 個で, seps[j] < (j 番目のセクション) < seps[j+1]
+func adjSeparators(cs []*Cell) ([]*big.Rat, bool) {
+	n := len(cs) / 2
+	if n == 0 {
+		return []*big.Rat{toBigRat(cs[0].intv.inf)}, true
+	}
+	seps := make([]*big.Rat, n+1)
+	seps[0] = new(big.Rat).Sub(cs[1].isoBound(false), big.NewRat(1, 1))
+	for j := 1; j < n; j++ {
+		lo := cs[2*j-1].isoBound(true)
+		hi := cs[2*j+1].isoBound(false)
+		if lo.Cmp(hi) >= 0 {
+			return nil, false
+		}
+		seps[j] = simplestRatOpen(lo, hi)
+	}
+	seps[n] = new(big.Rat).Add(cs[2*n-1].isoBound(true), big.NewRat(1, 1))
+	return seps, true
+}
+
+// separate は a の分離区間を u を含まなくなるまで狭める
+func (b *adjBase) separate(u *big.Rat) bool {
+	for i := 0; i < 100 && b.lo.Cmp(u) <= 0 && u.Cmp(b.hi) <= 0; i++ {
+		b.refine()
+	}
+	return u.Cmp(b.lo) < 0 || u.Cmp(b.hi) > 0
+}
+
+// near は a の分離区間の u の側の端点を返す
+func (b *adjBase) near(u *big.Rat) *big.Rat {
+	if u.Cmp(b.hi) > 0 {
+		return b.hi
+	}
+	return b.lo
+}
+
+// approach は x_k を u から a に近づけた u1 を返す.
+// x_k が a と u1 の間 (u1 を含み, a を含まない) を動くとき,
+// checks は根をもたず, stricts は a でも根をもたない.
+func (b *adjBase) approach(k Level, u *big.Rat, checks, stricts []RObj) (*big.Rat, bool) {
+	if !b.separate(u) {
+		return nil, false
+	}
+	fs := make([]RObj, 0, len(checks)+len(stricts))
+	fs = append(fs, checks...)
+	fs = append(fs, stricts...)
+
+	u1 := new(big.Rat).Set(u)
+	for i := 0; i < 100; i++ {
+		ok := true
+		for j, f := range fs {
+			if !b.noRootNear(f, k, u1, j >= len(checks)) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return u1, true
+		}
+		b.refine()
+		u1.Add(u1, b.near(u1))
+		u1.Quo(u1, big.NewRat(2, 1))
+	}
+	return nil, false
+}
+
+// sectionLimits は x_k を u から a に近づけたときの P(x_k, x_m) = 0 の根の極限を
+// 小さい順に返す. 極限が seps[j] と seps[j+1] の間の根なら j,
+// -inf なら -1, +inf なら len(seps)-1.
+// checks は x_k が a と u1 の間を動くときに根をもってはならない x_k の多項式.
+// u1 は実際に根を数えた x_k の値.
+func sectionLimits(P RObj, k, m Level, b *adjBase, u *big.Rat, seps []*big.Rat, checks []RObj) ([]int, *big.Rat, bool) {
+	stricts := make([]RObj, len(seps))
+	for j, s := range seps {
+		stricts[j] = P.Subst(newRatBig(s), m)
+	}
+	u1, ok := b.approach(k, u, checks, stricts)
+	if !ok {
+		return nil, nil, false
+	}
+
+	g := P.Subst(newRatBig(u1), k)
+	lims := []int{}
+	switch gg := g.(type) {
+	case NObj:
+		if gg.IsZero() {
+			return nil, nil, false
+		}
+		return lims, u1, true
+	case *Poly:
+		if gg.lv != m || !gg.isUnivariate() {
+			return nil, nil, false
+		}
+		for j := 0; j <= len(seps); j++ {
+			var lo, hi NObj
+			if j > 0 {
+				lo = newRatBig(seps[j-1])
+			}
+			if j < len(seps) {
+				hi = newRatBig(seps[j])
+			}
+			n, err := gg.NumRealRoots(m, lo, hi)
+			if err != nil {
+				return nil, nil, false
+			}
+			for ; n > 0; n-- {
+				lims = append(lims, j-1)
+			}
+		}
+		return lims, u1, true
+	}
+	return nil, nil, false
+}
+
+// adjFromLimits は D のセクションの極限から, C と D の子供の隣接関係を返す
+func adjFromLimits(cs, ds []*Cell, lims []int) []cellPair {
+	n := len(cs) / 2
+	ret := make([]cellPair, 0)
+	for i, l := range lims {
+		if 0 <= l && l < n {
+			ret = append(ret, cellPair{cs[2*l+1], ds[2*i+1]})
+		}
+	}
+	for i := 0; i <= len(lims); i++ {
+		// D のセクタ i の下端と上端の極限の間にある C のセル
+		lo, hi := 0, 2*n
+		if i > 0 && 2*lims[i-1]+1 > lo {
+			lo = 2*lims[i-1] + 1
+		}
+		if i < len(lims) && 2*lims[i]+1 < hi {
+			hi = 2*lims[i] + 1
+		}
+		for j := lo; j <= hi; j++ {
+			ret = append(ret, cellPair{cs[j], ds[2*i]})
+		}
+	}
+	return ret
+}
+
+func newCadAdj(cad *CAD) *cadAdj {
+	adj := new(cadAdj)
+	adj.cad = cad
+	adj.prod = make([]RObj, len(cad.q))
+	for lv := range adj.prod {
+		adj.prod[lv] = cad.projProduct(Level(lv))
+	}
+	adj.hasTrue = make(map[*Cell]bool)
+	adj.lims = make(map[cellPair][]int)
+	adj.setHasTrue(cad.root)
+	return adj
+}
+
+func (adj *cadAdj) setHasTrue(cell *Cell) bool {
+	ret := cell.truth == t_true
+	for _, c := range cell.children {
+		if adj.setHasTrue(c) {
+			ret = true
+		}
+	}
+	adj.hasTrue[cell] = ret
+	return ret
+}
+
+// siblings は同じスタック内の隣接するセルの組を返す
+func (adj *cadAdj) siblings(cell *Cell) []cellPair {
+	ret := make([]cellPair, 0)
+	cs := cell.children
+	for i := 1; i < len(cs); i += 2 {
+		ret = append(ret, cellPair{cs[i], cs[i-1]}, cellPair{cs[i], cs[i+1]})
+	}
+	return ret
+}
+
+// build は最後のレベルの真となるセルを含む隣接するセルの組を求める.
+func (adj *cadAdj) build() {
+	n := Level(len(adj.cad.q))
+	cells := []*Cell{adj.cad.root}
+	var pairs []cellPair
+	for lv := Level(0); lv < n; lv++ {
+		next := make([]*Cell, 0)
+		npairs := make([]cellPair, 0)
+		for _, cell := range cells {
+			for _, p := range adj.siblings(cell) {
+				if adj.hasTrue[p.c] && adj.hasTrue[p.d] {
+					npairs = append(npairs, p)
+				}
+			}
+			for _, c := range cell.children {
+				if adj.hasTrue[c] {
+					next = append(next, c)
+				}
+			}
+		}
+		for _, p := range pairs {
+			ps, ok := adj.liftPair(p)
+			if !ok {
+				adj.incomplete = true
+				continue
+			}
+			for _, q := range ps {
+				if adj.hasTrue[q.c] && adj.hasTrue[q.d] {
+					npairs = append(npairs, q)
+				}
+			}
+		}
+		cells = next
+		pairs = npairs
+	}
+	adj.pairs = pairs
+}
+
+// liftPair は隣接するセル p.c, p.d の子供の隣接関係を返す.
+// 求められない場合は false
+func (adj *cadAdj) liftPair(p cellPair) ([]cellPair, bool) {
+	lv := p.c.lv
+	cc := make([]*Cell, lv+1)
+	dd := make([]*Cell, lv+1)
+	for c, d := p.c, p.d; c.lv >= 0; c, d = c.parent, d.parent {
+		cc[c.lv] = c
+		dd[d.lv] = d
+	}
+	// C と D は x_k で分かれる
+	k := Level(0)
+	for cc[k] == dd[k] {
+		k++
+	}
+	if cc[k].index%2 == 0 || dd[k].index%2 != 0 {
+		return nil, false
+	}
+	lims, ok := adj.liftPairRat(p, cc, dd, k)
+	if !ok {
+		lims, ok = adj.liftPairAlg(p, cc, dd, k, false)
+	}
+	if !ok {
+		lims, ok = adj.liftPairAlg(p, cc, dd, k, true)
+	}
+	if !ok {
+		return nil, false
+	}
+	adj.lims[p] = lims
+	return adjFromLimits(p.c.children, p.d.children, lims), true
+}
+
+// liftPairRat は x_k 以外の座標が有理数で, C の x_k より上のセルが
+// セクタの場合に, x_k 軸に平行な直線の上で D のセクションの極限を求める.
+func (adj *cadAdj) liftPairRat(p cellPair, cc, dd []*Cell, k Level) ([]int, bool) {
+	lv := p.c.lv
+	fixed := make([]NObj, lv+2)
+	for i := Level(0); i < k; i++ {
+		r, ok := cc[i].ratCoord()
+		if !ok {
+			return nil, false
+		}
+		fixed[i] = r
+	}
+	b, ok := newAdjBase(cc[k])
+	if !ok {
+		return nil, false
+	}
+	// C のセクタの標本点を通り x_k 軸に平行な直線の上で考える
+	checks := make([]RObj, 0)
+	for i := k + 1; i <= lv; i++ {
+		if cc[i].index%2 != 0 {
+			return nil, false
+		}
+		fixed[i] = cc[i].intv.inf
+		checks = append(checks, substFixed(adj.prod[i], fixed, i+1))
+	}
+	seps, ok := adjSeparators(p.c.children)
+	if !ok {
+		return nil, false
+	}
+	m := lv + 1
+	P := substFixed(adj.prod[m], fixed, m)
+	lims, u1, ok := sectionLimits(P, k, m, b, toBigRat(dd[k].intv.inf), seps, checks)
+	if !ok || 2*len(lims)+1 != len(p.d.children) {
+		return nil, false
+	}
+	// 直線が D を通ることを確認する
+	for i := k + 1; i <= lv; i++ {
+		q, ok := substFixed(adj.prod[i], fixed, i).Subst(newRatBig(u1), k).(*Poly)
+		n := 0
+		if ok {
+			if q.lv != i || !q.isUnivariate() {
+				return nil, false
+			}
+			n, _ = q.NumRealRoots(i, nil, fixed[i])
+		}
+		if dd[i].index != uint(2*n) {
+			return nil, false
+		}
+	}
+	return lims, true
+}
+
+// adjCoord は x_k を動かす道の x_i 座標.
+// p = nil なら有理数 r, そうでなければ p(x_0, ..., x_i) = 0 の根.
+// i < k なら C の座標, i > k なら D のセクションか,
+// D のセクタの中で e 番目のセクションから x_k の多項式 off だけずらした点.
+type adjCoord struct {
+	r   NObj
+	p   *Poly
+	e   int
+	off RObj
+}
+
+// vanishing は cell で 0 になる射影因子を返す
+func (adj *cadAdj) vanishing(cell *Cell) *Poly {
+	for j, m := range cell.multiplicity {
+		if m > 0 {
+			return adj.cad.proj[cell.lv].get(uint(j)).P()
+		}
+	}
+	return nil
+}
+
+// elim は f に道の座標を代入し, x_k の多項式を返す.
+// 無理数の座標は終結式で消去するので, 道の上で f が 0 なら結果も 0 になる.
+func (adj *cadAdj) elim(f RObj, path []adjCoord, k Level) (RObj, bool) {
+	for i := Level(len(path)) - 1; i >= 0; i-- {
+		fp, ok := f.(*Poly)
+		if !ok {
+			break
+		}
+		if i == k || !fp.hasVar(i) {
+			continue
+		}
+		if path[i].p == nil {
+			f = fp.Subst(path[i].r, i)
+		} else {
+			f = adj.cad.g.ox.Resultant(path[i].p, fp, i)
+		}
+	}
+	switch ff := f.(type) {
+	case NObj:
+		return f, !ff.IsZero()
+	case *Poly:
+		return f, ff.lv == k && ff.isUnivariate()
+	}
+	return nil, false
+}
+
+// algBase は C の x_k 座標 a を x_k の1変数多項式の根として表す
+func (adj *cadAdj) algBase(c *Cell, path []adjCoord) (*adjBase, bool) {
+	if b, ok := newAdjBase(c); ok {
+		return b, true
+	}
+	f := adj.vanishing(c)
+	if f == nil {
+		return nil, false
+	}
+	g, ok := adj.elim(f, path[:c.lv], c.lv)
+	q, ok2 := g.(*Poly)
+	if !ok || !ok2 {
+		return nil, false
+	}
+	q = uniSqfree(q)
+	for i := 0; i < 10; i++ {
+		lo := newRatBig(c.isoBound(false))
+		hi := newRatBig(c.isoBound(true))
+		if !q.Subst(lo, c.lv).IsZero() && !q.Subst(hi, c.lv).IsZero() {
+			if n, err := q.NumRealRoots(c.lv, lo, hi); err == nil && n == 1 {
+				return &adjBase{q: q, lo: toBigRat(lo), hi: toBigRat(hi)}, true
+			}
+		}
+		c.improveIsoIntv(c.defpoly, true)
+	}
+	return nil, false
+}
+
+// offset は x_k が a と u の間で正となり, a で 0 となる x_k の多項式を返す.
+// u は a の分離区間に含まれない.
+func (b *adjBase) offset(k Level, u *big.Rat) RObj {
+	right := u.Cmp(b.hi) > 0
+	var w RObj
+	if b.q == nil {
+		w = NewPolyCoef(k, newRatBig(new(big.Rat).Neg(b.lo)), one)
+		if !right {
+			w = w.Neg()
+		}
+	} else if b.q.Subst(newRatBig(b.near(u)), k).Sign() < 0 {
+		w = b.q.Neg()
+	} else {
+		w = b.q
+	}
+	return w
+}
+
+// adjCmp はセル c, d の座標を比較する. 分からなければ false
+func (adj *cadAdj) adjCmp(c, d *Cell) (int, bool) {
+	for i := 0; i < 10; i++ {
+		x, okx := c.ratCoord()
+		y, oky := d.ratCoord()
+		if okx && oky {
+			return x.Cmp(y), true
+		}
+		if s, ok := adj.cad.cellcmp(c, d); ok && s != 0 {
+			return s, true
+		}
+		c.improveIsoIntv(c.defpoly, true)
+		d.improveIsoIntv(d.defpoly, false)
+	}
+	return 0, false
+}
+
+// cmpRat はセクション c の座標と有理数 r を比較する
+func (adj *cadAdj) cmpRat(c *Cell, r *big.Rat) (int, bool) {
+	d := &Cell{lv: c.lv}
+	d.intv.inf = newRatBig(r)
+	d.intv.sup = d.intv.inf
+	return adj.adjCmp(c, d)
+}
+
+// stackAt は x_k = u1 とした道の上の点での D のスタックを返す.
+// D のセルを複製して標本点を道の上に移し, 持ち上げる.
+// 道の上の点が D に含まれない場合は false
+func (adj *cadAdj) stackAt(dd []*Cell, k Level, u1 *big.Rat, path []adjCoord) ([]*Cell, bool) {
+	t := new(Cell)
+	*t = *dd[k]
+	t.children = nil
+	t.intv.inf = newRatBig(u1)
+	t.intv.sup = t.intv.inf
+	for i := k + 1; ; i++ {
+		if err := t.makeStack(adj.cad); err != nil {
+			return nil, false
+		}
+		if int(i) == len(dd) {
+			return t.children, true
+		}
+		cs := t.children
+		if len(cs) != len(dd[i-1].children) {
+			return nil, false
+		}
+		idx := int(dd[i].index)
+		if idx%2 != 0 {
+			t = cs[idx]
+			continue
+		}
+
+		// D のセクタに道の上の点を置く
+		s := new(Cell)
+		*s = *cs[idx]
+		if path[i].p == nil {
+			s.intv.inf = path[i].r
+			s.intv.sup = path[i].r
+		} else {
+			e := cs[path[i].e]
+			off := path[i].off.Subst(newRatBig(u1), k).(NObj)
+			if r, ok := e.ratCoord(); ok {
+				s.intv.inf = Add(r, off).(NObj)
+				s.intv.sup = s.intv.inf
+			} else {
+				y := NewPolyVar(i)
+				s.defpoly = e.defpoly.Subst(Sub(y, off), i).(*Poly)
+				s.nintv = e.getNumIsoIntv(53).Add(off.toIntv(53)).(*Interval)
+				s.intv.inf = nil
+				s.intv.sup = nil
+				s.sgn_of_left = e.sgn_of_left
+				s.de = true
+			}
+		}
+		if idx > 0 {
+			if sgn, ok := adj.adjCmp(cs[idx-1], s); !ok || sgn >= 0 {
+				return nil, false
+			}
+		}
+		if idx+1 < len(cs) {
+			if sgn, ok := adj.adjCmp(cs[idx+1], s); !ok || sgn <= 0 {
+				return nil, false
+			}
+		}
+		cs[idx] = s
+		t = s
+	}
+}
+
+// liftPairAlg は C の座標に無理数が含まれる場合などに, D のセクションの極限を求める.
+// x_k を D の標本点から a に近づける道をとる. x_i (i < k) は C の座標に固定する.
+// x_i (i > k) は D がセクションならそれに沿い, セクタなら C の有理数の座標に固定するか,
+// C の座標に収束する D の境界のセクションから少しずらして沿う. shift なら後者を優先する.
+// 無理数の座標は終結式で消去して, 道が D を出ないことと D のセクションが
+// 分離する有理数を横切らないことを確認し, 道の端点の上のスタックで根を数える.
+func (adj *cadAdj) liftPairAlg(p cellPair, cc, dd []*Cell, k Level, shift bool) ([]int, bool) {
+	lv := p.c.lv
+	path := make([]adjCoord, lv+1)
+	for i := Level(0); i < k; i++ {
+		if r, ok := cc[i].ratCoord(); ok {
+			path[i].r = r
+		} else if path[i].p = adj.vanishing(cc[i]); path[i].p == nil {
+			return nil, false
+		}
+	}
+	b, ok := adj.algBase(cc[k], path)
+	if !ok {
+		return nil, false
+	}
+	u := toBigRat(dd[k].intv.inf)
+	if !b.separate(u) {
+		return nil, false
+	}
+	w := b.offset(k, u)
+	checks := []RObj{w}
+	for i := k + 1; i <= lv; i++ {
+		if dd[i].index%2 != 0 {
+			// D のセクションに沿う
+			if cc[i].index%2 == 0 {
+				return nil, false
+			}
+			path[i].p = adj.vanishing(dd[i])
+			if path[i].p == nil {
+				return nil, false
+			}
+			continue
+		}
+		// D のセクタの両端のセクションの極限が C の座標か
+		j := int(dd[i].index / 2)
+		lims, ok := adj.lims[cellPair{cc[i-1], dd[i-1]}]
+		if !ok {
+			return nil, false
+		}
+		lo := j > 0 && 2*lims[j-1]+1 == int(cc[i].index)
+		hi := j < len(lims) && 2*lims[j]+1 == int(cc[i].index)
+		r, rat := cc[i].ratCoord()
+		if rat && (!shift || lo == hi) {
+			path[i].r = r
+		} else if lo != hi {
+			path[i].e = 2*j - 1
+			path[i].off = w
+			if hi {
+				path[i].e = 2*j + 1
+				path[i].off = w.Neg()
+			}
+			e := dd[i-1].children[path[i].e]
+			f := adj.vanishing(e)
+			if f == nil {
+				return nil, false
+			}
+			path[i].p = f.Subst(Sub(NewPolyVar(i), path[i].off), i).(*Poly)
+		} else {
+			return nil, false
+		}
+		f, ok := adj.elim(adj.prod[i], path[:i+1], k)
+		if !ok {
+			return nil, false
+		}
+		checks = append(checks, f)
+	}
+	seps, ok := adjSeparators(p.c.children)
+	if !ok {
+		return nil, false
+	}
+	m := lv + 1
+	stricts := make([]RObj, len(seps))
+	for j, s := range seps {
+		f, ok := adj.elim(adj.prod[m].Subst(newRatBig(s), m), path, k)
+		if !ok {
+			return nil, false
+		}
+		stricts[j] = f
+	}
+
+	var cs []*Cell
+	for i := 0; i < 10 && cs == nil; i++ {
+		u1, ok := b.approach(k, u, checks, stricts)
+		if !ok {
+			return nil, false
+		}
+		cs, _ = adj.stackAt(dd, k, u1, path)
+		// 道の上の点が D に含まれなければ a に近づける
+		u = u1.Add(u1, b.near(u1))
+		u.Quo(u, big.NewRat(2, 1))
+	}
+	if len(cs) != len(p.d.children) {
+		return nil, false
+	}
+	lims := make([]int, 0, len(cs)/2)
+	for i := 1; i < len(cs); i += 2 {
+		j := 0
+		for ; j < len(seps); j++ {
+			sgn, ok := adj.cmpRat(cs[i], seps[j])
+			if !ok {
+				return nil, false
+			} else if sgn < 0 {
+				break
+			}
+		}
+		lims = append(lims, j-1)
+	}
+	return lims, true
+}
+
+// components は最後のレベルの真となるセルを連結成分ごとに分類し,
+// 各セルの代表元を返す
+func (adj *cadAdj) components() map[*Cell]*Cell {
+	uf := make(map[*Cell]*Cell)
+	var find func(c *Cell) *Cell
+	find = func(c *Cell) *Cell {
+		if uf[c] == c {
+			return c
+		}
+		r := find(uf[c])
+		uf[c] = r
+		return r
+	}
+	n := Level(len(adj.cad.q))
+	var walk func(c *Cell)
+	walk = func(c *Cell) {
+		if c.lv == n-1 {
+			if c.truth == t_true {
+				uf[c] = c
+			}
+			return
+		}
+		for _, d := range c.children {
+			walk(d)
+		}
+	}
+	walk(adj.cad.root)
+	for _, p := range adj.pairs {
+		if p.c.truth == t_true && p.d.truth == t_true {
+			uf[find(p.c)] = find(p.d)
+		}
+	}
+	for c := range uf {
+		find(c)
+	}
+	return uf
+}
+
+// cadConnected は qff fof の CAD を構成して持ち上げ, 隣接関係を求める.
+func (g *Ganrac) cadConnected(fof Fof) (*CAD, *cadAdj, error) {
+	if g.ox == nil {
+		return nil, nil, fmt.Errorf("ox is required")
+	}
+	if !fof.IsQff() {
+		return nil, nil, fmt.Errorf("quantifier-free formula is expected")
+	}
+	cad, err := NewCAD(fof, g)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err = cad.Projection(PROJ_McCallum); err != nil {
+		return nil, nil, err
+	}
+	if err = cad.Lift(); err != nil {
+		return nil, nil, err
+	}
+	if err = cad.liftAll(); err != nil {
+		return nil, nil, err
+	}
+	adj := newCadAdj(cad)
+	adj.build()
+	return cad, adj, nil
+}
+
+// NumComponents は fof が真となる領域の連結成分の数を返す.
+func (g *Ganrac) NumComponents(fof Fof) (int, error) {
+	switch fof.(type) {
+	case *AtomT:
+		return 1, nil
+	case *AtomF:
+		return 0, nil
+	}
+	_, adj, err := g.cadConnected(fof)
+	if err != nil {
+		return 0, err
+	}
+	uf := adj.components()
+	n := 0
+	for c, r := range uf {
+		if c == r {
+			n++
+		}
+	}
+	if n > 1 && adj.incomplete {
+		return 0, fmt.Errorf("adjacency of some cells is not determined")
+	}
+	return n, nil
+}
+
+// Connected は fof が真となる領域で, 有理数の点 p と q が連結かどうかを返す.
+// 点の座標は変数のレベルの順.
+func (g *Ganrac) Connected(fof Fof, p, q []NObj) (bool, error) {
+	switch fof.(type) {
+	case *AtomT:
+		return true, nil
+	case *AtomF:
+		return false, nil
+	}
+	cad, adj, err := g.cadConnected(fof)
+	if err != nil {
+		return false, err
+	}
+	cp, err := cad.Locate(p)
+	if err != nil {
+		return false, err
+	}
+	cq, err := cad.Locate(q)
+	if err != nil {
+		return false, err
+	}
+	if cp.truth != t_true || cq.truth != t_true {
+		return false, nil
+	}
+	uf := adj.components()
+	if uf[cp] == uf[cq] {
+		return true, nil
+	}
+	if adj.incomplete {
+		return false, fmt.Errorf("adjacency of some cells is not determined")
+	}
+	return false, nil
+}
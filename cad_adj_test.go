@@ -0,0 +1,135 @@
+package ganrac
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestSectionLimits(t *testing.T) {
+	x := NewPolyVar(0)
+	y := NewPolyVar(1)
+
+	for i, s := range []struct {
+		p      RObj
+		base   *adjBase
+		u      int64
+		seps   []int64
+		expect []int
+	}{
+		// 放物線 y^2 = x: x -> 0+ で両方の枝が y=0 に近づく
+		{Sub(Mul(y, y), x), &adjBase{lo: big.NewRat(0, 1), hi: big.NewRat(0, 1)}, 1, []int64{-1, 1}, []int{0, 0}},
+		{Sub(Mul(y, y), x), &adjBase{lo: big.NewRat(0, 1), hi: big.NewRat(0, 1)}, -1, []int64{-1, 1}, []int{}},
+		// 双曲線 xy = 1: 漸近線
+		{Sub(Mul(x, y), one), &adjBase{lo: big.NewRat(0, 1), hi: big.NewRat(0, 1)}, 1, []int64{0}, []int{0}},
+		{Sub(Mul(x, y), one), &adjBase{lo: big.NewRat(0, 1), hi: big.NewRat(0, 1)}, -1, []int64{0}, []int{-1}},
+		// y (y - x) = 0: 2 本の直線が原点で交わる
+		{Mul(y, Sub(y, x)), &adjBase{lo: big.NewRat(0, 1), hi: big.NewRat(0, 1)}, 1, []int64{-1, 1}, []int{0, 0}},
+		// y^2 = x^2 - 2, a = sqrt(2)
+		{Sub(Mul(y, y), Sub(Mul(x, x), two)), &adjBase{q: Sub(Mul(x, x), two).(*Poly), lo: big.NewRat(1, 1), hi: big.NewRat(2, 1)}, 2, []int64{-1, 1}, []int{0, 0}},
+		// (y - 2)(y + 2)(y^2 - x) の y=2 と y=-2 は極限でもそのまま
+		{Mul(Mul(Sub(y, two), Add(y, two)), Sub(Mul(y, y), x)), &adjBase{lo: big.NewRat(0, 1), hi: big.NewRat(0, 1)}, 1, []int64{-3, -1, 1, 3}, []int{0, 1, 1, 2}},
+	} {
+		seps := make([]*big.Rat, len(s.seps))
+		for j, v := range s.seps {
+			seps[j] = big.NewRat(v, 1)
+		}
+		lims, _, ok := sectionLimits(s.p, 0, 1, s.base, big.NewRat(s.u, 1), seps, nil)
+		if !ok || len(lims) != len(s.expect) {
+			t.Errorf("%d: p=%v, u=%d: expect=%v, actual=%v, ok=%v", i, s.p, s.u, s.expect, lims, ok)
+			continue
+		}
+		for j := range lims {
+			if lims[j] != s.expect[j] {
+				t.Errorf("%d: p=%v, u=%d: expect=%v, actual=%v", i, s.p, s.u, s.expect, lims)
+				break
+			}
+		}
+	}
+}
+
+func TestAdjFromLimits(t *testing.T) {
+	mk := func(n int) []*Cell {
+		cs := make([]*Cell, n)
+		for i := range cs {
+			cs[i] = &Cell{index: uint(i)}
+		}
+		return cs
+	}
+	for i, s := range []struct {
+		nc, nd int
+		lims   []int
+		expect [][2]uint // [c.index, d.index]
+	}{
+		// 放物線 y^2 = x の原点
+		{3, 5, []int{0, 0}, [][2]uint{{1, 1}, {1, 3}, {0, 0}, {1, 0}, {1, 2}, {1, 4}, {2, 4}}},
+		// 双曲線 xy = 1
+		{1, 3, []int{0}, [][2]uint{{0, 0}}},
+		{1, 3, []int{-1}, [][2]uint{{0, 2}}},
+	} {
+		cs := mk(s.nc)
+		ds := mk(s.nd)
+		ps := adjFromLimits(cs, ds, s.lims)
+		if len(ps) != len(s.expect) {
+			t.Errorf("%d: expect=%v, actual=%d pairs", i, s.expect, len(ps))
+			continue
+		}
+		for j, p := range ps {
+			if p.c.index != s.expect[j][0] || p.d.index != s.expect[j][1] {
+				t.Errorf("%d: %d: expect=%v, actual=(%d,%d)", i, j, s.expect[j], p.c.index, p.d.index)
+			}
+		}
+	}
+}
+
+func TestConnected(t *testing.T) {
+	g := NewGANRAC()
+	connc, connd := testConnectOx(g)
+	if g.ox == nil {
+		fmt.Printf("skip TestConnected... (no ox)\n")
+		return
+	}
+	defer connc.Close()
+	defer connd.Close()
+
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"connected(x^2+y^2 > 1 && x^2+y^2 < 4);", "1"},
+		{"connected(x^2+y^2 < 1 || (x-3)^2+y^2 < 1);", "2"},
+		{"connected(x^2+y^2 <= 1 || (x-2)^2+y^2 <= 1);", "1"},
+		{"connected(x*y > 1);", "2"},
+		{"connected(y^2 < x^3 - x);", "2"},
+		{"connected(x^2+y^2+z^2 < 1);", "1"},
+		{"connected(x*y > 1, [1, 2], [2, 1]);", "true"},
+		{"connected(x*y > 1, [1, 2], [-1, -2]);", "false"},
+		{"connected(x*y > 1, [1, 2], [0, 0]);", "false"},
+		{"connected(x^2+y^2 > 1 && x^2+y^2 < 4, [3/2, 0], [-3/2, 0]);", "true"},
+		// 3 変数で, 座標が無理数のセルの上
+		{"connected(2*x^2+y^2+z^2 <= 1 || (x-3)^2+y^2+z^2 <= 1);", "2"},
+		{"connected(2*x^2+y^2+z^2 < 1 || (x-3)^2+y^2+z^2 < 1, [0, 0, 0], [3, 0, 0]);", "false"},
+		{"connected(2*x^2+y^2+z^2 <= 1, [0, 0, 0], [1/2, 0, 0]);", "true"},
+		{"connected(2*x^2 == 1 && y^2+z^2 <= 1);", "2"},
+		{"connected(x^2+y^2+z^2 <= 2 && 3*x^2 >= 1);", "2"},
+		{"connected(x^2+y^2+z^2 >= 2);", "1"},
+	} {
+		c, err := g.Eval(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: %s: %v", i, s.input, err)
+			continue
+		}
+		expect, _ := g.Eval(strings.NewReader(s.expect + ";"))
+		switch cc := c.(type) {
+		case RObj:
+			if !cc.Equals(expect) {
+				t.Errorf("%d: %s: expect=%v, actual=%v", i, s.input, expect, c)
+			}
+		case Fof:
+			if !cc.Equals(expect) {
+				t.Errorf("%d: %s: expect=%v, actual=%v", i, s.input, expect, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package ganrac
+
+// CAD のセルを読み出すための API.
+// セルは根 (R^0) から深さ優先でたどれる. セルの値は変更しないこと.
+
+import (
+	"fmt"
+)
+
+// Root は R^0 に対応する根のセルを返す
+func (cad *CAD) Root() *Cell {
+	return cad.root
+}
+
+// Dim は CAD の変数の数を返す
+func (cad *CAD) Dim() int {
+	return len(cad.q)
+}
+
+// ProjFactors は level lv の射影因子を返す.
+// Cell.Signature() の符号はこの順に並ぶ.
+func (cad *CAD) ProjFactors(lv Level) []*Poly {
+	pfs := cad.proj[lv].gets()
+	ret := make([]*Poly, len(pfs))
+	for i, pf := range pfs {
+		ret[i] = pf.P()
+	}
+	return ret
+}
+
+// Walk は根から深さ優先 (前順) でセルをたどり, fn を呼び出す.
+// fn が false を返すと, そのセルの子供はたどらない.
+func (cad *CAD) Walk(fn func(cell *Cell) bool) {
+	var walk func(cell *Cell)
+	walk = func(cell *Cell) {
+		if !fn(cell) {
+			return
+		}
+		for _, c := range cell.children {
+			walk(c)
+		}
+	}
+	walk(cad.root)
+}
+
+// Locate は有理数の点 p を含むセルを返す. 点の座標は変数のレベルの順.
+// 持ち上げていないセルに含まれる場合は, そのセルを返す
+func (cad *CAD) Locate(p []NObj) (*Cell, error) {
+	if len(p) != len(cad.q) {
+		return nil, fmt.Errorf("the dimension of the point is %d, expected %d", len(p), len(cad.q))
+	}
+	cell := cad.root
+	for lv := Level(0); lv < Level(len(p)) && cell.children != nil; lv++ {
+		idx := 0
+		switch q := substFixed(cad.projProduct(lv), p, lv).(type) {
+		case NObj:
+			if q.IsZero() {
+				return nil, fmt.Errorf("projection factors vanish at the point")
+			}
+		case *Poly:
+			if q.lv != lv || !q.isUnivariate() {
+				return nil, fmt.Errorf("invalid projection factor")
+			}
+			m, err := q.NumRealRoots(lv, nil, nil)
+			if err != nil {
+				return nil, err
+			}
+			if 2*m+1 != len(cell.children) {
+				return nil, fmt.Errorf("projection factors vanish at the point")
+			}
+			n, err := q.NumRealRoots(lv, nil, p[lv])
+			if err != nil {
+				return nil, err
+			}
+			if q.Subst(p[lv], lv).IsZero() {
+				idx = 2*n - 1
+			} else {
+				idx = 2 * n
+			}
+		}
+		cell = cell.children[idx]
+	}
+	return cell, nil
+}
+
+// Level はセルのレベルを返す. 根は -1
+func (cell *Cell) Level() Level {
+	return cell.lv
+}
+
+// Parent は親のセルを返す. 根なら nil
+func (cell *Cell) Parent() *Cell {
+	return cell.parent
+}
+
+// Children は子供のセルを小さい順に返す. 持ち上げていなければ nil
+func (cell *Cell) Children() []*Cell {
+	if cell.children == nil {
+		return nil
+	}
+	ret := make([]*Cell, len(cell.children))
+	copy(ret, cell.children)
+	return ret
+}
+
+// Dim はセルの次元 (インデックスのうちセクタの数) を返す
+func (cell *Cell) Dim() int {
+	n := 0
+	for c := cell; c.lv >= 0; c = c.parent {
+		if !c.isSection() {
+			n++
+		}
+	}
+	return n
+}
+
+// Truth はセルでの論理式の真偽値を返す.
+// 真偽値が決まっていない場合は ok = false
+func (cell *Cell) Truth() (value bool, ok bool) {
+	switch cell.truth {
+	case t_true:
+		return true, true
+	case t_false:
+		return false, true
+	}
+	return false, false
+}
+
+// Signature はセルでの level の射影因子の符号 (-1, 0, 1) を返す.
+// 順序は CAD.ProjFactors(cell.Level()) と同じ
+func (cell *Cell) Signature() []int {
+	ret := make([]int, len(cell.signature))
+	for i, s := range cell.signature {
+		ret[i] = int(s)
+	}
+	return ret
+}
+
+// DefPoly はセクションの定義多項式を返す. セクタなら nil.
+// 係数に下のレベルの変数を含む場合がある.
+func (cell *Cell) DefPoly() *Poly {
+	if !cell.isSection() {
+		return nil
+	}
+	if cell.defpoly != nil {
+		return cell.defpoly
+	}
+	// 有理数 n/d なら d*x - n
+	r := toBigRat(cell.intv.inf)
+	return NewPolyCoef(cell.lv, NewIntZ(r.Num()).Neg(), NewIntZ(r.Denom()))
+}
+
+// Sample は標本点の cell.Level() の座標を返す.
+// 有理数, 1変数の定義多項式をもつ場合は *RealAlg,
+// 定義多項式が多変数の場合は座標を含む *Interval.
+// 標本点が設定されていない (持ち上げていないセクタ) 場合は nil
+func (cell *Cell) Sample() NObj {
+	if cell.lv < 0 {
+		return nil
+	}
+	if r, ok := cell.ratCoord(); ok {
+		return r
+	} else if cell.defpoly == nil {
+		return nil
+	}
+	if cell.defpoly.lv == cell.lv && cell.defpoly.isUnivariate() {
+		lo := newRatBig(cell.isoBound(false))
+		hi := newRatBig(cell.isoBound(true))
+		if x, err := NewRealAlg(cell.defpoly, lo, hi); err == nil {
+			return x
+		}
+	}
+	return cell.getNumIsoIntv(53).clonePrec(53)
+}
+
+// SamplePoint は標本点の座標をレベルの順に返す. 座標は Sample() と同じ.
+func (cell *Cell) SamplePoint() []NObj {
+	ret := make([]NObj, cell.lv+1)
+	for c := cell; c.lv >= 0; c = c.parent {
+		ret[c.lv] = c.Sample()
+	}
+	return ret
+}
@@ -0,0 +1,153 @@
+package ganrac
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCellAPI(t *testing.T) {
+	// x < sqrt(2), x = sqrt(2), x > sqrt(2) の上に y = 1/2 のセクション
+	root := &Cell{lv: -1, truth: t_undef}
+	p := NewPolyInts(0, -2, 0, 1)
+	cs := []*Cell{
+		{lv: 0, index: 0, parent: root, truth: t_false, signature: []sign_t{-1}},
+		{lv: 0, index: 1, parent: root, truth: t_undef, signature: []sign_t{0}, defpoly: p},
+		{lv: 0, index: 2, parent: root, truth: t_true, signature: []sign_t{1}},
+	}
+	cs[0].intv.inf = NewInt(0)
+	cs[0].intv.sup = cs[0].intv.inf
+	cs[1].intv.inf = NewInt(1)
+	cs[1].intv.sup = NewInt(2)
+	cs[2].intv.inf = NewInt(2)
+	cs[2].intv.sup = cs[2].intv.inf
+	root.children = cs
+	ds := []*Cell{
+		{lv: 1, index: 0, parent: cs[1], truth: t_false},
+		{lv: 1, index: 1, parent: cs[1], truth: t_true},
+		{lv: 1, index: 2, parent: cs[1], truth: t_false},
+	}
+	ds[1].intv.inf = NewRatInt64(1, 2)
+	ds[1].intv.sup = ds[1].intv.inf
+	cs[1].children = ds
+	cad := &CAD{root: root, q: []int8{q_free, q_free}}
+
+	n := 0
+	cad.Walk(func(c *Cell) bool {
+		n++
+		return c.lv < 0
+	})
+	if n != 4 {
+		t.Errorf("walk: expect=4, actual=%d", n)
+	}
+	n = 0
+	cad.Walk(func(c *Cell) bool {
+		n++
+		return true
+	})
+	if n != 7 {
+		t.Errorf("walk: expect=7, actual=%d", n)
+	}
+
+	for i, s := range []struct {
+		c     *Cell
+		dim   int
+		truth bool
+		ok    bool
+	}{
+		{root, 0, false, false},
+		{cs[0], 1, false, true},
+		{cs[1], 0, false, false},
+		{cs[2], 1, true, true},
+		{ds[0], 1, false, true},
+		{ds[1], 0, true, true},
+	} {
+		if d := s.c.Dim(); d != s.dim {
+			t.Errorf("%d: dim: expect=%d, actual=%d", i, s.dim, d)
+		}
+		if v, ok := s.c.Truth(); v != s.truth || ok != s.ok {
+			t.Errorf("%d: truth: expect=(%v,%v), actual=(%v,%v)", i, s.truth, s.ok, v, ok)
+		}
+	}
+
+	if sig := cs[2].Signature(); len(sig) != 1 || sig[0] != 1 {
+		t.Errorf("signature: %v", sig)
+	}
+	if q := cs[1].DefPoly(); q != p {
+		t.Errorf("defpoly: expect=%v, actual=%v", p, q)
+	}
+	if q := ds[1].DefPoly(); !q.Equals(NewPolyInts(1, -1, 2)) {
+		t.Errorf("defpoly: expect=2*y-1, actual=%v", q)
+	}
+	if q := ds[0].DefPoly(); q != nil {
+		t.Errorf("defpoly: expect=nil, actual=%v", q)
+	}
+
+	pt := ds[1].SamplePoint()
+	if len(pt) != 2 {
+		t.Errorf("sample: %v", pt)
+	} else if r, ok := pt[0].(*RealAlg); !ok || !Mul(r, r).(NObj).Equals(two) {
+		t.Errorf("sample: expect sqrt(2), actual=%v", pt[0])
+	} else if !pt[1].Equals(NewRatInt64(1, 2)) {
+		t.Errorf("sample: expect 1/2, actual=%v", pt[1])
+	}
+	if x := ds[0].Sample(); x != nil {
+		t.Errorf("sample: expect nil, actual=%v", x)
+	}
+}
+
+func TestCADLocate(t *testing.T) {
+	g := NewGANRAC()
+	connc, connd := testConnectOx(g)
+	if g.ox == nil {
+		fmt.Printf("skip TestCADLocate... (no ox)\n")
+		return
+	}
+	defer connc.Close()
+	defer connd.Close()
+
+	c, err := g.Eval(strings.NewReader("C = cadinit(x^2+y^2 < 1);"))
+	if err != nil {
+		t.Errorf("cadinit: %v", err)
+		return
+	}
+	cad := c.(*CAD)
+	if _, err = cad.Projection(PROJ_McCallum); err != nil {
+		t.Errorf("proj: %v", err)
+		return
+	}
+	if err = cad.Lift(); err != nil {
+		t.Errorf("lift: %v", err)
+		return
+	}
+	for i, s := range []struct {
+		p     []NObj
+		index []uint
+		truth bool
+	}{
+		{[]NObj{zero, zero}, []uint{2, 2}, true},
+		{[]NObj{zero, one}, []uint{2, 3}, false},
+		{[]NObj{one, zero}, []uint{3, 1}, false},
+		{[]NObj{NewRatInt64(1, 2), NewInt(-1)}, []uint{2, 0}, false},
+	} {
+		cell, err := cad.Locate(s.p)
+		if err != nil {
+			t.Errorf("%d: %v: %v", i, s.p, err)
+			continue
+		}
+		// 偽のセルは持ち上げていないことがある
+		idx := cell.Index()
+		for j := range idx {
+			if idx[j] != s.index[j] {
+				t.Errorf("%d: %v: expect=%v, actual=%v", i, s.p, s.index, idx)
+				break
+			}
+		}
+		if v, ok := cell.Truth(); !ok || v != s.truth {
+			t.Errorf("%d: %v: truth expect=%v, actual=(%v,%v)", i, s.p, s.truth, v, ok)
+		}
+	}
+	if _, err = cad.Locate([]NObj{zero}); err == nil {
+		t.Errorf("invalid dimension: error is expected")
+	}
+}
@@ -0,0 +1,246 @@
+package ganrac
+
+// 構築済みの CAD での別の論理式の評価.
+//
+// 論理式の多項式が射影因子の積で表せれば, 射影因子の符号からセルでの真偽値が決まる.
+// 射影や射影因子の追加は行わず, 真偽値の評価と解の論理式の構成だけを行う.
+// 真偽値が決まらないセルが持ち上げられていなければ, 持ち上げる.
+
+import (
+	"fmt"
+)
+
+// projLink は p を射影因子の積で表す. 表せなければエラー
+func (cad *CAD) projLink(p *Poly) (*ProjLink, error) {
+	pl := newProjLink()
+	var q RObj = p
+	for {
+		qq, ok := q.(*Poly)
+		if !ok {
+			break
+		}
+		lv := qq.lv
+		for _, pf := range cad.proj[lv].gets() {
+			m := uint(0)
+			for {
+				qq, ok := q.(*Poly)
+				if !ok || qq.lv != lv {
+					break
+				}
+				if _, _, r := qq.pquorem(pf.P()); !r.IsZero() {
+					break
+				}
+				q = qq.sdiv(pf.P())
+				m++
+			}
+			if m > 0 {
+				pl.addPoly(pf, m)
+			}
+		}
+		if qq, ok := q.(*Poly); ok && qq.lv == lv {
+			return nil, fmt.Errorf("%v is not covered by the projection factors", p)
+		}
+	}
+	if q.Sign() > 0 {
+		pl.op = GT
+	} else {
+		pl.op = LT
+	}
+	return pl, nil
+}
+
+// clone4eval は clone4CAD と同じだが, 射影因子を追加しない
+func (cad *CAD) clone4eval(formula Fof) (Fof, error) {
+	switch fml := formula.(type) {
+	case *FmlAnd:
+		var t Fof = trueObj
+		for _, f := range fml.fml {
+			g, err := cad.clone4eval(f)
+			if err != nil {
+				return nil, err
+			}
+			t = NewFmlAnd(t, g)
+		}
+		return t, nil
+	case *FmlOr:
+		var t Fof = falseObj
+		for _, f := range fml.fml {
+			g, err := cad.clone4eval(f)
+			if err != nil {
+				return nil, err
+			}
+			t = NewFmlOr(t, g)
+		}
+		return t, nil
+	case *Atom:
+		t := new(AtomProj)
+		t.op = fml.op
+		t.p = make([]*Poly, len(fml.p))
+		t.pl = new(ProjLink)
+		t.pl.op = GT
+		for i, poly := range fml.p {
+			pl2, err := cad.projLink(poly)
+			if err != nil {
+				return nil, err
+			}
+			t.pl.merge(pl2)
+			t.p[i] = poly
+		}
+		return t, nil
+	case *AtomT, *AtomF:
+		return fml, nil
+	}
+	return nil, fmt.Errorf("unsupported formula: %v", formula)
+}
+
+// Eval は CAD の射影因子で符号不変な冠頭形の論理式 fml を評価し,
+// 限量子のない論理式を返す. CAD の論理式は fml に置き換わる.
+// エラーのときは CAD を評価前の状態に戻す
+func (cad *CAD) Eval(fml Fof) (Fof, error) {
+	if cad.stage < CAD_STAGE_PROJED {
+		return nil, fmt.Errorf("projection is required")
+	}
+	if int(fml.maxVar()) > len(cad.q) {
+		return nil, fmt.Errorf("too many variables")
+	}
+	q, qff, err := cadQuantifiers(fml, Level(len(cad.q)))
+	if err != nil {
+		return nil, err
+	}
+	f, err := cad.clone4eval(qff)
+	if err != nil {
+		return nil, err
+	}
+
+	// 数値評価で符号を決めた射影因子は, 元の論理式が真になりうる範囲でしか使えない
+	if cad.hasSignedProj() && !cad.inU(qff) {
+		return nil, fmt.Errorf("the formula is not covered by the range of CAD")
+	}
+
+	// エラーのときは CAD を評価前の状態に戻す
+	undo := make([]func(), 0)
+	cad.Walk(func(c *Cell) bool {
+		truth, children := c.truth, c.children
+		undo = append(undo, func() {
+			c.truth = truth
+			c.children = children
+		})
+		return true
+	})
+	qfml, fml0, q0, output, stack, stage, stat := cad.qfml, cad.fml, cad.q, cad.output, cad.stack, cad.stage, cad.stat.clone()
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+		cad.qfml, cad.fml, cad.q, cad.output, cad.stack, cad.stage, cad.stat = qfml, fml0, q0, output, stack, stage, stat
+	}
+
+	cad.qfml = fml
+	cad.fml = f
+	cad.q = q
+	cad.output = nil
+	cad.Walk(func(c *Cell) bool {
+		c.truth = t_undef
+		return true
+	})
+	cad.stack = newCellStack()
+	if err := cad.evalCell(cad.root); err != nil {
+		rollback()
+		return nil, err
+	}
+	cad.stage = CAD_STAGE_LIFTED
+
+	out, err := cad.sfc(false)
+	if err != nil {
+		rollback()
+		return nil, err
+	}
+	cad.output = out
+	return out, nil
+}
+
+// hasSignedProj は数値評価で符号を決めた射影因子があるか
+func (cad *CAD) hasSignedProj() bool {
+	for _, pfs := range cad.proj {
+		for _, pf := range pfs.gets() {
+			if pf.Sign() != 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inU は fml が真になりうる範囲が cad.u に含まれるか
+func (cad *CAD) inU(fml Fof) bool {
+	if cad.u == nil {
+		return false
+	}
+	for lv, u := range cad.fmlU(fml) {
+		if u.inf.Cmp(cad.u[lv].inf) < 0 || u.sup.Cmp(cad.u[lv].sup) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// evalCell は cell の真偽値を決める.
+// 決まらなければ子供の真偽値から決める
+func (cad *CAD) evalCell(cell *Cell) error {
+	if cell.lv >= 0 {
+		switch cell.evalTruth(cad.fml, cad).(type) {
+		case *AtomT:
+			cell.setTruthTree(cad, t_true)
+			return nil
+		case *AtomF:
+			cell.setTruthTree(cad, t_false)
+			return nil
+		}
+		if int(cell.lv) == len(cad.q)-1 {
+			return fmt.Errorf("truth value is not determined at %v", cell.Index())
+		}
+	}
+	if cell.children == nil {
+		if cell.lv >= 0 && !cell.isSection() {
+			cad.setSamplePoint(cell.parent.children, int(cell.index))
+		}
+		cad.stat.lift[cell.lv+1]++
+		if err := cell.makeStack(cad); err != nil {
+			return err
+		}
+		cad.stat.cell[cell.lv+1] += len(cell.children)
+	}
+	qx := cad.q[cell.lv+1]
+	for _, c := range cell.children {
+		if qx >= 0 && cell.truth >= 0 {
+			// 兄弟の真偽値で決まった
+			c.truth = t_other
+			c.set_truth_other()
+			continue
+		}
+		if err := cad.evalCell(c); err != nil {
+			return err
+		}
+		if qx >= 0 && c.truth == qx {
+			cell.truth = qx
+		}
+	}
+	if qx >= 0 && cell.truth < 0 {
+		cell.truth = 1 - qx
+	}
+	return nil
+}
+
+// setTruthTree は cell と, 自由変数の子孫の真偽値を t にする.
+// 限量子のつく変数の子孫は評価しない
+func (cell *Cell) setTruthTree(cad *CAD, t int8) {
+	cell.truth = t
+	for _, c := range cell.children {
+		if cad.q[c.lv] < 0 {
+			c.setTruthTree(cad, t)
+		} else {
+			c.truth = t_other
+			c.set_truth_other()
+		}
+	}
+}
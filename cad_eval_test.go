@@ -0,0 +1,192 @@
+package ganrac
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// makeTestCADEval は射影因子 x-1, y-x の持ち上げ済みの CAD を作る
+func makeTestCADEval(g *Ganrac) *CAD {
+	p := NewPolyInts(0, -1, 1)
+	q := NewPolyCoef(1, NewPolyInts(0, 0, -1), one)
+	cad := &CAD{
+		g:     g,
+		q:     []int8{q_free, q_exists},
+		stage: CAD_STAGE_LIFTED,
+		palgo: PROJ_McCallum,
+	}
+	cad.proj = []ProjFactors{newProjFactorsMC(), newProjFactorsMC()}
+	cad.proj[0].addPoly(p, true).SetIndex(0)
+	cad.proj[1].addPoly(q, true).SetIndex(0)
+	cad.pl4const = make([]*ProjLink, 3)
+	for i, s := range []OP{EQ, GT, LT} {
+		cad.pl4const[i] = newProjLink()
+		cad.pl4const[i].op = s
+	}
+	cad.stat.cell = make([]int, 2)
+	cad.stat.true_cell = make([]int, 2)
+	cad.stat.false_cell = make([]int, 2)
+	cad.stat.lift = make([]int, 2)
+	cad.stat.rlift = make([]int, 2)
+	cad.stat.tm = make([]time.Duration, 3)
+
+	cad.root = &Cell{lv: -1, truth: t_undef}
+	for i := 0; i < 3; i++ {
+		c := &Cell{lv: 0, index: uint(i), parent: cad.root, signature: []sign_t{sign_t(i - 1)}, multiplicity: []mult_t{0}}
+		c.intv.inf = NewInt(int64(i))
+		c.intv.sup = c.intv.inf
+		cad.root.children = append(cad.root.children, c)
+		for j := 0; j < 3; j++ {
+			d := &Cell{lv: 1, index: uint(j), parent: c, signature: []sign_t{sign_t(j - 1)}, multiplicity: []mult_t{0}}
+			d.intv.inf = NewInt(int64(i + j - 1))
+			d.intv.sup = d.intv.inf
+			c.children = append(c.children, d)
+		}
+		c.children[1].multiplicity[0] = 1
+	}
+	cad.root.children[1].multiplicity[0] = 1
+	return cad
+}
+
+func TestCADProjLink(t *testing.T) {
+	g := NewGANRAC()
+	cad := makeTestCADEval(g)
+	for i, s := range []struct {
+		p   string
+		op  OP
+		pfs [][2]int // [lv, multiplicity]
+		err bool
+	}{
+		{"x-1", GT, [][2]int{{0, 1}}, false},
+		{"1-x", LT, [][2]int{{0, 1}}, false},
+		{"-3*(y-x)*(x-1)^2", LT, [][2]int{{1, 1}, {0, 2}}, false},
+		{"(x-y)^3", LT, [][2]int{{1, 3}}, false},
+		{"y+x", GT, nil, true},
+		{"(y-x)*x", GT, nil, true},
+	} {
+		v, err := g.Eval(strings.NewReader(s.p + ";"))
+		if err != nil {
+			t.Fatalf("%d: %s: %v", i, s.p, err)
+		}
+		pl, err := cad.projLink(v.(*Poly))
+		if s.err {
+			if err == nil {
+				t.Errorf("%d: %s: error is expected", i, s.p)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%d: %s: %v", i, s.p, err)
+			continue
+		}
+		if pl.op != s.op || len(pl.projs) != len(s.pfs) {
+			t.Errorf("%d: %s: op=%v, projs=%v", i, s.p, pl.op, pl.projs)
+			continue
+		}
+		for j, pf := range pl.projs {
+			if int(pf.Lv()) != s.pfs[j][0] || int(pl.multiplicity[j]) != s.pfs[j][1] {
+				t.Errorf("%d: %s: [%d] lv=%d, mul=%d", i, s.p, j, pf.Lv(), pl.multiplicity[j])
+			}
+		}
+	}
+}
+
+func TestCADEval(t *testing.T) {
+	g := NewGANRAC()
+	cad := makeTestCADEval(g)
+	for i, s := range []struct {
+		f      string
+		expect []bool // x = 0, 1, 2
+	}{
+		{"ex([y], y-x == 0 && x-1 > 0)", []bool{false, false, true}},
+		{"all([y], y-x > 0 || x-1 <= 0)", []bool{true, true, false}},
+		{"ex([y], y-x > 0 && x-1 < 0)", []bool{true, false, false}},
+		{"all([y], (y-x)^2 >= 0)", []bool{true, true, true}},
+		{"ex([y], x-1 == 0 && y-x < 0)", []bool{false, true, false}},
+	} {
+		f, err := g.Eval(strings.NewReader(s.f + ";"))
+		if err != nil {
+			t.Fatalf("%d: %s: %v", i, s.f, err)
+		}
+		out, err := cad.Eval(f.(Fof))
+		if err != nil {
+			t.Errorf("%d: %s: %v", i, s.f, err)
+			continue
+		}
+		if !out.IsQff() || cad.output != out {
+			t.Errorf("%d: %s: output=%v", i, s.f, out)
+		}
+		for x, e := range s.expect {
+			var v Fof
+			if e {
+				v = trueObj
+			} else {
+				v = falseObj
+			}
+			if r := out.Subst(NewInt(int64(x)), 0); r != v {
+				t.Errorf("%d: %s: x=%d, expect=%v, actual=%v (%v)", i, s.f, x, v, r, out)
+			}
+		}
+	}
+
+	for i, s := range []string{
+		"ex([y], y+x > 0)",
+		"ex([z], z > 0 && x > 1)",
+		"ex([x], x-1 > 0 && y-x > 0)",
+	} {
+		f, err := g.Eval(strings.NewReader(s + ";"))
+		if err != nil {
+			t.Fatalf("%d: %s: %v", i, s, err)
+		}
+		if _, err := cad.Eval(f.(Fof)); err == nil {
+			t.Errorf("%d: %s: error is expected", i, s)
+		}
+	}
+}
+
+func TestCADEvalRollback(t *testing.T) {
+	g := NewGANRAC()
+	cad := makeTestCADEval(g)
+	// x=0 と x=2 のセルの符号を同じにして, 解の論理式を作れなくする
+	c := cad.root.children[2]
+	c.signature[0] = -1
+	for _, d := range c.children {
+		d.signature[0] = 1
+	}
+
+	f, err := g.Eval(strings.NewReader("ex([y], x-1 == 0 && y-x < 0);"))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	out, err := cad.Eval(f.(Fof))
+	if err != nil {
+		t.Fatalf("%v: %v", f, err)
+	}
+	truth := make([]int8, 0)
+	cad.Walk(func(c *Cell) bool {
+		truth = append(truth, c.truth)
+		return true
+	})
+
+	f2, err := g.Eval(strings.NewReader("ex([y], y-x < 0);"))
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if _, err := cad.Eval(f2.(Fof)); err == nil {
+		t.Fatalf("%v: error is expected", f2)
+	}
+	if cad.qfml != f || cad.output != out || cad.stage != CAD_STAGE_LIFTED {
+		t.Errorf("not restored: qfml=%v, output=%v, stage=%d", cad.qfml, cad.output, cad.stage)
+	}
+	i := 0
+	cad.Walk(func(c *Cell) bool {
+		if i >= len(truth) || c.truth != truth[i] {
+			t.Errorf("truth is not restored: %v", c.Index())
+		}
+		i++
+		return true
+	})
+	if i != len(truth) {
+		t.Errorf("cells are not restored: %d != %d", i, len(truth))
+	}
+}
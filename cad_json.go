@@ -0,0 +1,287 @@
+package ganrac
+
+// CAD, 論理式, 統計情報の JSON 出力.
+// セッションの保存 (session.go) とは異なり, 読むための形式である.
+// 多項式と論理式は print() と同じ文字列で, 読み込み直すことができる.
+// 形式は doc/cad.md を参照.
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"time"
+)
+
+type cadInfoJSON struct {
+	Vars        []string          `json:"vars"`
+	Quantifiers []string          `json:"quantifiers"`
+	Input       Fof               `json:"input"`
+	Qff         Fof               `json:"qff"`
+	Output      Fof               `json:"output"`
+	Stage       string            `json:"stage"`
+	Projection  string            `json:"projection"`
+	Proj        [][]*projInfoJSON `json:"proj"`
+	Root        *Cell             `json:"root"`
+	Stat        CADStat           `json:"stat"`
+}
+
+type projInfoJSON struct {
+	Index uint  `json:"index"`
+	Poly  *Poly `json:"poly"`
+	Input bool  `json:"input"`
+	Deg   int   `json:"deg"`
+	Sign  int   `json:"sign"`
+}
+
+type cellInfoJSON struct {
+	Index     []uint          `json:"index"`
+	Level     Level           `json:"level"`
+	Dim       int             `json:"dim"`
+	Truth     *bool           `json:"truth"`
+	Signature []int           `json:"signature"`
+	DefPoly   *Poly           `json:"defpoly,omitempty"`
+	Sample    *sampleInfoJSON `json:"sample,omitempty"`
+	Children  []*Cell         `json:"children"`
+}
+
+// 標本点の座標. 有理数なら value, そうでなければ分離区間 [inf, sup]
+type sampleInfoJSON struct {
+	Value  string  `json:"value,omitempty"`
+	Inf    string  `json:"inf,omitempty"`
+	Sup    string  `json:"sup,omitempty"`
+	Approx float64 `json:"approx"`
+}
+
+type statInfoJSON struct {
+	Time struct {
+		Proj float64 `json:"proj"`
+		Lift float64 `json:"lift"`
+		Sfc  float64 `json:"sfc"`
+	} `json:"time"`
+	Fctr         int              `json:"fctr"`
+	Qrealroot    int              `json:"qrealroot"`
+	Irealroot    int              `json:"irealroot"`
+	IrealrootOk  int              `json:"irealroot_ok"`
+	Sqrt         int              `json:"sqrt"`
+	SqrtOk       int              `json:"sqrt_ok"`
+	Discriminant int              `json:"discriminant"`
+	Resultant    int              `json:"resultant"`
+	Psc          int              `json:"psc"`
+	Precision    int              `json:"precision"`
+	Levels       []*statLevelJSON `json:"levels"`
+}
+
+type statLevelJSON struct {
+	Cell  int `json:"cell"`
+	True  int `json:"true"`
+	False int `json:"false"`
+	Lift  int `json:"lift"`
+	Rlift int `json:"rlift"`
+}
+
+// marshalJSON は json.Marshal と同じだが,
+// 論理式が読みにくくなるので, < > & はエスケープしない
+func marshalJSON(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(b.Bytes(), "\n"), nil
+}
+
+func (z *Poly) MarshalJSON() ([]byte, error) {
+	return marshalJSON(z.String())
+}
+
+func marshalFofJSON(f Fof) ([]byte, error) {
+	return marshalJSON(f.String())
+}
+
+func (p *Atom) MarshalJSON() ([]byte, error) {
+	return marshalFofJSON(p)
+}
+
+func (p *AtomT) MarshalJSON() ([]byte, error) {
+	return marshalFofJSON(p)
+}
+
+func (p *AtomF) MarshalJSON() ([]byte, error) {
+	return marshalFofJSON(p)
+}
+
+func (p *FmlAnd) MarshalJSON() ([]byte, error) {
+	return marshalFofJSON(p)
+}
+
+func (p *FmlOr) MarshalJSON() ([]byte, error) {
+	return marshalFofJSON(p)
+}
+
+func (p *ForAll) MarshalJSON() ([]byte, error) {
+	return marshalFofJSON(p)
+}
+
+func (p *Exists) MarshalJSON() ([]byte, error) {
+	return marshalFofJSON(p)
+}
+
+func (stat CADStat) MarshalJSON() ([]byte, error) {
+	sj := new(statInfoJSON)
+	if len(stat.tm) == 3 {
+		sj.Time.Proj = stat.tm[0].Seconds()
+		sj.Time.Lift = stat.tm[1].Seconds()
+		sj.Time.Sfc = stat.tm[2].Seconds()
+	}
+	sj.Fctr = stat.fctr
+	sj.Qrealroot = stat.qrealroot
+	sj.Irealroot = stat.irealroot
+	sj.IrealrootOk = stat.irealroot_ok
+	sj.Sqrt = stat.sqrt
+	sj.SqrtOk = stat.sqrt_ok
+	sj.Discriminant = stat.discriminant
+	sj.Resultant = stat.resultant
+	sj.Psc = stat.psc
+	sj.Precision = stat.precision
+	sj.Levels = make([]*statLevelJSON, len(stat.cell))
+	for i := range stat.cell {
+		sj.Levels[i] = &statLevelJSON{
+			Cell:  stat.cell[i],
+			True:  stat.true_cell[i],
+			False: stat.false_cell[i],
+			Lift:  stat.lift[i],
+			Rlift: stat.rlift[i],
+		}
+	}
+	return marshalJSON(sj)
+}
+
+func (stat *CADStat) UnmarshalJSON(b []byte) error {
+	sj := new(statInfoJSON)
+	if err := json.Unmarshal(b, sj); err != nil {
+		return err
+	}
+	sec := func(s float64) time.Duration {
+		return time.Duration(math.Round(s * float64(time.Second)))
+	}
+	*stat = CADStat{
+		fctr:         sj.Fctr,
+		qrealroot:    sj.Qrealroot,
+		irealroot:    sj.Irealroot,
+		irealroot_ok: sj.IrealrootOk,
+		sqrt:         sj.Sqrt,
+		sqrt_ok:      sj.SqrtOk,
+		discriminant: sj.Discriminant,
+		resultant:    sj.Resultant,
+		psc:          sj.Psc,
+		precision:    sj.Precision,
+		tm:           []time.Duration{sec(sj.Time.Proj), sec(sj.Time.Lift), sec(sj.Time.Sfc)},
+	}
+	n := len(sj.Levels)
+	stat.cell = make([]int, n)
+	stat.true_cell = make([]int, n)
+	stat.false_cell = make([]int, n)
+	stat.lift = make([]int, n)
+	stat.rlift = make([]int, n)
+	for i, l := range sj.Levels {
+		stat.cell[i] = l.Cell
+		stat.true_cell[i] = l.True
+		stat.false_cell[i] = l.False
+		stat.lift[i] = l.Lift
+		stat.rlift[i] = l.Rlift
+	}
+	return nil
+}
+
+func (cell *Cell) sampleJSON() *sampleInfoJSON {
+	if cell.lv < 0 {
+		return nil
+	}
+	if r, ok := cell.ratCoord(); ok {
+		return &sampleInfoJSON{Value: r.String(), Approx: r.Float()}
+	} else if cell.intv.inf != nil {
+		return &sampleInfoJSON{
+			Inf:    cell.intv.inf.String(),
+			Sup:    cell.intv.sup.String(),
+			Approx: (cell.intv.inf.Float() + cell.intv.sup.Float()) / 2,
+		}
+	} else if cell.nintv != nil {
+		return &sampleInfoJSON{
+			Inf:    cell.nintv.inf.Text('e', 20),
+			Sup:    cell.nintv.sup.Text('e', 20),
+			Approx: cell.nintv.Float(),
+		}
+	}
+	return nil
+}
+
+func (cell *Cell) MarshalJSON() ([]byte, error) {
+	cj := new(cellInfoJSON)
+	cj.Index = cell.Index()
+	cj.Level = cell.lv
+	cj.Dim = cell.Dim()
+	if v, ok := cell.Truth(); ok {
+		cj.Truth = &v
+	}
+	cj.Signature = cell.Signature()
+	if cell.lv >= 0 {
+		cj.DefPoly = cell.DefPoly()
+	}
+	cj.Sample = cell.sampleJSON()
+	cj.Children = cell.children
+	return marshalJSON(cj)
+}
+
+func (cad *CAD) MarshalJSON() ([]byte, error) {
+	cj := new(cadInfoJSON)
+	cj.Vars = make([]string, len(cad.q))
+	cj.Quantifiers = make([]string, len(cad.q))
+	for i, q := range cad.q {
+		cj.Vars[i] = varstr(Level(i))
+		switch q {
+		case q_forall:
+			cj.Quantifiers[i] = "all"
+		case q_exists:
+			cj.Quantifiers[i] = "ex"
+		default:
+			cj.Quantifiers[i] = "free"
+		}
+	}
+	cj.Input = cad.qfml
+	cj.Qff = cad.fml
+	cj.Output = cad.output
+	cj.Stage = []string{"init", "proj", "lift"}[cad.stage]
+	if cad.palgo == PROJ_HONG {
+		cj.Projection = "hong"
+	} else {
+		cj.Projection = "mccallum"
+	}
+	if cad.stage >= CAD_STAGE_PROJED {
+		cj.Proj = make([][]*projInfoJSON, len(cad.proj))
+		for lv, pfs := range cad.proj {
+			cj.Proj[lv] = make([]*projInfoJSON, pfs.Len())
+			for i, pf := range pfs.gets() {
+				cj.Proj[lv][i] = &projInfoJSON{
+					Index: pf.Index(),
+					Poly:  pf.P(),
+					Input: pf.Input(),
+					Deg:   pf.Deg(),
+					Sign:  int(pf.Sign()),
+				}
+			}
+		}
+	}
+	cj.Root = cad.root
+	cj.Stat = cad.stat
+	return marshalJSON(cj)
+}
+
+// FprintJSON は CAD を JSON で出力する
+func (cad *CAD) FprintJSON(b io.Writer) error {
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", " ")
+	return enc.Encode(cad)
+}
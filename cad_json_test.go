@@ -0,0 +1,230 @@
+package ganrac
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFofJSON(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []string{
+		"x^2+3*y-1",
+		"x^2+y^2 < 1",
+		"x > 0 && y <= x",
+		"ex([x], x^2+y*x+1 == 0 || x != y)",
+		"all([x, y], x^2+y^2 >= 0)",
+		"true",
+	} {
+		f, err := g.Eval(strings.NewReader(s + ";"))
+		if err != nil {
+			t.Errorf("%d: %s: %v", i, s, err)
+			continue
+		}
+		b, err := f.(json.Marshaler).MarshalJSON()
+		if err != nil {
+			t.Errorf("%d: %s: marshal %v", i, s, err)
+			continue
+		}
+		var str string
+		if err = json.Unmarshal(b, &str); err != nil {
+			t.Errorf("%d: %s: unmarshal %s: %v", i, s, b, err)
+			continue
+		}
+		if strings.Contains(string(b), "\\u00") {
+			t.Errorf("%d: %s: escaped %s", i, s, b)
+		}
+		h, err := g.Eval(strings.NewReader(str + ";"))
+		if err != nil {
+			t.Errorf("%d: %s: eval %s: %v", i, s, str, err)
+			continue
+		}
+		if !h.(equaler).Equals(f) {
+			t.Errorf("%d: expect=%v, actual=%v", i, f, h)
+		}
+	}
+}
+
+func TestCADStatJSON(t *testing.T) {
+	stat := CADStat{
+		fctr:         3,
+		qrealroot:    4,
+		irealroot:    5,
+		irealroot_ok: 2,
+		sqrt:         1,
+		sqrt_ok:      1,
+		discriminant: 2,
+		resultant:    7,
+		psc:          0,
+		cell:         []int{3, 9},
+		true_cell:    []int{1, 2},
+		false_cell:   []int{2, 7},
+		precision:    53,
+		lift:         []int{1, 3},
+		rlift:        []int{0, 2},
+		tm:           []time.Duration{1500 * time.Millisecond, 3 * time.Second, 250 * time.Microsecond},
+	}
+	b, err := json.Marshal(stat)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var st2 CADStat
+	if err = json.Unmarshal(b, &st2); err != nil {
+		t.Fatalf("unmarshal: %s: %v", b, err)
+	}
+	if !reflect.DeepEqual(stat, st2) {
+		t.Errorf("expect=%v, actual=%v", stat, st2)
+	}
+}
+
+func TestCADJSON(t *testing.T) {
+	g := NewGANRAC()
+	f, err := g.Eval(strings.NewReader("ex([y], x^2-2 == 0 && 2*y-1 == 0);"))
+	if err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+
+	// x < sqrt(2), x = sqrt(2), x > sqrt(2) の上に y = 1/2 のセクション
+	p := NewPolyInts(0, -2, 0, 1)
+	q := NewPolyInts(1, -1, 2)
+	cad := &CAD{
+		qfml:  f.(Fof),
+		fml:   f.(FofQ).Fml(),
+		q:     []int8{q_free, q_exists},
+		stage: CAD_STAGE_LIFTED,
+		palgo: PROJ_McCallum,
+	}
+	cad.proj = []ProjFactors{newProjFactorsMC(), newProjFactorsMC()}
+	cad.proj[0].addPoly(p, true)
+	cad.proj[1].addPoly(q, true)
+	cad.stat.cell = []int{3, 3}
+	cad.stat.true_cell = []int{1, 1}
+	cad.stat.false_cell = []int{2, 2}
+	cad.stat.lift = []int{1, 1}
+	cad.stat.rlift = []int{0, 0}
+	cad.stat.tm = make([]time.Duration, 3)
+	cad.output = NewAtom(p, EQ)
+
+	root := &Cell{lv: -1, truth: t_true}
+	cs := []*Cell{
+		{lv: 0, index: 0, parent: root, truth: t_false, signature: []sign_t{1}},
+		{lv: 0, index: 1, parent: root, truth: t_true, signature: []sign_t{0}, defpoly: p},
+		{lv: 0, index: 2, parent: root, truth: t_false, signature: []sign_t{1}},
+	}
+	cs[0].intv.inf = NewInt(0)
+	cs[0].intv.sup = cs[0].intv.inf
+	cs[1].intv.inf = NewInt(1)
+	cs[1].intv.sup = NewInt(2)
+	cs[2].intv.inf = NewInt(2)
+	cs[2].intv.sup = cs[2].intv.inf
+	root.children = cs
+	ds := []*Cell{
+		{lv: 1, index: 0, parent: cs[1], truth: t_false, signature: []sign_t{-1}},
+		{lv: 1, index: 1, parent: cs[1], truth: t_true, signature: []sign_t{0}},
+		{lv: 1, index: 2, parent: cs[1], truth: t_false, signature: []sign_t{1}},
+	}
+	ds[1].intv.inf = NewRatInt64(1, 2)
+	ds[1].intv.sup = ds[1].intv.inf
+	cs[1].children = ds
+	cad.root = root
+
+	var sb strings.Builder
+	if err = cad.FprintJSON(&sb); err != nil {
+		t.Fatalf("json: %v", err)
+	}
+
+	type cellJ struct {
+		Index     []uint `json:"index"`
+		Level     int    `json:"level"`
+		Dim       int    `json:"dim"`
+		Truth     *bool  `json:"truth"`
+		Signature []int  `json:"signature"`
+		DefPoly   string `json:"defpoly"`
+		Sample    *struct {
+			Value  string  `json:"value"`
+			Inf    string  `json:"inf"`
+			Sup    string  `json:"sup"`
+			Approx float64 `json:"approx"`
+		} `json:"sample"`
+		Children []*cellJ `json:"children"`
+	}
+	var cj struct {
+		Vars        []string `json:"vars"`
+		Quantifiers []string `json:"quantifiers"`
+		Input       string   `json:"input"`
+		Qff         string   `json:"qff"`
+		Output      string   `json:"output"`
+		Stage       string   `json:"stage"`
+		Projection  string   `json:"projection"`
+		Proj        [][]struct {
+			Index uint   `json:"index"`
+			Poly  string `json:"poly"`
+			Input bool   `json:"input"`
+			Deg   int    `json:"deg"`
+		} `json:"proj"`
+		Root *cellJ  `json:"root"`
+		Stat CADStat `json:"stat"`
+	}
+	if err = json.Unmarshal([]byte(sb.String()), &cj); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, sb.String())
+	}
+
+	if !reflect.DeepEqual(cj.Vars, []string{"x", "y"}) || !reflect.DeepEqual(cj.Quantifiers, []string{"free", "ex"}) {
+		t.Errorf("vars: %v %v", cj.Vars, cj.Quantifiers)
+	}
+	if cj.Stage != "lift" || cj.Projection != "mccallum" {
+		t.Errorf("stage: %v %v", cj.Stage, cj.Projection)
+	}
+	for _, s := range []struct {
+		str string
+		f   Fof
+	}{
+		{cj.Input, cad.qfml},
+		{cj.Qff, cad.fml},
+		{cj.Output, cad.output},
+	} {
+		h, err := g.Eval(strings.NewReader(s.str + ";"))
+		if err != nil || !h.(Fof).Equals(s.f) {
+			t.Errorf("fof: expect=%v, actual=%v: %v", s.f, s.str, err)
+		}
+	}
+	if len(cj.Proj) != 2 || len(cj.Proj[0]) != 1 || len(cj.Proj[1]) != 1 {
+		t.Fatalf("proj: %v", cj.Proj)
+	}
+	for lv, pp := range []*Poly{p, q} {
+		pj := cj.Proj[lv][0]
+		h, err := g.Eval(strings.NewReader(pj.Poly + ";"))
+		if err != nil || !pp.Equals(h) || !pj.Input || pj.Deg != pp.Deg(Level(lv)) {
+			t.Errorf("proj[%d]: expect=%v, actual=%v: %v", lv, pp, pj, err)
+		}
+	}
+	if !reflect.DeepEqual(cj.Stat, cad.stat) {
+		t.Errorf("stat: expect=%v, actual=%v", cad.stat, cj.Stat)
+	}
+
+	// セルの木
+	r := cj.Root
+	if r.Level != -1 || len(r.Children) != 3 || r.Truth == nil || !*r.Truth || r.Sample != nil {
+		t.Fatalf("root: %v", r)
+	}
+	if c := r.Children[0]; c.Children != nil || c.Dim != 1 || c.Sample == nil || c.Sample.Value != "0" {
+		t.Errorf("cell[0]: %v", c)
+	}
+	c := r.Children[1]
+	if c.Dim != 0 || len(c.Children) != 3 || c.Sample == nil || c.Sample.Inf != "1" || c.Sample.Sup != "2" {
+		t.Fatalf("cell[1]: %v", c)
+	}
+	if h, err := g.Eval(strings.NewReader(c.DefPoly + ";")); err != nil || !p.Equals(h) {
+		t.Errorf("cell[1]: defpoly expect=%v, actual=%v", p, c.DefPoly)
+	}
+	d := c.Children[1]
+	if !reflect.DeepEqual(d.Index, []uint{1, 1}) || d.Level != 1 || d.Truth == nil || !*d.Truth ||
+		!reflect.DeepEqual(d.Signature, []int{0}) || d.Sample == nil || d.Sample.Value != "1/2" || d.Sample.Approx != 0.5 {
+		t.Errorf("cell[1,1]: %v", d)
+	}
+	if h, err := g.Eval(strings.NewReader(d.DefPoly + ";")); err != nil || !q.Equals(h) {
+		t.Errorf("cell[1,1]: defpoly expect=%v, actual=%v", q, d.DefPoly)
+	}
+}
@@ -0,0 +1,778 @@
+package ganrac
+
+// CAD の保存と復元.
+// 射影因子は [lv, index] で参照する.
+// 持ち上げ中の CAD は, 持ち上げるセルのスタックも保存するので,
+// 復元後に続きから持ち上げられる.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type cadJSON struct {
+	Qfml    interface{}   `json:"qfml"`
+	Fml     interface{}   `json:"fml"`
+	Output  interface{}   `json:"output"`
+	Q       []int8        `json:"q"`
+	Stage   int8          `json:"stage"`
+	Palgo   int           `json:"palgo"`
+	Nwo     bool          `json:"nwo"`
+	Proj    []*projJSON   `json:"proj"`
+	U       []interface{} `json:"u"`
+	Apppoly []interface{} `json:"apppoly"`
+	Root    *cellJSON     `json:"root"`
+	Stack   [][]int       `json:"stack"`
+	Stat    *cadStatJSON  `json:"stat"`
+}
+
+type projJSON struct {
+	Pf        []*projFactorJSON   `json:"pf"`
+	Resultant [][]interface{}     `json:"res,omitempty"`  // McCallum
+	Pscs      [][][][]interface{} `json:"pscs,omitempty"` // Hong
+}
+
+type projFactorJSON struct {
+	P       interface{}     `json:"p"`
+	Index   uint            `json:"index"`
+	Input   bool            `json:"input"`
+	Sgn     sign_t          `json:"sgn"`
+	Coeff   []interface{}   `json:"coeff"`
+	Discrim interface{}     `json:"discrim,omitempty"` // McCallum
+	Psc     [][]interface{} `json:"psc,omitempty"`     // Hong
+}
+
+type cellJSON struct {
+	De        bool        `json:"de"`
+	Vanish    bool        `json:"vanish"`
+	Truth     int8        `json:"truth"`
+	SgnOfLeft sign_t      `json:"sgn_of_left"`
+	Index     uint        `json:"index"`
+	Defpoly   interface{} `json:"defpoly"`
+	Inf       interface{} `json:"inf"`
+	Sup       interface{} `json:"sup"`
+	Nintv     interface{} `json:"nintv"`
+	ExDeg     int         `json:"ex_deg"`
+	Signature []sign_t    `json:"sig"`
+	Multi     []mult_t    `json:"multi"`
+	Children  []*cellJSON `json:"children"`
+}
+
+type cadStatJSON struct {
+	Fctr         int             `json:"fctr"`
+	Qrealroot    int             `json:"qrealroot"`
+	Irealroot    int             `json:"irealroot"`
+	IrealrootOk  int             `json:"irealroot_ok"`
+	Sqrt         int             `json:"sqrt"`
+	SqrtOk       int             `json:"sqrt_ok"`
+	Discriminant int             `json:"discriminant"`
+	Resultant    int             `json:"resultant"`
+	Psc          int             `json:"psc"`
+	Cell         []int           `json:"cell"`
+	TrueCell     []int           `json:"true_cell"`
+	FalseCell    []int           `json:"false_cell"`
+	Precision    int             `json:"precision"`
+	Lift         []int           `json:"lift"`
+	Rlift        []int           `json:"rlift"`
+	Tm           []time.Duration `json:"tm"`
+}
+
+////////////////////////////////////////////////////////////
+// encode
+////////////////////////////////////////////////////////////
+
+func (cad *CAD) encodeProjLink(pl *ProjLink) interface{} {
+	if pl == nil {
+		return nil
+	}
+	for i, c := range cad.pl4const {
+		if c == pl {
+			return []interface{}{"c", i}
+		}
+	}
+	pfs := make([]interface{}, len(pl.projs))
+	for i, pf := range pl.projs {
+		pfs[i] = []interface{}{pf.Lv(), pf.Index()}
+	}
+	return []interface{}{"pl", pl.op, pl.multiplicity, pfs}
+}
+
+func (cad *CAD) encodeProjLinks(pls []*ProjLink) []interface{} {
+	if pls == nil {
+		return nil
+	}
+	ret := make([]interface{}, len(pls))
+	for i, pl := range pls {
+		ret[i] = cad.encodeProjLink(pl)
+	}
+	return ret
+}
+
+func (cad *CAD) encodeProjLinks2(pls [][]*ProjLink) [][]interface{} {
+	if pls == nil {
+		return nil
+	}
+	ret := make([][]interface{}, len(pls))
+	for i, pl := range pls {
+		ret[i] = cad.encodeProjLinks(pl)
+	}
+	return ret
+}
+
+func (cad *CAD) projToJSON() ([]*projJSON, error) {
+	if cad.proj == nil {
+		return nil, nil
+	}
+	ret := make([]*projJSON, len(cad.proj))
+	for lv, pfs := range cad.proj {
+		pj := new(projJSON)
+		ret[lv] = pj
+		pj.Pf = make([]*projFactorJSON, pfs.Len())
+		for i, pf := range pfs.gets() {
+			pfj := new(projFactorJSON)
+			var err error
+			if pfj.P, err = new(gobjEncoder).encodeRObj(pf.P()); err != nil {
+				return nil, err
+			}
+			pfj.Index = pf.Index()
+			pfj.Input = pf.Input()
+			pfj.Sgn = pf.Sign()
+			switch p := pf.(type) {
+			case *ProjFactorMC:
+				pfj.Coeff = cad.encodeProjLinks(p.coeff)
+				pfj.Discrim = cad.encodeProjLink(p.discrim)
+			case *ProjFactorHH:
+				pfj.Coeff = cad.encodeProjLinks(p.coeff)
+				pfj.Psc = cad.encodeProjLinks2(p.psc)
+			}
+			pj.Pf[i] = pfj
+		}
+		switch p := pfs.(type) {
+		case *ProjFactorsMC:
+			pj.Resultant = cad.encodeProjLinks2(p.resultant)
+		case *ProjFactorsHH:
+			pj.Pscs = make([][][][]interface{}, len(p.pscs))
+			for i, ps := range p.pscs {
+				pj.Pscs[i] = make([][][]interface{}, len(ps))
+				for j, pl := range ps {
+					pj.Pscs[i][j] = cad.encodeProjLinks2(pl)
+				}
+			}
+		}
+	}
+	return ret, nil
+}
+
+func (cell *Cell) toJSON() (*cellJSON, error) {
+	enc := new(gobjEncoder)
+	cj := new(cellJSON)
+	cj.De = cell.de
+	cj.Vanish = cell.vanish
+	cj.Truth = cell.truth
+	cj.SgnOfLeft = cell.sgn_of_left
+	cj.Index = cell.index
+	var err error
+	if cell.defpoly != nil {
+		if cj.Defpoly, err = enc.encodeRObj(cell.defpoly); err != nil {
+			return nil, err
+		}
+	}
+	if cell.intv.inf != nil {
+		if cj.Inf, err = enc.encodeRObj(cell.intv.inf); err != nil {
+			return nil, err
+		}
+	}
+	if cell.intv.sup != nil {
+		if cj.Sup, err = enc.encodeRObj(cell.intv.sup); err != nil {
+			return nil, err
+		}
+	}
+	if cell.nintv != nil {
+		if cj.Nintv, err = enc.encodeRObj(cell.nintv); err != nil {
+			return nil, err
+		}
+	}
+	cj.ExDeg = cell.ex_deg
+	cj.Signature = cell.signature
+	cj.Multi = cell.multiplicity
+	if cell.children != nil {
+		cj.Children = make([]*cellJSON, len(cell.children))
+		for i, c := range cell.children {
+			if cj.Children[i], err = c.toJSON(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return cj, nil
+}
+
+// cellPath はルートからの子供の位置の列を返す.
+// 木から外れたセルなら false
+func (cad *CAD) cellPath(cell *Cell) ([]int, bool) {
+	path := make([]int, 0, cell.lv+1)
+	for c := cell; c != cad.root; c = c.parent {
+		if c.parent == nil {
+			return nil, false
+		}
+		idx := -1
+		for i, d := range c.parent.children {
+			if d == c {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, false
+		}
+		path = append(path, idx)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, true
+}
+
+func (cad *CAD) toJSON() (*cadJSON, error) {
+	enc := newGobjEncoder()
+	enc.cad = cad
+
+	cj := new(cadJSON)
+	var err error
+	if cj.Qfml, err = enc.encode(cad.qfml); err != nil {
+		return nil, err
+	}
+	if cj.Fml, err = enc.encode(cad.fml); err != nil {
+		return nil, err
+	}
+	if cad.output != nil {
+		if cj.Output, err = enc.encode(cad.output); err != nil {
+			return nil, err
+		}
+	}
+	cj.Q = cad.q
+	cj.Stage = cad.stage
+	cj.Palgo = int(cad.palgo)
+	cj.Nwo = cad.nwo
+	if cj.Proj, err = cad.projToJSON(); err != nil {
+		return nil, err
+	}
+	for _, u := range cad.u {
+		v, err := enc.encodeRObj(u)
+		if err != nil {
+			return nil, err
+		}
+		cj.U = append(cj.U, v)
+	}
+	for _, p := range cad.apppoly {
+		v, err := enc.encodeRObj(p)
+		if err != nil {
+			return nil, err
+		}
+		cj.Apppoly = append(cj.Apppoly, v)
+	}
+	if cj.Root, err = cad.root.toJSON(); err != nil {
+		return nil, err
+	}
+
+	// 木から外れたセルは, 持ち上げても意味がないので保存しない
+	cj.Stack = make([][]int, 0, len(cad.stack.stack))
+	for _, c := range cad.stack.stack {
+		if path, ok := cad.cellPath(c); ok {
+			cj.Stack = append(cj.Stack, path)
+		}
+	}
+
+	st := &cad.stat
+	cj.Stat = &cadStatJSON{
+		Fctr:         st.fctr,
+		Qrealroot:    st.qrealroot,
+		Irealroot:    st.irealroot,
+		IrealrootOk:  st.irealroot_ok,
+		Sqrt:         st.sqrt,
+		SqrtOk:       st.sqrt_ok,
+		Discriminant: st.discriminant,
+		Resultant:    st.resultant,
+		Psc:          st.psc,
+		Cell:         st.cell,
+		TrueCell:     st.true_cell,
+		FalseCell:    st.false_cell,
+		Precision:    st.precision,
+		Lift:         st.lift,
+		Rlift:        st.rlift,
+		Tm:           st.tm,
+	}
+	return cj, nil
+}
+
+////////////////////////////////////////////////////////////
+// decode
+////////////////////////////////////////////////////////////
+
+func (cad *CAD) decodeProjLink(v interface{}) (*ProjLink, error) {
+	if v == nil {
+		return nil, nil
+	}
+	vs, ok := v.([]interface{})
+	if !ok || len(vs) == 0 {
+		return nil, fmt.Errorf("invalid projlink: %v", v)
+	}
+	if vs[0] == "c" && len(vs) == 2 {
+		i, err := decodeInt(vs[1])
+		if err != nil || i < 0 || i >= len(cad.pl4const) {
+			return nil, fmt.Errorf("invalid projlink: %v", v)
+		}
+		return cad.pl4const[i], nil
+	}
+	if vs[0] != "pl" || len(vs) != 4 {
+		return nil, fmt.Errorf("invalid projlink: %v", v)
+	}
+	pl := newProjLink()
+	op, err := decodeInt(vs[1])
+	if err != nil {
+		return nil, err
+	}
+	pl.op = OP(op)
+	ms, ok1 := vs[2].([]interface{})
+	pfs, ok2 := vs[3].([]interface{})
+	if !ok1 || !ok2 || len(ms) != len(pfs) {
+		return nil, fmt.Errorf("invalid projlink: %v", v)
+	}
+	for i, m := range ms {
+		mi, err := decodeInt(m)
+		if err != nil {
+			return nil, err
+		}
+		idx, err := decodeLevels(pfs[i])
+		if err != nil || len(idx) != 2 {
+			return nil, fmt.Errorf("invalid projlink: %v", v)
+		}
+		pf, err := cad.decodeProjFactorRef(idx[0], int(idx[1]))
+		if err != nil {
+			return nil, err
+		}
+		pl.addPoly(pf, uint(mi))
+	}
+	return pl, nil
+}
+
+func (cad *CAD) decodeProjFactorRef(lv Level, idx int) (ProjFactor, error) {
+	if lv < 0 || int(lv) >= len(cad.proj) || idx < 0 || idx >= cad.proj[lv].Len() {
+		return nil, fmt.Errorf("invalid proj. factor [%d,%d]", lv, idx)
+	}
+	return cad.proj[lv].get(uint(idx)), nil
+}
+
+func (cad *CAD) decodeProjLinks(vs []interface{}) ([]*ProjLink, error) {
+	if vs == nil {
+		return nil, nil
+	}
+	ret := make([]*ProjLink, len(vs))
+	for i, v := range vs {
+		pl, err := cad.decodeProjLink(v)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = pl
+	}
+	return ret, nil
+}
+
+func (cad *CAD) decodeProjLinks2(vs [][]interface{}) ([][]*ProjLink, error) {
+	if vs == nil {
+		return nil, nil
+	}
+	ret := make([][]*ProjLink, len(vs))
+	for i, v := range vs {
+		pl, err := cad.decodeProjLinks(v)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = pl
+	}
+	return ret, nil
+}
+
+func (cad *CAD) projFromJSON(dec *gobjDecoder, pjs []*projJSON) error {
+	if len(pjs) != len(cad.q) {
+		return fmt.Errorf("invalid # of proj")
+	}
+	if cad.palgo != PROJ_McCallum && cad.palgo != PROJ_HONG {
+		return fmt.Errorf("unknown projection %d", cad.palgo)
+	}
+	cad.proj = make([]ProjFactors, len(pjs))
+	for lv := range pjs {
+		if cad.palgo == PROJ_McCallum {
+			cad.proj[lv] = newProjFactorsMC()
+		} else {
+			cad.proj[lv] = newProjFactorsHH()
+		}
+	}
+	cad.pl4const = make([]*ProjLink, 3)
+	for i, s := range []OP{EQ, GT, LT} {
+		cad.pl4const[i] = newProjLink()
+		cad.pl4const[i].op = s
+	}
+
+	// 射影因子を作ってから, リンクを張る
+	for lv, pj := range pjs {
+		for _, pfj := range pj.Pf {
+			p, err := dec.decodePoly(pfj.P)
+			if err != nil {
+				return err
+			}
+			if p.lv != Level(lv) {
+				return fmt.Errorf("invalid level of proj. factor: %v", p)
+			}
+			pf := cad.proj[lv].addPoly(p, pfj.Input)
+			pf.SetIndex(pfj.Index)
+			pf.SetSign(pfj.Sgn)
+		}
+	}
+	for lv, pj := range pjs {
+		var err error
+		for i, pfj := range pj.Pf {
+			switch pf := cad.proj[lv].get(uint(i)).(type) {
+			case *ProjFactorMC:
+				if pf.coeff, err = cad.decodeProjLinks(pfj.Coeff); err != nil {
+					return err
+				}
+				if pf.discrim, err = cad.decodeProjLink(pfj.Discrim); err != nil {
+					return err
+				}
+			case *ProjFactorHH:
+				if pf.coeff, err = cad.decodeProjLinks(pfj.Coeff); err != nil {
+					return err
+				}
+				if pf.psc, err = cad.decodeProjLinks2(pfj.Psc); err != nil {
+					return err
+				}
+			}
+		}
+		switch pfs := cad.proj[lv].(type) {
+		case *ProjFactorsMC:
+			if pfs.resultant, err = cad.decodeProjLinks2(pj.Resultant); err != nil {
+				return err
+			}
+			if pfs.resultant == nil {
+				pfs.resultant = make([][]*ProjLink, 0)
+			}
+		case *ProjFactorsHH:
+			pfs.pscs = make([][][][]*ProjLink, len(pj.Pscs))
+			for i, ps := range pj.Pscs {
+				pfs.pscs[i] = make([][][]*ProjLink, len(ps))
+				for j, pl := range ps {
+					if pfs.pscs[i][j], err = cad.decodeProjLinks2(pl); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func decodeNObj(dec *gobjDecoder, v interface{}) (NObj, error) {
+	if v == nil {
+		return nil, nil
+	}
+	o, err := dec.decode(v)
+	if err != nil {
+		return nil, err
+	}
+	n, ok := o.(NObj)
+	if !ok {
+		return nil, fmt.Errorf("number is expected: %v", v)
+	}
+	return n, nil
+}
+
+func (cad *CAD) cellFromJSON(dec *gobjDecoder, cj *cellJSON, parent *Cell) (*Cell, error) {
+	if cj == nil {
+		return nil, fmt.Errorf("invalid cell")
+	}
+	cell := new(Cell)
+	cell.parent = parent
+	if parent == nil {
+		cell.lv = -1
+	} else {
+		cell.lv = parent.lv + 1
+		if int(cell.lv) >= len(cad.q) {
+			return nil, fmt.Errorf("invalid cell level")
+		}
+	}
+	cell.de = cj.De
+	cell.vanish = cj.Vanish
+	cell.truth = cj.Truth
+	cell.sgn_of_left = cj.SgnOfLeft
+	cell.index = cj.Index
+	cell.ex_deg = cj.ExDeg
+	cell.signature = cj.Signature
+	cell.multiplicity = cj.Multi
+	if cell.lv >= 0 && (cad.proj == nil ||
+		len(cell.signature) != cad.proj[cell.lv].Len() ||
+		len(cell.multiplicity) != cad.proj[cell.lv].Len()) {
+		return nil, fmt.Errorf("invalid signature of cell")
+	}
+
+	var err error
+	if cj.Defpoly != nil {
+		if cell.defpoly, err = dec.decodePoly(cj.Defpoly); err != nil {
+			return nil, err
+		}
+	}
+	if cell.intv.inf, err = decodeNObj(dec, cj.Inf); err != nil {
+		return nil, err
+	}
+	if cell.intv.sup, err = decodeNObj(dec, cj.Sup); err != nil {
+		return nil, err
+	}
+	if cj.Nintv != nil {
+		o, err := dec.decode(cj.Nintv)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if cell.nintv, ok = o.(*Interval); !ok {
+			return nil, fmt.Errorf("interval is expected: %v", cj.Nintv)
+		}
+	}
+	if cj.Children != nil {
+		cell.children = make([]*Cell, len(cj.Children))
+		for i, c := range cj.Children {
+			if cell.children[i], err = cad.cellFromJSON(dec, c, cell); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return cell, nil
+}
+
+func (g *Ganrac) cadFromJSON(cj *cadJSON) (*CAD, error) {
+	cad := new(CAD)
+	cad.g = g
+	cad.q = cj.Q
+	cad.stage = cj.Stage
+	cad.palgo = ProjectionAlgo(cj.Palgo)
+	cad.nwo = cj.Nwo
+	if cad.stage < CAD_STAGE_INITED || cad.stage > CAD_STAGE_LIFTED {
+		return nil, fmt.Errorf("invalid CAD stage %d", cad.stage)
+	}
+
+	dec := &gobjDecoder{g: g, version: SESSION_VERSION, cad: cad}
+	var err error
+	if cj.Proj != nil {
+		if err := cad.projFromJSON(dec, cj.Proj); err != nil {
+			return nil, err
+		}
+	} else if cad.stage >= CAD_STAGE_PROJED {
+		return nil, fmt.Errorf("proj. factors are required")
+	}
+	if cad.qfml, err = dec.decodeFof(cj.Qfml); err != nil {
+		return nil, err
+	}
+	if cad.fml, err = dec.decodeFof(cj.Fml); err != nil {
+		return nil, err
+	}
+	if cj.Output != nil {
+		if cad.output, err = dec.decodeFof(cj.Output); err != nil {
+			return nil, err
+		}
+	}
+	for _, u := range cj.U {
+		o, err := dec.decode(u)
+		if err != nil {
+			return nil, err
+		}
+		intv, ok := o.(*Interval)
+		if !ok {
+			return nil, fmt.Errorf("interval is expected: %v", u)
+		}
+		cad.u = append(cad.u, intv)
+	}
+	for _, p := range cj.Apppoly {
+		q, err := dec.decodePoly(p)
+		if err != nil {
+			return nil, err
+		}
+		cad.apppoly = append(cad.apppoly, q)
+	}
+
+	if cad.root, err = cad.cellFromJSON(dec, cj.Root, nil); err != nil {
+		return nil, err
+	}
+	cad.rootp = NewCellmod(cad.root)
+	cad.stack = newCellStack()
+	for _, path := range cj.Stack {
+		c := cad.root
+		for _, idx := range path {
+			if idx < 0 || idx >= len(c.children) {
+				return nil, fmt.Errorf("invalid cell index %v", path)
+			}
+			c = c.children[idx]
+		}
+		cad.stack.push(c)
+	}
+
+	if st := cj.Stat; st != nil {
+		cad.stat = CADStat{
+			fctr:         st.Fctr,
+			qrealroot:    st.Qrealroot,
+			irealroot:    st.Irealroot,
+			irealroot_ok: st.IrealrootOk,
+			sqrt:         st.Sqrt,
+			sqrt_ok:      st.SqrtOk,
+			discriminant: st.Discriminant,
+			resultant:    st.Resultant,
+			psc:          st.Psc,
+			cell:         st.Cell,
+			true_cell:    st.TrueCell,
+			false_cell:   st.FalseCell,
+			precision:    st.Precision,
+			lift:         st.Lift,
+			rlift:        st.Rlift,
+			tm:           st.Tm,
+		}
+	}
+	n := len(cad.q)
+	for _, s := range []*[]int{&cad.stat.cell, &cad.stat.true_cell, &cad.stat.false_cell, &cad.stat.lift, &cad.stat.rlift} {
+		if len(*s) != n {
+			*s = make([]int, n)
+		}
+	}
+	if len(cad.stat.tm) != 3 {
+		cad.stat.tm = make([]time.Duration, 3)
+	}
+	return cad, nil
+}
+
+////////////////////////////////////////////////////////////
+// file
+////////////////////////////////////////////////////////////
+
+const (
+	CAD_FORMAT  = "ganrac-cad"
+	CAD_VERSION = 1
+)
+
+type cadFileJSON struct {
+	Format  string   `json:"format"`
+	Version int      `json:"version"`
+	Vars    []string `json:"vars"`
+	CAD     *cadJSON `json:"cad"`
+}
+
+// Save writes the CAD with the variable order.
+// The projection factors, the cell tree and
+// the cells to be lifted are saved, so that
+// the lifting can be resumed after LoadCAD.
+func (cad *CAD) Save(w io.Writer) error {
+	cj, err := cad.toJSON()
+	if err != nil {
+		return err
+	}
+	s := new(cadFileJSON)
+	s.Format = CAD_FORMAT
+	s.Version = CAD_VERSION
+	s.Vars = make([]string, len(varlist))
+	for i, v := range varlist {
+		s.Vars[i] = v.v
+	}
+	s.CAD = cj
+	return json.NewEncoder(w).Encode(s)
+}
+
+// SaveFile writes the CAD to fname.
+// A temporary file is renamed to fname, so that
+// the previous checkpoint survives a failure.
+func (cad *CAD) SaveFile(fname string) error {
+	fp, err := os.CreateTemp(filepath.Dir(fname), filepath.Base(fname)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := fp.Name()
+	err = cad.Save(fp)
+	if cerr := fp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, fname)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// LoadCAD restores a CAD saved by CAD.Save.
+// The variable order is replaced if it differs.
+func (g *Ganrac) LoadCAD(r io.Reader) (*CAD, error) {
+	s := new(cadFileJSON)
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+	if s.Format != CAD_FORMAT || s.CAD == nil {
+		return nil, fmt.Errorf("not a CAD file")
+	}
+	if s.Version > CAD_VERSION || s.Version <= 0 {
+		return nil, fmt.Errorf("unsupported CAD version %d", s.Version)
+	}
+	if len(s.Vars) < len(s.CAD.Q) {
+		return nil, fmt.Errorf("too few variables")
+	}
+
+	// 失敗したら元に戻す
+	old := make([]string, len(varlist))
+	same := len(varlist) == len(s.Vars)
+	for i, v := range varlist {
+		old[i] = v.v
+		same = same && v.v == s.Vars[i]
+	}
+	if !same {
+		if err := g.InitVarList(s.Vars); err != nil {
+			return nil, err
+		}
+	}
+	cad, err := g.cadFromJSON(s.CAD)
+	if err != nil {
+		if !same {
+			g.InitVarList(old)
+		}
+		return nil, err
+	}
+	return cad, nil
+}
+
+// LoadCADFile restores the CAD saved in fname.
+func (g *Ganrac) LoadCADFile(fname string) (*CAD, error) {
+	fp, err := os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	return g.LoadCAD(fp)
+}
+
+// SetCheckpoint は持ち上げ中に intv ごとに CAD を fname に保存するようにする.
+// fname が空なら保存しない
+func (cad *CAD) SetCheckpoint(fname string, intv time.Duration) {
+	cad.ckpt = fname
+	cad.ckpt_intv = intv
+}
+
+// checkpoint は前回の保存から ckpt_intv 経過していたら CAD を保存する.
+// force なら経過時間によらず保存する
+func (cad *CAD) checkpoint(force bool) error {
+	if cad.ckpt == "" || (!force && time.Since(cad.ckpt_tm) < cad.ckpt_intv) {
+		return nil
+	}
+	cad.log(1, "cad.checkpoint %s\n", cad.ckpt)
+	if err := cad.SaveFile(cad.ckpt); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	cad.ckpt_tm = time.Now()
+	return nil
+}
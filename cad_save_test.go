@@ -0,0 +1,115 @@
+package ganrac
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCADSave(t *testing.T) {
+	g := NewGANRAC()
+	cad := makeTestCADEval(g)
+	f, err := g.Eval(strings.NewReader("ex([y], y-x > 0 && x-1 < 0);"))
+	if err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	if _, err = cad.Eval(f.(Fof)); err != nil {
+		t.Fatalf("cadeval: %v", err)
+	}
+
+	// 持ち上げ途中: [2] の持ち上げが残っている
+	c := cad.root.children[2]
+	c.children = nil
+	c.truth = t_undef
+	cad.root.truth = t_undef
+	cad.stage = CAD_STAGE_PROJED
+	cad.stack.push(cad.root)
+	cad.stack.push(c)
+
+	b := new(bytes.Buffer)
+	if err = cad.Save(b); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	saved := b.String()
+
+	// 変数順序は保存したものに置き換わる
+	h := NewGANRAC()
+	if err = h.InitVarList([]string{"a", "b", "c"}); err != nil {
+		t.Fatalf("vars: %v", err)
+	}
+	cad2, err := h.LoadCAD(strings.NewReader(saved))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if varlist[0].v != "x" || varlist[1].v != "y" {
+		t.Errorf("vars: %v", varlist)
+	}
+	if cad2.stage != CAD_STAGE_PROJED || len(cad2.stack.stack) != 2 {
+		t.Fatalf("stage=%d, stack=%v", cad2.stage, cad2.stack.stack)
+	}
+	if cad2.stack.pop() != cad2.root.children[2] || cad2.stack.pop() != cad2.root {
+		t.Errorf("stack is broken")
+	}
+	if cad2.root.children[1].truth != t_false || cad2.root.children[0].children[2].truth != t_true || cad2.stat.tm[1] != cad.stat.tm[1] {
+		t.Errorf("cell is broken")
+	}
+	cad2.stack.push(cad2.root)
+	cad2.stack.push(cad2.root.children[2])
+
+	b.Reset()
+	if err = cad2.Save(b); err != nil || b.String() != saved {
+		t.Errorf("save after load: err=%v", err)
+	}
+
+	for i, s := range []string{
+		"",
+		`{"format": "ganrac-session", "version": 1}`,
+		`{"format": "ganrac-cad", "version": 2}`,
+	} {
+		if _, err := h.LoadCAD(strings.NewReader(s)); err == nil {
+			t.Errorf("%d: %s: error is expected", i, s)
+		}
+	}
+}
+
+func TestCADCheckpoint(t *testing.T) {
+	g := NewGANRAC()
+	cad := makeTestCADEval(g)
+	cad.qfml = trueObj
+	cad.fml = trueObj
+	cad.stack = newCellStack()
+	fname := filepath.Join(t.TempDir(), "c.cad")
+
+	// 保存しない
+	if err := cad.checkpoint(true); err != nil {
+		t.Fatalf("checkpoint: %v", err)
+	}
+	if _, err := g.LoadCADFile(fname); err == nil {
+		t.Errorf("checkpoint: unexpected file")
+	}
+
+	cad.SetCheckpoint(fname, time.Hour)
+	cad.ckpt_tm = time.Now()
+	if err := cad.checkpoint(false); err != nil {
+		t.Fatalf("checkpoint: %v", err)
+	}
+	if _, err := g.LoadCADFile(fname); err == nil {
+		t.Errorf("checkpoint: interval is ignored")
+	}
+	for _, force := range []bool{true, false} {
+		cad.ckpt_tm = time.Time{}
+		cad.stat.lift[0]++
+		if err := cad.checkpoint(force); err != nil {
+			t.Fatalf("checkpoint: %v", err)
+		}
+		cad2, err := g.LoadCADFile(fname)
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+		if cad2.stat.lift[0] != cad.stat.lift[0] {
+			t.Errorf("force=%v: lift expect=%d, actual=%d", force, cad.stat.lift[0], cad2.stat.lift[0])
+		}
+	}
+}
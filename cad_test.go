@@ -1,7 +1,9 @@
 package ganrac
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 	"testing"
 )
 
@@ -58,3 +60,54 @@ func TestCADeasy(t *testing.T) {
 		}
 	}
 }
+
+func TestCADLiftContext(t *testing.T) {
+	g := NewGANRAC()
+	cad := makeTestCADEval(g)
+	cad.stage = CAD_STAGE_PROJED
+	cad.stack = newCellStack()
+	c := cad.root.children[2]
+	c.children = nil
+	cad.stack.push(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := cad.LiftContext(ctx); err != context.Canceled {
+		t.Errorf("lift: expect=%v, actual=%v", context.Canceled, err)
+	}
+	if cad.stage != CAD_STAGE_PROJED || len(cad.stack.stack) != 1 || cad.stack.stack[0] != c {
+		t.Errorf("lift: stage=%d, stack=%v", cad.stage, cad.stack.stack)
+	}
+
+	var p *CADProgress
+	cad.SetProgress(func(q *CADProgress) {
+		p = q
+	})
+	cad.stat.cell = []int{3, 6}
+	cad.stat.lift = []int{1, 2}
+	cad.progressed()
+	if p == nil || p.Stage != CAD_STAGE_PROJED ||
+		!reflect.DeepEqual(p.Proj, []int{1, 1}) ||
+		!reflect.DeepEqual(p.Cell, []int{3, 6}) ||
+		!reflect.DeepEqual(p.Lift, []int{1, 2}) {
+		t.Errorf("progress: %v", p)
+	}
+	cad.stat.lift[0]++
+	if p.Lift[0] != 1 {
+		t.Errorf("progress: shared %v", p)
+	}
+
+	for _, s := range []struct {
+		n      int
+		expect bool
+	}{
+		{0, false},
+		{9, false},
+		{8, true},
+	} {
+		cad.SetMaxCells(s.n)
+		if b := cad.tooManyCells(); b != s.expect {
+			t.Errorf("maxcells=%d: expect=%v, actual=%v", s.n, s.expect, b)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"github.com/hiwane/ganrac"
@@ -10,54 +12,13 @@ import (
 	"log"
 	"net"
 	"os"
+	"os/signal"
 	"strings"
 	"time"
 )
 
 var gitCommit string
 
-/*
- * 1文を取得.
- * 入力エラーリカバリが面倒だから１文ずつ処理する
- */
-func get_line(in *bufio.Reader) (string, error) {
-	//	line, err := in.ReadBytes(';')
-	line := make([]rune, 0, 100)
-	in_str := false  // 文字列内
-	in_com := false  // コメント内
-	depth_curly := 0 // 波括弧の深さ
-	for {
-		c, _, err := in.ReadRune()
-		if err != nil {
-			return "", err
-		}
-		line = append(line, c)
-		if in_com {
-			if c == '\n' {
-				in_com = false
-			}
-			continue
-		}
-		if c == '"' {
-			in_str = !in_str
-		} else if in_str {
-			//
-		} else if c == '{' {
-			depth_curly++
-		} else if c == '}' && depth_curly > 0 {
-			depth_curly--
-		} else if c == ';' { // eol
-			break
-		} else if c == ':' && depth_curly <= 0 { // eolq
-			break
-		} else if c == '#' {
-			// 改行まで skip
-			in_com = true
-		}
-	}
-	return string(line), nil
-}
-
 func main() {
 	var (
 		cport       = flag.String("control", "localhost:1234", "ox-asir, control port")
@@ -68,6 +29,8 @@ func main() {
 		ox_verbose  = flag.Bool("ox_verbose", false, "ox_verbose")
 		color       = flag.Bool("color", false, "colored")
 		quiet       = flag.Bool("q", false, "quiet")
+		smt2        = flag.String("smt2", "", "run SMT-LIB2 script and exit")
+		session     = flag.String("session", "", "restore the session from the file and save it after each statement")
 	)
 
 	flag.Usage = func() {
@@ -78,7 +41,7 @@ func main() {
 	flag.Parse()
 
 	in := bufio.NewReader(os.Stdin)
-	if !*quiet {
+	if !*quiet && *smt2 == "" {
 		if gitCommit == "" {
 			fmt.Printf("GaNRAC. see help();\n")
 		} else {
@@ -127,12 +90,35 @@ func main() {
 
 	logger.Printf("START!!!!")
 	g.Eval(strings.NewReader(fmt.Sprintf("verbose(%d,%d);", *verbose, *cad_verbose)))
+	if *smt2 != "" {
+		fp, err := os.Open(*smt2)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+			os.Exit(1)
+		}
+		defer fp.Close()
+		if err := g.ExecSMT2(fp, os.Stdout); err != nil {
+			fmt.Printf("(error \"%s\")\n", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+	if *session != "" {
+		if err := g.LoadSessionFile(*session); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "session %s: %s\n", *session, err)
+			os.Exit(1)
+		}
+	}
+
+	// Ctrl-C は評価中の文を中断する
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
 	for {
 		if _, err := os.Stdout.WriteString("> "); err != nil {
 			fmt.Fprintf(os.Stderr, "WriteString: %s", err)
 			break
 		}
-		line, err := get_line(in)
+		line, err := ganrac.ReadStatement(in)
 		if err == io.EOF {
 			return
 		}
@@ -141,13 +127,51 @@ func main() {
 			continue
 		}
 
-		p, err := g.Eval(strings.NewReader(string(line)))
-		if err != nil {
+		p, err := evalInterruptible(g, sig, string(line))
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintf(os.Stderr, "interrupted\n")
+			continue
+		} else if err != nil {
 			fmt.Fprintf(os.Stderr, "error: %s\n", err)
 			continue
 		}
 		if p != nil {
 			fmt.Println(p)
 		}
+		if *session != "" {
+			if err := g.SaveSessionFile(*session); err != nil {
+				fmt.Fprintf(os.Stderr, "session %s: %s\n", *session, err)
+			}
+		}
+	}
+}
+
+func evalInterruptible(g *ganrac.Ganrac, sig chan os.Signal, line string) (interface{}, error) {
+	// 入力中の Ctrl-C は捨てる
+	select {
+	case <-sig:
+	default:
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-sig:
+			cancel()
+		case <-done:
+			return
+		}
+		// 中断を確認しない組み込み関数の評価中なら, もう一度の Ctrl-C で終了する
+		fmt.Fprintf(os.Stderr, "interrupting... (Ctrl-C again to quit)\n")
+		select {
+		case <-sig:
+			fmt.Fprintf(os.Stderr, "quit\n")
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+	return g.EvalContext(ctx, strings.NewReader(line))
 }
@@ -0,0 +1,267 @@
+package ganrac
+
+// 1変数整数係数多項式の複素根の分離.
+//
+// 長方形の内部にある根の数を偏角の原理で数える.
+// 辺上で p = R + I*i とすると, 根の数は各辺での R/I の Cauchy 指数の和の半分.
+// Cauchy 指数は Sturm 列で厳密に計算する.
+// 頂点が座標軸上に写る場合や辺上に根がある場合は分割点をずらす.
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// cbox は長方形 [x0, x1] x [y0, y1] とその内部の根の数
+type cbox struct {
+	x0, x1, y0, y1 *big.Rat
+	n              int
+}
+
+type croot struct {
+	p  *Poly // 無平方な整数係数多項式. 変数は level 0
+	cs []*big.Rat
+}
+
+// eval は p(x+yi) を返す
+func (cr *croot) eval(x, y *big.Rat) (*big.Rat, *big.Rat) {
+	re := new(big.Rat)
+	im := new(big.Rat)
+	t := new(big.Rat)
+	for i := len(cr.cs) - 1; i >= 0; i-- {
+		// (re + im i) * (x + y i) + c
+		u := new(big.Rat).Mul(re, x)
+		u.Sub(u, t.Mul(im, y))
+		u.Add(u, cr.cs[i])
+		im.Add(new(big.Rat).Mul(re, y), t.Mul(im, x))
+		re = u
+	}
+	return re, im
+}
+
+// edge は Im z = c (horizontal) または Re z = c 上での p の実部と虚部を返す.
+// パラメータは level 0 の変数
+func (cr *croot) edge(c *big.Rat, horizontal bool) (RObj, RObj) {
+	s := NewPolyVar(0)
+	cc := newRatBig(c)
+	var re, im RObj = zero, zero
+	for i := len(cr.p.c) - 1; i >= 0; i-- {
+		a := cr.p.c[i]
+		if horizontal {
+			// (re + im i) * (s + c i)
+			re, im = Add(Sub(Mul(re, s), Mul(im, cc)), a), Add(Mul(re, cc), Mul(im, s))
+		} else {
+			// (re + im i) * (c + s i)
+			re, im = Add(Sub(Mul(re, cc), Mul(im, s)), a), Add(Mul(re, s), Mul(im, cc))
+		}
+	}
+	return re, im
+}
+
+// index は辺上での R/I の区間 (lo, hi] での Cauchy 指数を返す.
+// 辺上に根があるなど計算できない場合は false
+func (cr *croot) index(c, lo, hi *big.Rat, horizontal bool) (int, bool) {
+	re, im := cr.edge(c, horizontal)
+	ip, ok := im.(*Poly)
+	if !ok {
+		// 虚部が定数
+		return 0, !im.IsZero()
+	}
+	a := newRatBig(lo)
+	b := newRatBig(hi)
+	// 辺上の根は R と I の共通根
+	var g RObj = ip
+	if rp, ok := re.(*Poly); ok {
+		g = uniGcd(rp, ip)
+	} else if !re.IsZero() {
+		g = re
+	}
+	if gp, ok := g.(*Poly); ok {
+		if n, _ := gp.NumRealRoots(0, a, b); n > 0 {
+			return 0, false
+		}
+	}
+	n, err := CauchyIndex(re, ip, 0, a, b)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// count は長方形の内部の根の数を返す.
+// 頂点が座標軸に写るか, 辺上に根がある場合は false
+func (cr *croot) count(b *cbox) (int, bool, error) {
+	for _, x := range []*big.Rat{b.x0, b.x1} {
+		for _, y := range []*big.Rat{b.y0, b.y1} {
+			re, im := cr.eval(x, y)
+			if re.Sign() == 0 || im.Sign() == 0 {
+				return 0, false, nil
+			}
+		}
+	}
+	n := 0
+	for _, e := range []struct {
+		c, lo, hi  *big.Rat
+		horizontal bool
+		sgn        int
+	}{
+		{b.y0, b.x0, b.x1, true, +1},  // 下: 左から右
+		{b.x1, b.y0, b.y1, false, +1}, // 右: 下から上
+		{b.y1, b.x0, b.x1, true, -1},  // 上: 右から左
+		{b.x0, b.y0, b.y1, false, -1}, // 左: 上から下
+	} {
+		m, ok := cr.index(e.c, e.lo, e.hi, e.horizontal)
+		if !ok {
+			return 0, false, nil
+		}
+		n += e.sgn * m
+	}
+	if n%2 != 0 || n < 0 {
+		return 0, false, fmt.Errorf("invalid winding number %d/2: p=%v", n, cr.p)
+	}
+	return n / 2, true, nil
+}
+
+// split は b を4つに分割する.
+func (cr *croot) split(b *cbox) ([]*cbox, error) {
+	half := big.NewRat(1, 2)
+	mx := new(big.Rat).Add(b.x0, b.x1)
+	mx.Mul(mx, half)
+	my := new(big.Rat).Add(b.y0, b.y1)
+	my.Mul(my, half)
+	dx := new(big.Rat).Sub(b.x1, b.x0)
+	dx.Quo(dx, big.NewRat(64, 1))
+	dy := new(big.Rat).Sub(b.y1, b.y0)
+	dy.Quo(dy, big.NewRat(64, 1))
+
+	// 分割点の候補: 中点 + i*dx, 中点 + j*dy  (i, j = 0, -1, 1, -2, 2, ...)
+	offset := func(i int) *big.Rat {
+		k := big.NewRat(int64((i+1)/2), 1)
+		if i%2 == 1 {
+			k.Neg(k)
+		}
+		return k
+	}
+	for i := 0; i < 16; i++ {
+		x := new(big.Rat).Add(mx, new(big.Rat).Mul(offset(i), dx))
+		for j := 0; j < 16; j++ {
+			y := new(big.Rat).Add(my, new(big.Rat).Mul(offset(j), dy))
+			ret := []*cbox{
+				{x0: b.x0, x1: x, y0: b.y0, y1: y},
+				{x0: x, x1: b.x1, y0: b.y0, y1: y},
+				{x0: b.x0, x1: x, y0: y, y1: b.y1},
+				{x0: x, x1: b.x1, y0: y, y1: b.y1},
+			}
+			n := 0
+			ok := true
+			for _, c := range ret {
+				var err error
+				if c.n, ok, err = cr.count(c); err != nil {
+					return nil, err
+				} else if !ok {
+					break
+				}
+				n += c.n
+			}
+			if ok && n == b.n {
+				return ret, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("failed to split a box")
+}
+
+// complexRoots は1変数多項式 p の相異なる複素根を分離する長方形を返す.
+// 長方形の頂点は2進有理数で, 実部の小さい順に並べる.
+// prec が nil でなければ, 長方形の幅と高さを 2^prec 以下にする.
+func (p *Poly) complexRoots(prec *int) ([]*cbox, error) {
+	if !p.isUnivariate() {
+		return nil, fmt.Errorf("not a univariate polynomial")
+	}
+	q := uniSqfree(uniLv(p, 0))
+	cr := &croot{p: q, cs: make([]*big.Rat, len(q.c))}
+	for i, c := range q.c {
+		n, ok := c.(NObj)
+		if !ok {
+			return nil, fmt.Errorf("not a univariate polynomial")
+		}
+		cr.cs[i] = toBigRat(n)
+	}
+
+	// 根の上界 1 + max |c_i/c_d| より大きい 2 のべき
+	d := len(cr.cs) - 1
+	bound := new(big.Rat)
+	for i := 0; i < d; i++ {
+		t := new(big.Rat).Quo(cr.cs[i], cr.cs[d])
+		if t.Abs(t).Cmp(bound) > 0 {
+			bound = t
+		}
+	}
+	bound.Add(bound, big.NewRat(1, 1))
+	r := big.NewRat(1, 1)
+	for r.Cmp(bound) <= 0 {
+		r.Mul(r, big.NewRat(2, 1))
+	}
+	var b *cbox
+	for k := 0; ; k++ {
+		// 頂点が座標軸に写る場合は頂点をずらす
+		mr := new(big.Rat).Neg(r)
+		dr := new(big.Rat).Mul(r, big.NewRat(int64(k%16), 64))
+		b = &cbox{
+			x0: new(big.Rat).Sub(mr, dr),
+			x1: new(big.Rat).Add(r, new(big.Rat).Add(dr, dr)),
+			y0: new(big.Rat).Sub(mr, new(big.Rat).Mul(dr, big.NewRat(3, 1))),
+			y1: r}
+		var ok bool
+		var err error
+		if b.n, ok, err = cr.count(b); err != nil {
+			return nil, err
+		} else if ok {
+			break
+		}
+		if k%16 == 15 {
+			r.Mul(r, big.NewRat(2, 1))
+		}
+	}
+	if b.n != d {
+		return nil, fmt.Errorf("%d roots are found in the box, but deg=%d: p=%v", b.n, d, q)
+	}
+
+	var eps *big.Rat
+	if prec != nil {
+		if *prec >= 0 {
+			eps = new(big.Rat).SetInt(new(big.Int).Lsh(big.NewInt(1), uint(*prec)))
+		} else {
+			eps = new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Lsh(big.NewInt(1), uint(-*prec)))
+		}
+	}
+
+	ret := make([]*cbox, 0, d)
+	stack := []*cbox{b}
+	for len(stack) > 0 {
+		b = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if b.n == 0 {
+			continue
+		}
+		if b.n == 1 && (eps == nil ||
+			(new(big.Rat).Sub(b.x1, b.x0).Cmp(eps) <= 0 &&
+				new(big.Rat).Sub(b.y1, b.y0).Cmp(eps) <= 0)) {
+			ret = append(ret, b)
+			continue
+		}
+		bs, err := cr.split(b)
+		if err != nil {
+			return nil, err
+		}
+		stack = append(stack, bs...)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if c := ret[i].x0.Cmp(ret[j].x0); c != 0 {
+			return c < 0
+		}
+		return ret[i].y0.Cmp(ret[j].y0) < 0
+	})
+	return ret, nil
+}
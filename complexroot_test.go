@@ -0,0 +1,90 @@
+package ganrac
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestComplexRoot(t *testing.T) {
+	x := NewPolyVar(0)
+	s2 := math.Sqrt(2) / 2
+	for i, s := range []struct {
+		p     RObj
+		prec  int
+		roots []complex128
+	}{
+		{Add(Mul(x, x), one), -4, []complex128{1i, -1i}},
+		{Sub(Mul(Mul(x, x), x), Mul(two, x)), -8, []complex128{0, math.Sqrt2, -math.Sqrt2}},
+		{Add(Mul(Mul(x, x), Mul(x, x)), one), -6, []complex128{
+			complex(s2, s2), complex(s2, -s2), complex(-s2, s2), complex(-s2, -s2)}},
+		{Mul(Add(Add(Mul(x, x), x), one), Add(Add(Mul(x, x), x), one)), -5, []complex128{
+			complex(-0.5, math.Sqrt(3)/2), complex(-0.5, -math.Sqrt(3)/2)}},
+		{Mul(Sub(Mul(x, x), two), Add(Mul(x, x), two)), -10, []complex128{
+			complex(math.Sqrt2, 0), complex(-math.Sqrt2, 0),
+			complex(0, math.Sqrt2), complex(0, -math.Sqrt2)}},
+	} {
+		p := s.p.(*Poly)
+		prec := s.prec
+		bs, err := p.complexRoots(&prec)
+		if err != nil {
+			t.Errorf("%d: p=%v: err=%s", i, p, err)
+			continue
+		}
+		if len(bs) != len(s.roots) {
+			t.Errorf("%d: p=%v: expect=%d, actual=%d boxes", i, p, len(s.roots), len(bs))
+			continue
+		}
+		eps := math.Ldexp(1, prec)
+		for j, b := range bs {
+			x0, _ := b.x0.Float64()
+			x1, _ := b.x1.Float64()
+			y0, _ := b.y0.Float64()
+			y1, _ := b.y1.Float64()
+			if x1-x0 > eps || y1-y0 > eps {
+				t.Errorf("%d: p=%v: box %d is too large: %v", i, p, j, b)
+			}
+			n := 0
+			for _, r := range s.roots {
+				if x0 <= real(r) && real(r) <= x1 && y0 <= imag(r) && imag(r) <= y1 {
+					n++
+				}
+			}
+			if n != 1 {
+				t.Errorf("%d: p=%v: box %d contains %d roots: %v", i, p, j, n, b)
+			}
+			if j > 0 && bs[j-1].x0.Cmp(b.x0) > 0 {
+				t.Errorf("%d: p=%v: not sorted", i, p)
+			}
+		}
+	}
+}
+
+func TestComplexRootCount(t *testing.T) {
+	// x^5-x+1 の根は1つの実根と2組の共役複素根
+	x := NewPolyVar(0)
+	p := Add(Sub(Mul(Mul(x, x), Mul(Mul(x, x), x)), x), one).(*Poly)
+	cr := &croot{p: p, cs: []*big.Rat{
+		big.NewRat(1, 1), big.NewRat(-1, 1), new(big.Rat), new(big.Rat), new(big.Rat), big.NewRat(1, 1)}}
+	for i, s := range []struct {
+		x0, x1, y0, y1 int64
+		expect         int
+	}{
+		{-3, 3, -3, 3, 5},
+		{-3, 3, 1, 3, 1},
+		{-3, 0, -1, 1, 1},
+		{-3, 3, -5, -3, 0},
+		{0, 3, 1, 3, 0},
+		{0, 3, -3, 3, 2},
+	} {
+		b := &cbox{
+			x0: big.NewRat(2*s.x0+1, 4), x1: big.NewRat(2*s.x1+1, 4),
+			y0: big.NewRat(2*s.y0+1, 4), y1: big.NewRat(2*s.y1+1, 4)}
+		n, ok, err := cr.count(b)
+		if err != nil || !ok {
+			t.Errorf("%d: count failed: %v: %v", i, b, err)
+		} else if n != s.expect {
+			t.Errorf("%d: expect=%d, actual=%d", i, s.expect, n)
+		}
+	}
+}
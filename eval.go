@@ -3,6 +3,7 @@ package ganrac
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -25,8 +26,18 @@ func (g *Ganrac) evalStack(stack *pStack) (interface{}, error) {
 		return g.evalStackName(stack, s)
 	case unaryminus:
 		return g.evalStackRObj1(stack, s)
-	case and, or:
+	case and, or, impl, repl, equiv:
 		return g.evalStackFof2(stack, s)
+	case not:
+		o, err := g.evalStack(stack)
+		if err != nil {
+			return nil, err
+		}
+		f, ok := o.(Fof)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected FOF", s.str)
+		}
+		return f.Not(), nil
 	case geop:
 		return g.evalStackAtom(stack, GE, s)
 	case gtop:
@@ -48,6 +59,9 @@ func (g *Ganrac) evalStack(stack *pStack) (interface{}, error) {
 	case lb: // []
 		return g.evalStackElem(stack, s)
 	case number:
+		if strings.ContainsAny(s.str, ".eE") {
+			return ParseDecimal(s.str)
+		}
 		bi := ParseInt(s.str, 10)
 		if bi != nil {
 			return bi, nil
@@ -84,6 +98,27 @@ func (g *Ganrac) evalStack(stack *pStack) (interface{}, error) {
 		o, err := g.evalStack(stack)
 		g.addHisto(o)
 		return o, err
+	case block:
+		return g.evalStackBlock(stack, s)
+	case k_if:
+		return g.evalStackIf(stack, s)
+	case k_while:
+		return g.evalStackWhile(stack, s)
+	case k_for:
+		return g.evalStackFor(stack, s)
+	case k_def:
+		return g.evalStackDef(stack, s)
+	case k_return:
+		var o interface{}
+		if s.extra > 0 {
+			o, err = g.evalStack(stack)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, &evalCtrl{k_return, o}
+	case k_break, k_continue:
+		return nil, &evalCtrl{s.cmd, nil}
 	}
 	return nil, fmt.Errorf("unsupported [str=%s, cmd=%d]", s.str, s.cmd)
 }
@@ -97,6 +132,30 @@ func (g *Ganrac) evalStackAtom(stack *pStack, op OP, node pNode) (Fof, error) {
 	if !ok {
 		return nil, fmt.Errorf("%s is not supported", node.str)
 	}
+	if stack.Len() > 0 && isCmpNode(stack.v[stack.Len()-1].cmd) {
+		// 0 < x < 1 は 0 < x && x < 1
+		ls := stack.PopTree()
+		ms := ls.Clone()
+		ms.Pop()
+		ms = ms.PopTree()
+		left, err := g.evalStack(ls)
+		if err != nil {
+			return nil, err
+		}
+		mid, err := g.evalStack(ms)
+		if err != nil {
+			return nil, err
+		}
+		l, ok := left.(Fof)
+		m, ok2 := mid.(RObj)
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("%s is not supported", node.str)
+		}
+		if err := checkRealAlg(m, r); err != nil {
+			return nil, err
+		}
+		return NewFmlAnd(l, NewAtom(Sub(m, r), op)), nil
+	}
 	left, err := g.evalStack(stack)
 	if err != nil {
 		return nil, err
@@ -105,11 +164,22 @@ func (g *Ganrac) evalStackAtom(stack *pStack, op OP, node pNode) (Fof, error) {
 	if !ok {
 		return nil, fmt.Errorf("%s is not supported", node.str)
 	}
+	if err := checkRealAlg(l, r); err != nil {
+		return nil, err
+	}
 
 	a := NewAtom(Sub(l, r), op)
 	return a, nil
 }
 
+func isCmpNode(cmd int) bool {
+	switch cmd {
+	case ltop, gtop, leop, geop, eqop, neop:
+		return true
+	}
+	return false
+}
+
 func (g *Ganrac) evalInitVar(stack *pStack, num int) (interface{}, error) {
 	if num == 0 {
 		v := NewList()
@@ -164,6 +234,12 @@ func (g *Ganrac) evalStackFof2(stack *pStack, node pNode) (interface{}, error) {
 		return NewFmlAnd(l, r), nil
 	case or:
 		return NewFmlOr(l, r), nil
+	case impl:
+		return FofImpl(l, r), nil
+	case repl:
+		return FofImpl(r, l), nil
+	case equiv:
+		return FofEquiv(l, r), nil
 	}
 	return nil, fmt.Errorf("%s is not supported", node.str)
 }
@@ -185,6 +261,9 @@ func (g *Ganrac) evalStackRObj2(stack *pStack, node pNode) (interface{}, error)
 	if !ok {
 		return nil, fmt.Errorf("%s is not supported", node.str)
 	}
+	if err := checkRealAlg(l, r); err != nil {
+		return nil, err
+	}
 	switch node.cmd {
 	case plus:
 		return Add(l, r), nil
@@ -194,22 +273,24 @@ func (g *Ganrac) evalStackRObj2(stack *pStack, node pNode) (interface{}, error)
 		return Mul(l, r), nil
 	case pow:
 		c, ok := r.(*Int)
-		if !ok || c.Sign() < 0 {
+		if !ok {
 			return nil, fmt.Errorf("%s is not supported", node.str)
 		}
 		if c.IsZero() && l.IsZero() {
 			return nil, fmt.Errorf("0^0 is not defined")
 		}
+		if c.Sign() < 0 {
+			if isZeroDivisor(l) {
+				return nil, fmt.Errorf("divide by zero")
+			}
+			return divRObj(one, l.Pow(c.Neg().(*Int))), nil
+		}
 		return l.Pow(c), nil
 	case div:
-		c, ok := r.(NObj)
-		if !ok {
-			return nil, fmt.Errorf("%s is not supported", node.str)
-		}
-		if c.IsZero() {
+		if isZeroDivisor(r) {
 			return nil, fmt.Errorf("divide by zero")
 		}
-		return l.Div(c), nil
+		return divRObj(l, r), nil
 	}
 	return nil, fmt.Errorf("%s is not supported", node.str)
 }
@@ -289,7 +370,7 @@ func (g *Ganrac) evalStackAssign(stack *pStack, node pNode) (interface{}, error)
 
 	s, err := stack.Pop()
 	if s.cmd == name {
-		g.varmap[s.str] = vv
+		g.setVar(s.str, vv)
 		return vv, nil
 	} else if s.cmd != lb {
 		return nil, fmt.Errorf("invalid assignment")
@@ -346,6 +427,11 @@ func (g *Ganrac) evalStackVarHist(stack *pStack, node pNode) (interface{}, error
 }
 
 func (g *Ganrac) evalStackName(stack *pStack, node pNode) (interface{}, error) {
+	if len(g.locals) > 0 {
+		if v, ok := g.locals[len(g.locals)-1][node.str]; ok {
+			return v, nil
+		}
+	}
 	v, ok := g.varmap[node.str]
 	if !ok {
 		return zero, nil
@@ -381,3 +467,193 @@ func (g *Ganrac) evalStackElem(stack *pStack, node pNode) (interface{}, error) {
 
 	return nil, fmt.Errorf("index is not supported: p=%v, idx=%v", pp, idx)
 }
+
+////////////////////////////////////////////////////////////
+// 制御構文
+////////////////////////////////////////////////////////////
+
+// return, break, continue を呼び出し元に伝える
+type evalCtrl struct {
+	cmd int
+	v   interface{}
+}
+
+func (e *evalCtrl) Error() string {
+	switch e.cmd {
+	case k_return:
+		return "return outside function"
+	case k_break:
+		return "break outside loop"
+	default:
+		return "continue outside loop"
+	}
+}
+
+// 関数内なら局所変数に代入する
+func (g *Ganrac) setVar(name string, v interface{}) {
+	if len(g.locals) > 0 {
+		g.locals[len(g.locals)-1][name] = v
+	} else {
+		g.varmap[name] = v
+	}
+}
+
+func (g *Ganrac) evalStackBlock(stack *pStack, node pNode) (interface{}, error) {
+	for _, st := range stack.PopTrees(node.extra) {
+		if _, err := g.evalStack(st); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (g *Ganrac) evalCond(stack *pStack, node pNode) (bool, error) {
+	c, err := g.evalStack(stack)
+	if err != nil {
+		return false, err
+	}
+	switch c.(type) {
+	case *AtomT:
+		return true, nil
+	case *AtomF:
+		return false, nil
+	}
+	return false, fmt.Errorf("%s: condition should be true or false: %v", node.str, c)
+}
+
+// loopCtrl はループ本体の評価結果を処理する.
+// ループを抜けるなら true
+func loopCtrl(err error) (bool, error) {
+	if e, ok := err.(*evalCtrl); ok {
+		switch e.cmd {
+		case k_break:
+			return true, nil
+		case k_continue:
+			return false, nil
+		}
+	}
+	return err != nil, err
+}
+
+func (g *Ganrac) evalStackIf(stack *pStack, node pNode) (interface{}, error) {
+	sts := stack.PopTrees(node.extra)
+	c, err := g.evalCond(sts[0], node)
+	if err != nil {
+		return nil, err
+	}
+	if c {
+		return g.evalStack(sts[1])
+	} else if len(sts) > 2 {
+		return g.evalStack(sts[2])
+	}
+	return nil, nil
+}
+
+func (g *Ganrac) evalStackWhile(stack *pStack, node pNode) (interface{}, error) {
+	sts := stack.PopTrees(2)
+	for {
+		if err := g.context().Err(); err != nil {
+			return nil, err
+		}
+		c, err := g.evalCond(sts[0].Clone(), node)
+		if err != nil {
+			return nil, err
+		}
+		if !c {
+			return nil, nil
+		}
+		_, err = g.evalStack(sts[1].Clone())
+		if brk, err := loopCtrl(err); brk {
+			return nil, err
+		}
+	}
+}
+
+func (g *Ganrac) evalStackFor(stack *pStack, node pNode) (interface{}, error) {
+	v, err := stack.Pop()
+	if err != nil {
+		return nil, err
+	}
+	sts := stack.PopTrees(2)
+	o, err := g.evalStack(sts[0])
+	if err != nil {
+		return nil, err
+	}
+	lst, ok := o.(*List)
+	if !ok {
+		return nil, fmt.Errorf("%s: expected list: %v", node.str, o)
+	}
+	// 本体でリストが変更されても影響をうけないようにする
+	elems := make([]GObj, len(lst.v))
+	copy(elems, lst.v)
+	for _, e := range elems {
+		if err := g.context().Err(); err != nil {
+			return nil, err
+		}
+		g.setVar(v.str, e)
+		_, err = g.evalStack(sts[1].Clone())
+		if brk, err := loopCtrl(err); brk {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+////////////////////////////////////////////////////////////
+// ユーザ定義関数
+////////////////////////////////////////////////////////////
+
+const max_call_depth = 1000
+
+type userFunc struct {
+	name string
+	args []string
+	body *pStack
+}
+
+func (g *Ganrac) evalStackDef(stack *pStack, node pNode) (interface{}, error) {
+	for _, f := range g.builtin_func_table {
+		if f.name == node.str {
+			return nil, fmt.Errorf("%s is reserved", node.str)
+		}
+	}
+	body := stack.PopTree()
+	f := &userFunc{name: node.str, body: body, args: make([]string, node.extra)}
+	for i := node.extra - 1; i >= 0; i-- {
+		a, err := stack.Pop()
+		if err != nil {
+			return nil, err
+		}
+		for j := i + 1; j < node.extra; j++ {
+			if f.args[j] == a.str {
+				return nil, fmt.Errorf("%s(): %s is duplicated", node.str, a.str)
+			}
+		}
+		f.args[i] = a.str
+	}
+	g.user_func[node.str] = f
+	return nil, nil
+}
+
+func (g *Ganrac) callUserFunc(f *userFunc, args []interface{}) (interface{}, error) {
+	if len(args) != len(f.args) {
+		return nil, fmt.Errorf("%s(): expected %d arguments, but %d", f.name, len(f.args), len(args))
+	}
+	if len(g.locals) >= max_call_depth {
+		return nil, fmt.Errorf("%s(): too deep recursion", f.name)
+	}
+	local := make(map[string]interface{}, len(args))
+	for i, a := range f.args {
+		local[a] = args[i]
+	}
+	g.locals = append(g.locals, local)
+	defer func() { g.locals = g.locals[:len(g.locals)-1] }()
+
+	_, err := g.evalStack(f.body.Clone())
+	if e, ok := err.(*evalCtrl); ok && e.cmd == k_return {
+		return e.v, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
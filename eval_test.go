@@ -1,8 +1,11 @@
 package ganrac
 
 import (
+	"context"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestEvalRobj(t *testing.T) {
@@ -37,6 +40,13 @@ func TestEvalRobj(t *testing.T) {
 		{"(x^2+3*x+1)+(-x^2+5*x+8);", NewPolyCoef(0, 9, 8)},
 		{"(x^2+3*x+1)+(-x^2-3*x+8);", NewInt(9)},
 		{"(x^2+3*x+1)+(-x^2-3*x-1);", zero},
+		{"0.25;", NewRatInt64(1, 4)},
+		{"2.50;", NewRatInt64(5, 2)},
+		{"1.;", one},
+		{"1e3;", NewInt(1000)},
+		{"15E-1;", NewRatInt64(3, 2)},
+		{"1.5e+2*x;", NewPolyCoef(0, 0, 150)},
+		{"0.001 - 1e-3;", zero},
 	} {
 		u, err := g.Eval(strings.NewReader(s.input))
 		if err != nil && s.expect != nil {
@@ -203,3 +213,164 @@ func TestEvalFof(t *testing.T) {
 		}
 	}
 }
+
+func TestEvalSyntax(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"!(x > 0);", "not(x > 0)"},
+		{"!x > 0 && y < 0;", "not(x > 0) && y < 0"},
+		{"x > 0 ==> y > 0;", "impl(x > 0, y > 0)"},
+		{"x > 0 <== y > 0;", "impl(y > 0, x > 0)"},
+		{"x > 0 <==> y > 0 || z < 0;", "equiv(x > 0, y > 0 || z < 0)"},
+		{"x > 0 ==> y > 0 ==> z > 0;", "impl(x > 0, impl(y > 0, z > 0))"},
+		{"0 < x < 1;", "0 < x && x < 1"},
+		{"0 <= x < y+1 <= 1;", "0 <= x && x < y+1 && y+1 <= 1"},
+		{"x ≤ 1 ∧ y ≥ 0 ∨ ¬(z ≠ 0);", "x <= 1 && y >= 0 || not(z != 0)"},
+		{"x > 0 ⇒ y > 0 ⇔ z > 0;", "equiv(impl(x > 0, y > 0), z > 0)"},
+		{"∀x (x^2 + y ≥ 0);", "all([x], x^2 + y >= 0)"},
+		{"∃x,y ∀z (x*z ≤ y) && z > 0;", "ex([x,y], all([z], x*z <= y)) && z > 0"},
+		{"x /* comment; */ + /**/ 1 > 0 /* x\n*/;", "x + 1 > 0"},
+	} {
+		u, err := g.Eval(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		v, err := g.Eval(strings.NewReader(s.expect + ";"))
+		if err != nil {
+			t.Errorf("%d: expect=%s: err=%s", i, s.expect, err)
+			continue
+		}
+		if f, ok := u.(Fof); !ok || !f.Equals(v) {
+			t.Errorf("%d: input=%s: expect=%v, actual=%v", i, s.input, v, u)
+		}
+	}
+
+	for i, s := range []string{
+		"1 /* 2;",
+		"!x;",
+		"∀x x > 0;",
+	} {
+		if _, err := g.Eval(strings.NewReader(s)); err == nil {
+			t.Errorf("%d: input=%s: error is expected", i, s)
+		}
+	}
+}
+
+func TestEvalCtrl(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"def fib(N) { if (N <= 1) { return N; } return fib(N-1) + fib(N-2); };", ""},
+		{"fib(20);", "6765"},
+		{"S = 0;", "0"},
+		{"for (I in range(1, 11)) { if (I == 5) { continue; } S = S + I; };", ""},
+		{"S;", "50"},
+		{"I = 0;", "0"},
+		{"while (true) { I = I + 1; if (I >= 7) { break; } };", ""},
+		{"I;", "7"},
+		{"L = [];", "[]"},
+		{"for (I in [x, y, z]) { L = [I, L]; };", ""},
+		{"L;", "[z, [y, [x, []]]]"},
+		{"if (1 > 2) { A = 1; } else if (2 > 3) { A = 2; } else { A = 3; };", ""},
+		{"A;", "3"},
+		{"if (A == 3) { A = 4; };", ""},
+		{"A;", "4"},
+
+		// 局所変数
+		{"def f(X) { A = X^2; return A + 1; };", ""},
+		{"f(x+1);", "x^2+2*x+2"},
+		{"A;", "4"},
+		{"def g(X) { return A + X; };", ""}, // 大域変数は参照できる
+		{"g(1);", "5"},
+		{"def h() { for (I in range(3)) { return I + 10; } };", ""},
+		{"h();", "10"},
+		{"def h() { };", ""},
+		{"h();", ""},
+	} {
+		u, err := g.Eval(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			return
+		}
+		if u == nil && s.expect != "" || u != nil && fmt.Sprintf("%v", u) != s.expect {
+			t.Errorf("%d: input=%s: expect=%s, actual=%v", i, s.input, s.expect, u)
+		}
+	}
+
+	for i, s := range []string{
+		"break;",
+		"if (x > 0) { 1; };",
+		"for (I in 3) { };",
+		"while (true) { return 1; };",
+		"def f(X, X) { };",
+		"def len(X) { };",
+		"def f(X) { return X; }; f(1, 2);",
+		"def f(X) { return f(X); }; f(1);",
+		"def f(X) { break; }; f(1);",
+		"def f(x) { };",
+		"if (true) 1;",
+		"return 1;",
+	} {
+		var err error
+		stmts := strings.SplitAfter(s, "}; ")
+		for _, stmt := range stmts {
+			if _, err = g.Eval(strings.NewReader(stmt)); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			t.Errorf("%d: input=%s: error is expected", i, s)
+		}
+	}
+	if len(g.locals) != 0 {
+		t.Errorf("locals are not released: %d", len(g.locals))
+	}
+
+	// range()
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"range(3);", "[0, 1, 2]"},
+		{"range(0);", "[]"},
+		{"range(2, 5);", "[2, 3, 4]"},
+		{"range(10, 0, -3);", "[10, 7, 4, 1]"},
+		{"range(1, 3, -1);", "[]"},
+	} {
+		u, err := g.Eval(strings.NewReader(s.input))
+		if err != nil || fmt.Sprintf("%v", u) != s.expect {
+			t.Errorf("%d: input=%s: expect=%s, actual=%v, err=%v", i, s.input, s.expect, u, err)
+		}
+	}
+}
+
+func TestEvalContext(t *testing.T) {
+	g := NewGANRAC()
+	for _, s := range []string{
+		"def f() { while (true) { } };",
+		"I = 0;",
+	} {
+		if _, err := g.Eval(strings.NewReader(s)); err != nil {
+			t.Fatalf("input=%s: %v", s, err)
+		}
+	}
+	for i, s := range []string{
+		"while (true) { };",
+		"for (I in range(1000000)) { };",
+		"f();",
+		"while (true) { I = I + 1; };",
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, err := g.EvalContext(ctx, strings.NewReader(s))
+		cancel()
+		if err != context.DeadlineExceeded {
+			t.Errorf("%d: input=%s: expect=%v, actual=%v", i, s, context.DeadlineExceeded, err)
+		}
+	}
+}
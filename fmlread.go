@@ -0,0 +1,385 @@
+package ganrac
+
+// 他システム (QEPCAD B, Redlog) の入力ファイル用の共通部分.
+// 字句解析と, 変数順序が決まるまで論理式を保持する構文木
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	fmlTokEOF = iota
+	fmlTokNum
+	fmlTokIdent
+	fmlTokOp
+)
+
+type fmlToken struct {
+	kind int
+	s    string
+	line int
+}
+
+type fmlScanner struct {
+	r       *bufio.Reader
+	line    int
+	ops     []string // 長いもの順
+	comment rune     // 行コメント. 0 ならなし
+	fold    bool     // 識別子を小文字にする
+	tok     fmlToken // 先読み
+	pend    []fmlToken
+	name    string   // for error message
+}
+
+func newFmlScanner(r io.Reader, name string, ops []string) *fmlScanner {
+	sc := new(fmlScanner)
+	sc.r = bufio.NewReader(r)
+	sc.line = 1
+	sc.name = name
+	sc.ops = make([]string, len(ops))
+	copy(sc.ops, ops)
+	sort.SliceStable(sc.ops, func(i, j int) bool {
+		return len(sc.ops[i]) > len(sc.ops[j])
+	})
+	return sc
+}
+
+func (sc *fmlScanner) errorf(line int, format string, a ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", sc.name, line, fmt.Sprintf(format, a...))
+}
+
+func (sc *fmlScanner) readRune() (rune, error) {
+	c, _, err := sc.r.ReadRune()
+	if c == '\n' {
+		sc.line++
+	}
+	return c, err
+}
+
+func (sc *fmlScanner) unreadRune(c rune) {
+	sc.r.UnreadRune()
+	if c == '\n' {
+		sc.line--
+	}
+}
+
+func (sc *fmlScanner) skipSpace() (rune, error) {
+	for {
+		c, err := sc.readRune()
+		if err != nil {
+			return 0, err
+		}
+		if c == sc.comment && c != 0 {
+			for c != '\n' {
+				if c, err = sc.readRune(); err != nil {
+					return 0, err
+				}
+			}
+		} else if !unicode.IsSpace(c) {
+			return c, nil
+		}
+	}
+}
+
+// skipUntil は end が出現するまで読み飛ばす.
+func (sc *fmlScanner) skipUntil(end rune) error {
+	for {
+		c, err := sc.readRune()
+		if err == io.EOF {
+			return sc.errorf(sc.line, "`%c` is expected", end)
+		} else if err != nil {
+			return err
+		}
+		if c == end {
+			return nil
+		}
+	}
+}
+
+// unget は tok を押し戻す. 次の next() で現在のトークンに戻る.
+func (sc *fmlScanner) unget(tok fmlToken) {
+	sc.pend = append([]fmlToken{sc.tok}, sc.pend...)
+	sc.tok = tok
+}
+
+// next reads the next token into sc.tok.
+func (sc *fmlScanner) next() error {
+	if len(sc.pend) > 0 {
+		sc.tok = sc.pend[0]
+		sc.pend = sc.pend[1:]
+		return nil
+	}
+	c, err := sc.skipSpace()
+	if err == io.EOF {
+		sc.tok = fmlToken{fmlTokEOF, "", sc.line}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	line := sc.line
+	var b strings.Builder
+	if '0' <= c && c <= '9' {
+		for '0' <= c && c <= '9' {
+			b.WriteRune(c)
+			if c, err = sc.readRune(); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			sc.unreadRune(c)
+		}
+		sc.tok = fmlToken{fmlTokNum, b.String(), line}
+		return nil
+	}
+	if unicode.IsLetter(c) || c == '_' {
+		for unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' {
+			b.WriteRune(c)
+			if c, err = sc.readRune(); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			sc.unreadRune(c)
+		}
+		s := b.String()
+		if sc.fold {
+			s = strings.ToLower(s)
+		}
+		sc.tok = fmlToken{fmlTokIdent, s, line}
+		return nil
+	}
+
+	sc.unreadRune(c)
+	buf, _ := sc.r.Peek(4)
+	for _, op := range sc.ops {
+		if strings.HasPrefix(string(buf), op) {
+			sc.r.Discard(len(op))
+			sc.tok = fmlToken{fmlTokOp, op, line}
+			return nil
+		}
+	}
+	return sc.errorf(line, "unexpected character `%c`", c)
+}
+
+func (sc *fmlScanner) isOp(op string) bool {
+	return sc.tok.kind == fmlTokOp && sc.tok.s == op
+}
+
+func (sc *fmlScanner) expect(op string) error {
+	if !sc.isOp(op) {
+		return sc.unexpected(op)
+	}
+	return sc.next()
+}
+
+func (sc *fmlScanner) unexpected(expect string) error {
+	if sc.tok.kind == fmlTokEOF {
+		return sc.errorf(sc.tok.line, "unexpected EOF. `%s` is expected", expect)
+	}
+	return sc.errorf(sc.tok.line, "unexpected `%s`. `%s` is expected", sc.tok.s, expect)
+}
+
+////////////////////////////////////////////////////////////
+// 構文木
+////////////////////////////////////////////////////////////
+
+const (
+	fmlNodeNum = iota
+	fmlNodeVar
+	fmlNodeAdd
+	fmlNodeSub
+	fmlNodeMul
+	fmlNodeDiv
+	fmlNodeNeg
+	fmlNodePow
+	fmlNodeAtom
+	fmlNodeTrue
+	fmlNodeFalse
+	fmlNodeAnd
+	fmlNodeOr
+	fmlNodeNot
+	fmlNodeImpl
+	fmlNodeEquiv
+	fmlNodeEx
+	fmlNodeAll
+)
+
+type fmlNode struct {
+	cmd  int
+	s    string   // num, var
+	op   OP       // atom
+	vars []string // quantifier
+	args []*fmlNode
+	line int
+}
+
+func newFmlNode(cmd int, line int, args ...*fmlNode) *fmlNode {
+	n := new(fmlNode)
+	n.cmd = cmd
+	n.line = line
+	n.args = args
+	return n
+}
+
+func (n *fmlNode) isFof() bool {
+	return n.cmd >= fmlNodeAtom
+}
+
+// fmlVarOrder は変数を出現順に返す.
+// 束縛変数は, 自由変数のあとに, 外側の限量子のものから順に並べる.
+func fmlVarOrder(nodes ...*fmlNode) []string {
+	bound := make(map[string]bool)
+	seen := make(map[string]bool)
+	var frees, bounds []string
+	var f func(n *fmlNode)
+	f = func(n *fmlNode) {
+		switch n.cmd {
+		case fmlNodeVar:
+			if !seen[n.s] {
+				seen[n.s] = true
+				frees = append(frees, n.s)
+			}
+		case fmlNodeEx, fmlNodeAll:
+			for _, v := range n.vars {
+				if !bound[v] {
+					bound[v] = true
+					bounds = append(bounds, v)
+				}
+			}
+		}
+		for _, a := range n.args {
+			f(a)
+		}
+	}
+	for _, n := range nodes {
+		f(n)
+	}
+	vars := make([]string, 0, len(frees)+len(bounds))
+	for _, v := range frees {
+		if !bound[v] {
+			vars = append(vars, v)
+		}
+	}
+	return append(vars, bounds...)
+}
+
+func (n *fmlNode) errorf(name string, format string, a ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", name, n.line, fmt.Sprintf(format, a...))
+}
+
+// robj converts n to RObj.  varlist must be initialized.
+func (n *fmlNode) robj(name string) (RObj, error) {
+	switch n.cmd {
+	case fmlNodeNum:
+		return ParseInt(n.s, 10), nil
+	case fmlNodeVar:
+		lv, ok := varstr2lv[n.s]
+		if !ok {
+			return nil, n.errorf(name, "undefined variable `%s`", n.s)
+		}
+		return NewPolyVar(lv), nil
+	case fmlNodeNeg:
+		x, err := n.args[0].robj(name)
+		if err != nil {
+			return nil, err
+		}
+		return x.Neg(), nil
+	}
+
+	if n.cmd > fmlNodePow {
+		return nil, n.errorf(name, "polynomial is expected")
+	}
+	x, err := n.args[0].robj(name)
+	if err != nil {
+		return nil, err
+	}
+	y, err := n.args[1].robj(name)
+	if err != nil {
+		return nil, err
+	}
+	switch n.cmd {
+	case fmlNodeAdd:
+		return Add(x, y), nil
+	case fmlNodeSub:
+		return Sub(x, y), nil
+	case fmlNodeMul:
+		return Mul(x, y), nil
+	case fmlNodeDiv:
+		d, ok := y.(NObj)
+		if !ok || d.IsZero() {
+			return nil, n.errorf(name, "division by `%v`", y)
+		}
+		return x.Div(d), nil
+	case fmlNodePow:
+		e, ok := y.(*Int)
+		if !ok || e.Sign() < 0 {
+			return nil, n.errorf(name, "exponent should be a nonnegative integer: `%v`", y)
+		}
+		return x.Pow(e), nil
+	}
+	return nil, n.errorf(name, "unknown node %d", n.cmd)
+}
+
+// fof converts n to Fof.  varlist must be initialized.
+func (n *fmlNode) fof(name string) (Fof, error) {
+	switch n.cmd {
+	case fmlNodeTrue:
+		return trueObj, nil
+	case fmlNodeFalse:
+		return falseObj, nil
+	case fmlNodeAtom:
+		x, err := n.args[0].robj(name)
+		if err != nil {
+			return nil, err
+		}
+		y, err := n.args[1].robj(name)
+		if err != nil {
+			return nil, err
+		}
+		return NewAtom(Sub(x, y), n.op), nil
+	case fmlNodeEx, fmlNodeAll:
+		f, err := n.args[0].fof(name)
+		if err != nil {
+			return nil, err
+		}
+		lvs := make([]Level, len(n.vars))
+		for i, v := range n.vars {
+			lv, ok := varstr2lv[v]
+			if !ok {
+				return nil, n.errorf(name, "undefined variable `%s`", v)
+			}
+			lvs[i] = lv
+		}
+		return NewQuantifier(n.cmd == fmlNodeAll, lvs, f), nil
+	}
+	if !n.isFof() {
+		return nil, n.errorf(name, "formula is expected")
+	}
+
+	fs := make([]Fof, len(n.args))
+	for i, a := range n.args {
+		f, err := a.fof(name)
+		if err != nil {
+			return nil, err
+		}
+		fs[i] = f
+	}
+	switch n.cmd {
+	case fmlNodeAnd:
+		return newFmlAnds(fs...), nil
+	case fmlNodeOr:
+		return newFmlOrs(fs...), nil
+	case fmlNodeNot:
+		return fs[0].Not(), nil
+	case fmlNodeImpl:
+		return FofImpl(fs[0], fs[1]), nil
+	case fmlNodeEquiv:
+		return FofEquiv(fs[0], fs[1]), nil
+	}
+	return nil, n.errorf(name, "unknown node %d", n.cmd)
+}
@@ -0,0 +1,179 @@
+package ganrac
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLoadQEPCAD(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		vars   string
+		expect string
+	}{
+		{`[ quadratic ]
+		(a,b,c,x)
+		3
+		(E x)[ a x^2 + b x + c = 0 ].
+		finish.`, "a,b,c,x", "ex([x], a*x^2+b*x+c==0)"},
+		{`[ [ not nested ]
+		(x,y)
+		0
+		(A x)(E y)[ [ x^2 - 2 x y /= -1 /\ ~ y <= 0 ] \/ x (x + 1)(y) > 3 ].`,
+			"x,y", "all([x], ex([y], (x^2-2*x*y+1!=0 && y>0) || x*(x+1)*y>3))"},
+		{`[ ] (u,v) 2 [ u > 0 ==> v >= 0 ] <==> [ u < 0 <== TRUE ].`,
+			"u,v", "equiv(impl(u>0, v>=0), impl(true, u<0))"},
+		{`[ ] (x,y) 1 (E y)[ 2 y - 1/3 x = 0 /\ (x+1)^2 * y < 1 ].`,
+			"x,y", "ex([y], 2*y-x/3==0 && (x+1)^2*y<1)"},
+	} {
+		f, err := g.LoadQEPCAD(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		if _, err := g.Eval(strings.NewReader(fmt.Sprintf("%s(%s);", init_var_funcname, s.vars))); err != nil {
+			t.Errorf("%d: vars=%s: err=%s", i, s.vars, err)
+			continue
+		}
+		u, err := g.Eval(strings.NewReader(s.expect + ";"))
+		if err != nil {
+			t.Errorf("%d: expect=%s: err=%s", i, s.expect, err)
+			continue
+		}
+		if !f.Equals(u) {
+			t.Errorf("%d: input=%s\nexpect=%v\nactual=%v", i, s.input, u, f)
+		}
+	}
+
+	for i, s := range []string{
+		`(x) 0 [ x > 0 ].`,
+		`[ ] (x,y) 1 [ x > 0 ].`,
+		`[ ] (x,y) 1 (E x)[ x > y ].`,
+		`[ ] (x) 1 [ x > 0 ]`,
+		`[ ] (x) 1 [ x + 0 ].`,
+		`[ ] (x) 1 [ x > z ].`,
+		`[ ] (x) 1 [ x^y > 0 ].`,
+	} {
+		_, err := g.LoadQEPCAD(strings.NewReader(s))
+		if err == nil {
+			t.Errorf("%d: input=%s: error is expected", i, s)
+		}
+	}
+}
+
+func TestLoadRedlog(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		vars   string
+		expect []string
+	}{
+		{`load_package redlog;
+		rlset ofsf;
+		% comment
+		phi := ex(x, a*x**2+b*x+c=0);
+		comment this is also a comment;
+		rlqe phi;
+		rlqe all({X, y}, x^2 + y^2 > 0 and not x <> 0 or y >= -1/2)$
+		end;`, "a,b,c,x,y", []string{
+			"ex([x], a*x^2+b*x+c==0)",
+			"all([x,y], (x^2+y^2>0 && x==0) || y>=-1/2)",
+		}},
+		{`psi := (x geq 0 impl y > 1) equiv (y < 0 repl x = 0);
+		psi;`, "x,y", []string{
+			"equiv(impl(x>=0, y>1), impl(x==0, y<0))",
+		}},
+		{`rlqe(ex(x, x*y - 1 = 0 and true));`, "y,x", []string{
+			"ex([x], x*y-1==0)",
+		}},
+	} {
+		fs, err := g.LoadRedlog(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		if len(fs) != len(s.expect) {
+			t.Errorf("%d: input=%s: expect %d formulas, actual %d", i, s.input, len(s.expect), len(fs))
+			continue
+		}
+		if _, err := g.Eval(strings.NewReader(fmt.Sprintf("%s(%s);", init_var_funcname, s.vars))); err != nil {
+			t.Errorf("%d: vars=%s: err=%s", i, s.vars, err)
+			continue
+		}
+		for j, e := range s.expect {
+			u, err := g.Eval(strings.NewReader(e + ";"))
+			if err != nil {
+				t.Errorf("%d,%d: expect=%s: err=%s", i, j, e, err)
+				continue
+			}
+			if !fs[j].Equals(u) {
+				t.Errorf("%d,%d: input=%s\nexpect=%v\nactual=%v", i, j, s.input, u, fs[j])
+			}
+		}
+	}
+
+	for i, s := range []string{
+		`rlset ofsf;`,
+		`rlqe x + 1;`,
+		`rlqe ex(x, x > 0`,
+		`rlqe ex(x, x + 1);`,
+		`rlqe x/y > 0;`,
+	} {
+		_, err := g.LoadRedlog(strings.NewReader(s))
+		if err == nil {
+			t.Errorf("%d: input=%s: error is expected", i, s)
+		}
+	}
+}
+
+func TestQEPCADRedlogPrint(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []string{
+		"x^2+y>1",
+		"-3*x*y+1/2<=0 && y != 0",
+		"x^12*y-x>=0 || y<-2/3",
+		"ex([z], z^2+x*z+y==0 && (x>0 || y>0))",
+		"all([z], z^2+x*z+y>0)",
+	} {
+		g.InitVarList([]string{"x", "y", "z"})
+		u, err := g.Eval(strings.NewReader(s + ";"))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s, err)
+			continue
+		}
+		f := u.(Fof)
+		str := fmt.Sprintf("rlqe %R;", f)
+		fs, err := g.LoadRedlog(strings.NewReader(str))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s\n%s", i, s, err, str)
+			continue
+		}
+
+		// 変数順序がかわるので, 文字列を経由して比較
+		str = fmt.Sprintf("%v;", fs[0])
+		g.InitVarList([]string{"x", "y", "z"})
+		v, err := g.Eval(strings.NewReader(str))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s\n%s", i, s, err, str)
+			continue
+		}
+		if !f.Equals(v) {
+			t.Errorf("%d: redlog input=%s\nexpect=%v\nactual=%v", i, s, f, v)
+		}
+		nfree := 3
+		if !f.IsQff() {
+			nfree = 2
+		}
+		str = fmt.Sprintf("[ ] (x,y,z) %d %Q.", nfree, f)
+		v, err = g.LoadQEPCAD(strings.NewReader(str))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s\n%s", i, s, err, str)
+			continue
+		}
+		if !f.Equals(v) {
+			t.Errorf("%d: qepcad input=%s\nexpect=%v\nactual=%v", i, s, f, v)
+		}
+	}
+}
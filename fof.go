@@ -769,6 +769,8 @@ func (p *AtomT) Format(s fmt.State, format rune) {
 		fmt.Fprintf(s, "trueObj")
 	case FORMAT_QEPCAD:
 		fmt.Fprintf(s, "TRUE")
+	case FORMAT_SMT2, FORMAT_REDLOG:
+		fmt.Fprintf(s, "true")
 	default:
 		p.Format(s, format)
 	}
@@ -788,6 +790,8 @@ func (p *AtomF) Format(s fmt.State, format rune) {
 		fmt.Fprintf(s, "falseObj")
 	case FORMAT_QEPCAD:
 		fmt.Fprintf(s, "FALSE")
+	case FORMAT_SMT2, FORMAT_REDLOG:
+		fmt.Fprintf(s, "false")
 	default:
 		p.Format(s, format)
 	}
@@ -808,7 +812,9 @@ func (p *Atom) Format(b fmt.State, format rune) {
 				if i != 0 {
 					fmt.Fprintf(b, "*")
 				}
-				fmt.Fprintf(b, "(%v)", pp)
+				fmt.Fprintf(b, "(")
+				pp.Format(b, format)
+				fmt.Fprintf(b, ")")
 			}
 			fmt.Fprintf(b, "%s0", p.op)
 		}
@@ -834,6 +840,41 @@ func (p *Atom) Format(b fmt.State, format rune) {
 			}
 		}
 		fmt.Fprintf(b, " %s 0", []string{"@false@", "<", "=", "<=", ">", "/=", ">=", "@true@"}[p.op])
+	case FORMAT_REDLOG:
+		if len(p.p) == 1 {
+			p.p[0].Format(b, format)
+		} else {
+			for i, f := range p.p {
+				if i != 0 {
+					fmt.Fprintf(b, "*")
+				}
+				fmt.Fprintf(b, "(")
+				f.Format(b, format)
+				fmt.Fprintf(b, ")")
+			}
+		}
+		fmt.Fprintf(b, " %s 0", []string{"@false@", "<", "=", "<=", ">", "<>", ">=", "@true@"}[p.op])
+	case FORMAT_SMT2:
+		if p.op == NE {
+			fmt.Fprintf(b, "(not (= ")
+		} else {
+			fmt.Fprintf(b, "(%s ", []string{"@false@", "<", "=", "<=", ">", "/=", ">=", "@true@"}[p.op])
+		}
+		if len(p.p) == 1 {
+			p.p[0].Format(b, format)
+		} else {
+			fmt.Fprintf(b, "(*")
+			for _, f := range p.p {
+				fmt.Fprintf(b, " ")
+				f.Format(b, format)
+			}
+			fmt.Fprintf(b, ")")
+		}
+		if p.op == NE {
+			fmt.Fprintf(b, " 0))")
+		} else {
+			fmt.Fprintf(b, " 0)")
+		}
 	case FORMAT_DUMP: // dump
 		fmt.Fprintf(b, "(atom %d (", len(p.p))
 		for _, pp := range p.p {
@@ -924,6 +965,26 @@ func (p *FmlAnd) Format(b fmt.State, format rune) {
 			}
 		}
 		fmt.Fprintf(b, " ]")
+	case FORMAT_REDLOG:
+		for i, f := range p.fml {
+			if i != 0 {
+				fmt.Fprintf(b, " and ")
+			}
+			if _, ok := f.(*FmlOr); ok {
+				fmt.Fprintf(b, "(")
+				f.Format(b, format)
+				fmt.Fprintf(b, ")")
+			} else {
+				f.Format(b, format)
+			}
+		}
+	case FORMAT_SMT2:
+		fmt.Fprintf(b, "(and")
+		for _, f := range p.fml {
+			fmt.Fprintf(b, " ")
+			f.Format(b, format)
+		}
+		fmt.Fprintf(b, ")")
 	case FORMAT_DUMP: // dump
 		fmt.Fprintf(b, "(&& %d (", len(p.fml))
 		for _, f := range p.fml {
@@ -991,6 +1052,26 @@ func (p *FmlOr) Format(b fmt.State, format rune) {
 			}
 		}
 		fmt.Fprintf(b, " ]")
+	case FORMAT_REDLOG:
+		for i, f := range p.fml {
+			if i != 0 {
+				fmt.Fprintf(b, " or ")
+			}
+			if _, ok := f.(*FmlAnd); ok {
+				fmt.Fprintf(b, "(")
+				f.Format(b, format)
+				fmt.Fprintf(b, ")")
+			} else {
+				f.Format(b, format)
+			}
+		}
+	case FORMAT_SMT2:
+		fmt.Fprintf(b, "(or")
+		for _, f := range p.fml {
+			fmt.Fprintf(b, " ")
+			f.Format(b, format)
+		}
+		fmt.Fprintf(b, ")")
 	case FORMAT_DUMP: // dump
 		fmt.Fprintf(b, "(|| %d (", len(p.fml))
 		for _, f := range p.fml {
@@ -1046,6 +1127,28 @@ func (p *ForAll) Format(b fmt.State, format rune) {
 			fmt.Fprintf(b, "(A %s)", varstr(lv))
 		}
 		p.fml.Format(b, format)
+	case FORMAT_REDLOG:
+		fmt.Fprintf(b, "all({")
+		for i, lv := range p.q {
+			if i != 0 {
+				fmt.Fprintf(b, ",")
+			}
+			fmt.Fprintf(b, "%s", varstr(lv))
+		}
+		fmt.Fprintf(b, "}, ")
+		p.fml.Format(b, format)
+		fmt.Fprintf(b, ")")
+	case FORMAT_SMT2:
+		fmt.Fprintf(b, "(forall (")
+		for i, lv := range p.q {
+			if i != 0 {
+				fmt.Fprintf(b, " ")
+			}
+			fmt.Fprintf(b, "(%s Real)", varstr(lv))
+		}
+		fmt.Fprintf(b, ") ")
+		p.fml.Format(b, format)
+		fmt.Fprintf(b, ")")
 	case FORMAT_DUMP: // dump
 		fmt.Fprintf(b, "(all ")
 		for i, lv := range p.q {
@@ -1100,6 +1203,28 @@ func (p *Exists) Format(b fmt.State, format rune) {
 			fmt.Fprintf(b, "(E %s)", varstr(lv))
 		}
 		p.fml.Format(b, format)
+	case FORMAT_REDLOG:
+		fmt.Fprintf(b, "ex({")
+		for i, lv := range p.q {
+			if i != 0 {
+				fmt.Fprintf(b, ",")
+			}
+			fmt.Fprintf(b, "%s", varstr(lv))
+		}
+		fmt.Fprintf(b, "}, ")
+		p.fml.Format(b, format)
+		fmt.Fprintf(b, ")")
+	case FORMAT_SMT2:
+		fmt.Fprintf(b, "(exists (")
+		for i, lv := range p.q {
+			if i != 0 {
+				fmt.Fprintf(b, " ")
+			}
+			fmt.Fprintf(b, "(%s Real)", varstr(lv))
+		}
+		fmt.Fprintf(b, ") ")
+		p.fml.Format(b, format)
+		fmt.Fprintf(b, ")")
 	case FORMAT_DUMP: // dump
 		fmt.Fprintf(b, "(ex ")
 		for i, lv := range p.q {
@@ -1191,6 +1316,13 @@ func (p *AtomF) Subst(xs RObj, lvs Level) Fof {
 
 func (p *Atom) Subst(xs RObj, lvs Level) Fof {
 	op := p.op
+	if _, ok := xs.(*RatFunc); ok {
+		var q RObj = one
+		for _, pp := range p.p {
+			q = Mul(q, pp.Subst(xs, lvs))
+		}
+		return NewAtom(q, op)
+	}
 	pp := make([]RObj, 0, len(p.p))
 	s := 1
 	for _, q := range p.p {
@@ -1250,7 +1382,7 @@ func (p *FmlOr) Subst(xs RObj, lvs Level) Fof {
 		}
 	}
 	if len(q.fml) == 0 {
-		return NewBool(true)
+		return NewBool(false)
 	} else if len(q.fml) == 1 {
 		return q.fml[0]
 	}
@@ -1414,6 +1546,12 @@ func NewAtoms(pp []RObj, op OP) Fof {
 }
 
 func NewAtom(p RObj, op OP) Fof {
+	switch r := p.(type) {
+	case *RatFunc:
+		return r.atom(op)
+	case *Piecewise:
+		return r.atom(op)
+	}
 	if p.IsNumeric() {
 		s := p.Sign()
 		if s < 0 {
@@ -136,6 +136,23 @@ func TestFmlOr(t *testing.T) {
 	}
 }
 
+func TestFmlSubstEmpty(t *testing.T) {
+	// x > 0 || x < 0 で x=0 とすると, すべての選言子が false になる
+	or := NewFmlOr(
+		NewAtom(NewPolyCoef(0, 0, 1), GT),
+		NewAtom(NewPolyCoef(0, 0, 1), LT))
+	if h := or.Subst(zero, 0); !h.Equals(falseObj) {
+		t.Errorf("or: expect=false, actual=%v", h)
+	}
+
+	and := NewFmlAnd(
+		NewAtom(NewPolyCoef(0, 0, 1), GE),
+		NewAtom(NewPolyCoef(0, 0, 1), LE))
+	if h := and.Subst(zero, 0); !h.Equals(trueObj) {
+		t.Errorf("and: expect=true, actual=%v", h)
+	}
+}
+
 func TestOp(t *testing.T) {
 	for ii, ss := range []struct {
 		input  OP
@@ -1,7 +1,10 @@
 package ganrac
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"math/big"
 	"os"
 	"time"
 )
@@ -10,14 +13,164 @@ func (g *Ganrac) setBuiltinFuncTable() {
 	// 関数テーブル
 	g.builtin_func_table = []func_table{
 		// sorted by name
+		{"abs", 1, 1, funcAbs, false, "(poly)\t\t\tabsolute value", `
+Args
+========
+  poly : polynomial or rational function
+
+Returns
+========
+  |poly|.  If poly is not a number, the result is a piecewise term,
+  which is expanded into a disjunction in an atomic formula.
+
+Examples
+========
+  > abs(-3);
+  3
+  > abs(x) <= 1;
+  (x-1 <= 0 && x >= 0) || (x < 0 && x+1 >= 0)
+`},
 		{"all", 2, 2, funcForAll, false, "([x], FOF):\t\tuniversal quantifier.", ""},
 		//		{"and", 2, 2, funcAnd, false, "(FOF, ...):\t\tconjunction (&&)", ""},
 		{"cad", 1, 2, funcCAD, true, "(FOF [, proj])*", ""},
+		{"cadadd", 2, 2, funcCADadd, true, "(CAD, G)*\t\tadd polynomials to CAD", `
+Args
+========
+  CAD : CAD generated by cadinit()
+  G   : polynomial, list of polynomials or FOF
+
+Returns
+========
+  CAD.  The polynomials of G are added to the projection factors.
+  Only the projection of the new factors is computed, and the lifted
+  stacks are refined where the new factors have roots.
+
+Examples
+========
+  > C = cadinit(ex([y], x^2+y^2 < 1));
+  > cadproj(C);
+  > cadlift(C);
+  > cadadd(C, y-x);
+  > cadadd(C, [x-1/2, x+y]);
+`},
+		{"cadeval", 2, 2, funcCADeval, true, "(CAD, FOF)*\t\tevaluate FOF over CAD", `
+Args
+========
+  CAD : CAD generated by cadinit()
+  FOF : prenex first-order formula whose polynomials are products of
+        the projection factors of CAD
+
+Returns
+========
+  an equivalent quantifier-free formula.
+  Only the truth values of the cells are evaluated.  No projection
+  factor is added, so an error occurs if FOF is not covered by CAD or
+  the solution formula needs additional polynomials.
+
+Examples
+========
+  > C = cadinit(ex([y], x^2+y^2 < 1 && x*y > 0));
+  > cadproj(C);
+  > cadlift(C);
+  > cadeval(C, all([y], x^2+y^2 >= 1 || x*y > 0));
+`},
 		{"cadinit", 1, 1, funcCADinit, true, "(FOF)*", ""},
-		{"cadlift", 1, 10, funcCADlift, true, "(CAD)*", ""},
+		{"cadlift", 1, 10, funcCADlift, true, "(CAD [, index, ...] [, opt])*", `
+Args
+========
+  CAD   : CAD generated by cadinit()
+  index : integers, index of the cell to be lifted.
+          If no index is given, all the cells are lifted.
+  opt   : dictionary
+    checkpoint : string, file name.  The CAD is saved periodically
+                 during lifting.  see cadsave().
+    interval   : integer, interval of checkpoints in seconds (default: 600)
+
+Examples
+========
+  > C = cadinit(ex([y], x^2+y^2 < 1));
+  > cadproj(C);
+  > cadlift(C, {checkpoint: "c.cad", interval: 3600});
+
+  After a crash, the lifting is resumed from the checkpoint:
+  > C = cadload("c.cad");
+  > cadlift(C, {checkpoint: "c.cad"});
+`},
+		{"cadload", 1, 1, funcCADload, true, "(fname)*		restore CAD", `
+Args
+========
+  fname : string, file name saved by cadsave()
+
+Returns
+========
+  CAD.  The variable order is replaced by that of the saved CAD.
+  If the CAD was saved during lifting, cadlift() resumes the lifting.
+`},
 		{"cadproj", 1, 2, funcCADproj, true, "(CAD [, proj])*", ""},
+		{"cadsave", 2, 2, funcCADsave, true, "(CAD, fname)*		save CAD", `
+Args
+========
+  CAD   : CAD generated by cadinit()
+  fname : string, file name
+
+The projection factors, the cell tree with the sample points and
+the cells to be lifted are saved.  see cadload().
+`},
 		{"cadsfc", 1, 1, funcCADsfc, true, "(CAD)*", ""},
+		{"checksat", 1, 1, funcCheckSat, true, "(FOF)*\t\tsatisfiability of FOF over the reals", `
+Args
+========
+  FOF : a first-order formula. free variables are existentially quantified.
+
+Returns
+========
+  "sat" or "unsat"
+
+Examples
+========
+  > checksat(x^2+1 < 0);
+  "unsat"
+`},
 		{"coef", 3, 3, funcCoef, false, "(poly, var, deg)", ""}, // coef(F, x, 2)
+		{"complexroot", 1, 2, funcComplexRoot, false, "(uni-poly [, prec])\tcomplex roots", `
+Args
+========
+  uni-poly : univariate polynomial with rational coefficients
+  prec     : integer. the width and height of the rectangles are refined to 2^prec.
+
+Returns
+========
+  the list of the rectangles [[re_lo, re_hi], [im_lo, im_hi]]
+  with dyadic corners, each of which contains exactly one distinct
+  complex root.  The rectangles are sorted by the real parts.
+
+Examples
+========
+  > len(complexroot(x^5-x+1));
+  5
+  > complexroot(x^2+1, -4)[1][1][0] > 0;
+  true
+`},
+		{"connected", 1, 3, funcConnected, true, "(FOF [, p, q])*\tconnectivity of the region where FOF is true", `
+Args
+========
+  FOF  : a quantifier-free formula
+  p, q : lists of rational numbers; coordinates of points in order of the
+         variable levels
+
+Returns
+========
+  the number of connected components of the region where FOF is true
+  if p and q are not given.
+  true if p and q are in the same connected component, false otherwise.
+
+Examples
+========
+  > connected(x^2+y^2 > 1 && x^2+y^2 < 4);
+  1
+  > connected(x^2+y^2 < 1 || (x-3)^2+y^2 < 1, [0, 0], [3, 0]);
+  false
+`},
 		{"deg", 2, 2, funcDeg, false, "(poly|FOF, var)\t\tdegree of a polynomial with respect to var", `
 Args
 ========
@@ -75,7 +228,44 @@ Examples
 		{"indets", 1, 1, funcIndets, false, "(mobj)\t\t\tfind indeterminates of an expression", ""},
 		{"intv", 1, 3, funcIntv, false, "(lb, ub [, prec])\t\tmake an interval", ""},
 		{"len", 1, 1, funcLen, false, "(mobj)\t\t\tlength of an object", ""},
-		{"load", 2, 2, funcLoad, false, "(fname)@\t\t\tload file", ""},
+		{"load", 1, 1, funcLoad, false, "(fname)\t\t\tload file", `
+Args
+========
+  fname : string, file name of a GaNRAC script
+
+Returns
+========
+  the value of the last statement in the script
+`},
+		{"loadsession", 1, 1, funcLoadSession, false, "(fname)\t\trestore the session", `
+Args
+========
+  fname : string, file name saved by savesession()
+
+The variable order, the variables and the history are replaced.
+`},
+		{"max", 1, 100, funcMax, false, "(poly, ...)\t\tmaximum", `
+Args
+========
+  poly : polynomial or rational function
+
+Examples
+========
+  > max(1, 3, 2);
+  3
+  > max(x, y) < z;
+  (x-y >= 0 && x-z < 0) || (x-y < 0 && y-z < 0)
+`},
+		{"min", 1, 100, funcMin, false, "(poly, ...)\t\tminimum", `
+Args
+========
+  poly : polynomial or rational function
+
+Examples
+========
+  > min(1, -3, 2);
+  -3
+`},
 		{"not", 1, 1, funcNot, false, "(FOF)", `
 Args
 ========
@@ -87,6 +277,25 @@ Examples
   x <= 0
   > not(ex([x], a*x^2+b*x+c==0));
   all([x], a*x^2+b*x+c != 0)
+`},
+		{"nroots", 2, 4, funcNRoots, false, "(uni-poly, var [, a, b])\tnumber of real roots", `
+Args
+========
+  uni-poly : univariate polynomial
+  var      : variable
+  a, b     : numbers, "-inf" or "inf"
+
+Returns
+========
+  the number of the distinct real roots in the interval (a, b].
+  If a and b are omitted, the number of all the real roots.
+
+Examples
+========
+  > nroots(x^3-2*x, x);
+  3
+  > nroots(x^3-2*x, x, 0, 2);
+  1
 `},
 		{"oxfunc", 2, 100, funcOXFunc, true, "(fname, args...)*\tcall ox-function by ox-asir", `
 Args
@@ -110,6 +319,38 @@ Examples
 ========
   > oxstr("fctr(x^2-4);");
   [[1,1],[x-2,1],[x+2,1]]
+`},
+		{"piecewise", 1, 100, funcPiecewise, false, "([cond, val], ...)\tpiecewise term", `
+Args
+========
+  cond : first-order formula
+  val  : polynomial or rational function
+
+The conditions should be mutually exclusive.
+The term is not defined where no condition holds.
+
+Examples
+========
+  > piecewise([x >= 0, x], [x < 0, -x]) > 1;
+  x-1 > 0 || x+1 < 0
+`},
+		{"plot", 2, 4, funcPlot, true, "(CAD|FOF, [x, xmin, xmax], [y, ymin, ymax], fname)*\tSVG plot", `
+Args
+========
+  CAD   : a lifted CAD whose first two variables are free
+  FOF   : a formula in two free variables x and y
+  x, y  : variables of level 0 and 1
+  xmin, xmax, ymin, ymax : rational numbers. the range of the plot
+  fname : string, file name
+
+Usage
+========
+  plot(CAD, fname)
+  plot(FOF, [x, xmin, xmax], [y, ymin, ymax], fname)
+
+The zeros of the projection factors, the cells, the sample points
+and the regions where FOF is true (blue) or false (gray) are drawn.
+If the range is not given, it is chosen to contain all the sections.
 `},
 		{"print", 1, 10, funcPrint, false, "(obj [, kind, ...])\tprint object", `
 
@@ -119,6 +360,8 @@ Examples*
   y+x^10-3
   > print(x^10+y-3, "tex");
   y+x^{10}-3
+  > print(0.25*x+3/8, "decimal");
+  0.25*x+0.375
   > print(ex([x], x^2>1 && y +x == 0), "tex");
   \exists x x^2-1 > 0 \land y+x = 0
 
@@ -132,6 +375,9 @@ Examples*
   > print(C, "signatures", 1);
   > print(C, "cell", 1, 1);
   > print(C, "stat");
+  > print(C, "json");
+  > print(x^2+y > 0, "json");
+  "y+x^2>0"
 `},
 		{"psc", 4, 4, funcOXPsc, true, "(poly, poly, var, int)*\tprincipal subresultant coefficient.", ""},
 		{"qe", 1, 2, funcQE, true, "(fof [, opt])\t\treal quantifier elimination", fmt.Sprintf(`
@@ -146,11 +392,16 @@ opt: dictionary.
   %9s: inequational constraints (Iwane15)
   %9s: simplify  even formula
   %9s: simplify  homogeneous formula
+    timeout: time limit of each algorithm in seconds (rational number)
+   maxcells: maximum number of cells of CAD
+  An algorithm which exceeds the limit is abandoned, and the next
+  applicable algorithm is tried.  If all fail, an error is returned.
 
 Example
 =======
   > vars(x, b, c);
   > F = ex([x], x^2+b*x+c == 0);
+  > qe(F, {timeout: 60, maxcells: 100000});
 `,
 			getQEoptStr(QEALGO_VSLIN),
 			getQEoptStr(QEALGO_VSQUAD),
@@ -160,8 +411,118 @@ Example
 			getQEoptStr(QEALGO_SMPL_EVEN),
 			getQEoptStr(QEALGO_SMPL_HOMO),
 		)},
+		{"qepcadload", 1, 1, funcQEPCADLoad, false, "(fname)\t\tload a QEPCAD B input file", `
+Args
+========
+  fname : string, file name of a QEPCAD B input file
+
+Returns
+========
+  first-order formula.
+  variable order is initialized by the variable list of the input.
+`},
 		{"quit", 0, 1, funcQuit, false, "([code])\t\tbye.", ""},
-		{"realroot", 2, 2, funcRealRoot, false, "(uni-poly)\t\treal root isolation", ""},
+		{"range", 1, 3, funcRange, false, "([start,] stop [, step])\tlist of integers", `
+Args
+========
+  start : integer (default: 0)
+  stop  : integer
+  step  : nonzero integer (default: 1)
+
+Returns
+========
+  [start, start+step, ...], not including stop
+
+Examples
+========
+  > len(range(10, 0, -3));
+  4
+`},
+		{"realalg", 3, 3, funcRealAlg, false, "(uni-poly, lb, ub)\treal algebraic number", `
+Args
+========
+  uni-poly : univariate polynomial
+  lb, ub   : rational numbers
+
+Returns
+========
+  the unique real root of uni-poly in the open interval (lb, ub).
+  It is an exact number: comparison, arithmetic and subst() are supported.
+  It can be combined only with rational and real algebraic numbers,
+  not with polynomials or intervals.
+
+Examples
+========
+  > realalg(x^2-2, 1, 2)^2;
+  2
+  > realalg(x^2-2, 1, 2) < 3/2;
+  true
+`},
+		{"realroot", 1, 2, funcRealRoot, false, "(uni-poly [, prec])\treal roots", `
+Args
+========
+  uni-poly : univariate polynomial
+  prec     : integer. isolating intervals are refined to width 2^prec.
+
+Returns
+========
+  the list of the real roots in increasing order.
+  Irrational roots are represented as realalg(poly, lb, ub).
+
+Examples
+========
+  > len(realroot(x^3-2*x));
+  3
+  > realroot(x^3-2*x)[1];
+  0
+`},
+		{"realsolve", 2, 2, funcRealSolve, true, "(polys, vars)*\t\treal solutions of a polynomial system", `
+Args
+========
+  polys : list of polynomials which has finitely many common zeros
+  vars  : list of variables
+
+Returns
+========
+  the list of the real solutions of polys = 0 in lexicographic order.
+  Each solution is a list of the coordinates, which are rational
+  numbers or realalg(poly, lb, ub) with isolating intervals.
+
+Examples
+========
+  > len(realsolve([x^2+y^2-4, x*y-1], [x, y]));
+  4
+  > realsolve([x^2-2, x*y-1], [x, y])[1][1] == 1/realroot(x^2-2)[1];
+  true
+`},
+		{"redlogload", 1, 1, funcRedlogLoad, false, "(fname)\t\tload a Redlog script", `
+Args
+========
+  fname : string, file name of a Redlog script
+
+Returns
+========
+  list of first-order formulas given to rlqe.
+  variable order is initialized: free variables, then bound variables.
+`},
+		{"root", 2, 2, funcRoot, false, "(poly, k)\t\treal k-th root", `
+Args
+========
+  poly : polynomial
+  k    : positive integer
+
+Returns
+========
+  the real k-th root of poly.  If k is even, it is the nonnegative one.
+  Radicals are eliminated by qe() by introducing new quantified variables.
+
+Examples
+========
+  > root(-8, 3);
+  -2
+  > root(x, 3) > 1;
+  root(x, 3)-1 > 0
+`},
 		{"rootbound", 1, 1, funcRootBound, false, "(uni-poly in Z[x])\troot bound", `
 Args
 ========
@@ -172,11 +533,110 @@ Examples
   > rootbound(x^2-2);
   3
 `},
-		{"save", 2, 3, funcSave, false, "(obj, fname)@\t\tsave object...", ""},
+		{"save", 2, 2, funcSave, false, "(obj, fname)\t\tsave object", `
+Args
+========
+  obj   : formula, polynomial, number, string, list or dict
+  fname : string, file name
+
+The file is a GaNRAC script which restores the variable order,
+so that load(fname) returns obj.
+`},
+		{"savesession", 1, 1, funcSaveSession, false, "(fname)\t\tsave the session", `
+Args
+========
+  fname : string, file name
+
+The variable order, all the variables (including CAD objects)
+and the history are saved.  see loadsession().
+`},
 		{"simpl", 1, 2, funcSimplify, true, "(Fof)\t\t\tsimplify formula FoF", ""},
 		{"sleep", 1, 1, funcSleep, false, "(milisecond)\t\tzzz", ""},
+		{"smt2load", 1, 1, funcSMT2Load, false, "(fname)\t\tload an SMT-LIB2 script", `
+Args
+========
+  fname : string, file name of an SMT-LIB2 script
+
+Returns
+========
+  conjunction of the asserted formulas.
+  variable order is initialized by the declared constants.
+`},
+		{"solve", 2, 2, funcSolve, false, "(FOF, var)\t\tsolve a univariate formula", `
+Args
+========
+  FOF : quantifier-free formula in one variable
+  var : variable
+
+Returns
+========
+  the list of the maximal intervals on which FOF holds.
+  Each interval is [lower, upper, "[)", "approximation"].
+  The endpoints are exact real algebraic numbers, "-inf" or "inf".
+  The 3rd element shows whether each end is open or closed.
+
+Examples
+========
+  > len(solve(x^5-3*x+1 > 0 && x^2 < 4, x));
+  2
+  > solve(x^2 <= 0, x)[0][2];
+  "[]"
+`},
 		// {"sqfr", 1, 1, funcSqfr, false, "(poly)* square-free factorization", ""},
+		{"sqrt", 1, 1, funcSqrt, false, "(poly)\t\t\tsquare root", `
+Args
+========
+  poly : polynomial
+
+Returns
+========
+  the nonnegative square root of poly.  see root().
+
+Examples
+========
+  > sqrt(9/4);
+  3/2
+  > sqrt(x^2+y^2) <= 1;
+  sqrt(x^2+y^2)-1 <= 0
+`},
 		{"sres", 4, 4, funcOXSres, true, "(poly, poly, var, int)*\tslope resultant.", ""},
+		{"sturm", 2, 2, funcSturm, false, "(poly, var)\t\tSturm sequence", `
+Args
+========
+  poly : polynomial
+  var  : variable
+
+Returns
+========
+  the Sturm sequence [poly, diff(poly, var), -rem(...), ...].
+  The pseudo-remainders are multiplied by even powers of the leading
+  coefficients, so their signs are kept if the leading coefficients
+  do not vanish.
+
+Examples
+========
+  > sturm(x^3-2*x, x)[2];
+  x
+`},
+		{"sturmhabicht", 3, 3, funcSturmHabicht, false, "(poly, poly, var)\tprincipal Sturm-Habicht coefficients", `
+Args
+========
+  P, Q : polynomials
+  var  : variable
+
+Returns
+========
+  the list of the principal Sturm-Habicht coefficients of P and Q
+  [stha_d, ..., stha_0], where d is the degree of P in var.
+  The difference between the numbers of the permanences and
+  the variations of their signs is
+    #{x | P(x)=0, Q(x)>0} - #{x | P(x)=0, Q(x)<0}.
+
+Examples
+========
+  > sturmhabicht(x^2+b*x+c, 1, x)[2];
+  b^2-4*c
+`},
 		{"subst", 1, 101, funcSubst, false, "(poly|FOF|List,x,vx,y,vy,...)", ""},
 		{"time", 1, 1, funcTime, false, "(expr)\t\t\trun command and system resource usage", ""},
 		{init_var_funcname, 0, 0, nil, false, "(var, ...)\t\tinit variable order", `
@@ -201,6 +661,9 @@ Examples*
 
 // func (p *pNode) callFunction(args []interface{}) (interface{}, error) {
 func (g *Ganrac) callFunction(funcname string, args []interface{}) (interface{}, error) {
+	if err := g.context().Err(); err != nil {
+		return nil, err
+	}
 	// とりあえず素朴に
 	for _, f := range g.builtin_func_table {
 		if f.name == funcname {
@@ -221,6 +684,10 @@ func (g *Ganrac) callFunction(funcname string, args []interface{}) (interface{},
 		}
 	}
 
+	if f, ok := g.user_func[funcname]; ok {
+		return g.callUserFunc(f, args)
+	}
+
 	return nil, fmt.Errorf("unknown function: %s", funcname)
 }
 
@@ -484,34 +951,83 @@ func funcCAD(g *Ganrac, name string, args []interface{}) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
-	_, err = cad.Projection(algo)
+	_, err = cad.ProjectionContext(g.context(), algo)
 	if err != nil {
 		return nil, err
 	}
-	err = cad.Lift()
+	err = cad.LiftContext(g.context())
 	if err != nil {
 		return nil, err
 	}
 	return cad.Sfc()
 }
 
-func funcCADinit(g *Ganrac, name string, args []interface{}) (interface{}, error) {
-	c, ok := args[0].(Fof)
-	if !ok {
-		return nil, fmt.Errorf("%s() expected FOF", name)
-	}
-
-	return NewCAD(c, g)
-}
-
-func funcCADproj(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+func funcCADadd(g *Ganrac, name string, args []interface{}) (interface{}, error) {
 	c, ok := args[0].(*CAD)
 	if !ok {
-		return nil, fmt.Errorf("%s() expected CAD generated by cadinit()", name)
+		return nil, fmt.Errorf("%s(1st-arg) expected CAD generated by cadinit()", name)
 	}
 
-	var algo ProjectionAlgo = PROJ_McCallum
-	if len(args) > 1 {
+	var ps []*Poly
+	switch gg := args[1].(type) {
+	case *Poly:
+		ps = []*Poly{gg}
+	case *List:
+		for i, v := range gg.v {
+			p, ok := v.(*Poly)
+			if !ok {
+				return nil, fmt.Errorf("%s(2nd-arg:%d) expected polynomial", name, i)
+			}
+			ps = append(ps, p)
+		}
+	case Fof:
+		ps = solvePolys(gg)
+	case NObj:
+		return c, nil
+	default:
+		return nil, fmt.Errorf("%s(2nd-arg) expected polynomial or FOF", name)
+	}
+	if err := c.AddPolys(ps); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func funcCADeval(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	c, ok := args[0].(*CAD)
+	if !ok {
+		return nil, fmt.Errorf("%s(1st-arg) expected CAD generated by cadinit()", name)
+	}
+	f, ok := args[1].(Fof)
+	if !ok {
+		return nil, fmt.Errorf("%s(2nd-arg) expected FOF", name)
+	}
+	if hasRadical(f) {
+		return nil, fmt.Errorf("%s(): radicals are not supported. use qe()", name)
+	}
+	return c.Eval(f)
+}
+
+func funcCADinit(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	c, ok := args[0].(Fof)
+	if !ok {
+		return nil, fmt.Errorf("%s() expected FOF", name)
+	}
+	if hasRadical(c) {
+		return nil, fmt.Errorf("%s(): radicals are not supported. use qe()", name)
+	}
+
+	return NewCAD(c, g)
+}
+
+func funcCADproj(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	c, ok := args[0].(*CAD)
+	if !ok {
+		return nil, fmt.Errorf("%s() expected CAD generated by cadinit()", name)
+	}
+
+	var algo ProjectionAlgo = PROJ_McCallum
+	if len(args) > 1 {
 		algoi, ok := args[1].(*Int)
 		if !ok || (!algoi.IsZero() && !algoi.IsOne()) {
 			return nil, fmt.Errorf("%s(2nd-arg) expected proj operator", name)
@@ -519,7 +1035,7 @@ func funcCADproj(g *Ganrac, name string, args []interface{}) (interface{}, error
 		algo = ProjectionAlgo(algoi.Int64())
 	}
 
-	p, err := c.Projection(algo)
+	p, err := c.ProjectionContext(g.context(), algo)
 	return p, err
 }
 
@@ -529,6 +1045,31 @@ func funcCADlift(g *Ganrac, name string, args []interface{}) (interface{}, error
 		return nil, fmt.Errorf("%s(1st-arg) expected CAD generated by cadinit()", name)
 	}
 
+	if dic, ok := args[len(args)-1].(*Dict); ok && len(args) > 1 {
+		args = args[:len(args)-1]
+		fname := ""
+		intv := 600 * time.Second
+		for k, v := range dic.v {
+			switch k {
+			case "checkpoint":
+				s, ok := v.(*String)
+				if !ok {
+					return nil, fmt.Errorf("%s(opt): invalid option value: %s: %v", name, k, v)
+				}
+				fname = s.s
+			case "interval":
+				n, ok := v.(*Int)
+				if !ok || !n.IsInt64() || n.Sign() < 0 {
+					return nil, fmt.Errorf("%s(opt): invalid option value: %s: %v", name, k, v)
+				}
+				intv = time.Duration(n.Int64()) * time.Second
+			default:
+				return nil, fmt.Errorf("%s(opt): unknown option: %s", name, k)
+			}
+		}
+		c.SetCheckpoint(fname, intv)
+	}
+
 	index := make([]int, len(args)-1)
 	for i := 1; i < len(args); i++ {
 		v, ok := args[i].(*Int)
@@ -538,10 +1079,37 @@ func funcCADlift(g *Ganrac, name string, args []interface{}) (interface{}, error
 		index[i-1] = int(v.Int64())
 	}
 
-	err := c.Lift(index...)
+	err := c.LiftContext(g.context(), index...)
 	return c, err
 }
 
+func funcCADsave(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	c, ok := args[0].(*CAD)
+	if !ok {
+		return nil, fmt.Errorf("%s(1st-arg) expected CAD generated by cadinit()", name)
+	}
+	fname, ok := args[1].(*String)
+	if !ok {
+		return nil, fmt.Errorf("%s(2nd arg): expected string", name)
+	}
+	if err := c.SaveFile(fname.s); err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	return nil, nil
+}
+
+func funcCADload(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	fname, ok := args[0].(*String)
+	if !ok {
+		return nil, fmt.Errorf("%s(): expected string", name)
+	}
+	c, err := g.LoadCADFile(fname.s)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	return c, nil
+}
+
 func funcCADsfc(g *Ganrac, name string, args []interface{}) (interface{}, error) {
 	cad, ok := args[0].(*CAD)
 	if !ok {
@@ -551,6 +1119,126 @@ func funcCADsfc(g *Ganrac, name string, args []interface{}) (interface{}, error)
 	return cad.Sfc()
 }
 
+func funcConnected(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	fof, ok := args[0].(Fof)
+	if !ok {
+		return nil, fmt.Errorf("%s(1st arg): expected FOF", name)
+	}
+	if !fof.IsQff() {
+		return nil, fmt.Errorf("%s(1st arg): expected quantifier-free FOF", name)
+	}
+	if len(args) == 1 {
+		n, err := g.NumComponents(fof)
+		if err != nil {
+			return nil, fmt.Errorf("%s(): %w", name, err)
+		}
+		return NewInt(int64(n)), nil
+	} else if len(args) == 2 {
+		return nil, fmt.Errorf("%s(): expected 1 or 3 arguments", name)
+	}
+
+	var pts [2][]NObj
+	for i, nth := range []string{"2nd", "3rd"} {
+		p, ok := args[i+1].(*List)
+		if !ok {
+			return nil, fmt.Errorf("%s(%s arg): expected list", name, nth)
+		}
+		pts[i] = make([]NObj, p.Len())
+		for j, x := range p.v {
+			switch c := x.(type) {
+			case *Int, *Rat:
+				pts[i][j] = c.(NObj)
+			default:
+				return nil, fmt.Errorf("%s(%s arg): expected rational number: %v", name, nth, x)
+			}
+		}
+	}
+	b, err := g.Connected(fof, pts[0], pts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	if b {
+		return trueObj, nil
+	}
+	return falseObj, nil
+}
+
+func funcPlot(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	if len(args) != 2 && len(args) != 4 {
+		return nil, fmt.Errorf("%s(): expected 2 or 4 arguments", name)
+	}
+	fname, ok := args[len(args)-1].(*String)
+	if !ok && len(args) == 2 {
+		return nil, fmt.Errorf("%s(2nd arg): expected string", name)
+	} else if !ok {
+		return nil, fmt.Errorf("%s(4th arg): expected string", name)
+	}
+
+	var cad *CAD
+	var win *PlotWindow
+	if len(args) == 2 {
+		cad, ok = args[0].(*CAD)
+		if !ok {
+			return nil, fmt.Errorf("%s(1st arg): expected CAD", name)
+		}
+	} else {
+		fof, ok := args[0].(Fof)
+		if !ok {
+			return nil, fmt.Errorf("%s(1st arg): expected FOF", name)
+		}
+		win = new(PlotWindow)
+		for i, nth := range []string{"2nd", "3rd"} {
+			r, ok := args[i+1].(*List)
+			if !ok || r.Len() != 3 {
+				return nil, fmt.Errorf("%s(%s arg): expected [var, min, max]", name, nth)
+			}
+			v, ok := r.v[0].(*Poly)
+			if !ok || !v.isVar() || v.lv != Level(i) {
+				return nil, fmt.Errorf("%s(%s arg): expected the variable of level %d", name, nth, i)
+			}
+			var mm [2]float64
+			for j := 0; j < 2; j++ {
+				switch c := r.v[j+1].(type) {
+				case *Int, *Rat:
+					mm[j] = ratFloat(toBigRat(c.(NObj)))
+				default:
+					return nil, fmt.Errorf("%s(%s arg): expected rational number: %v", name, nth, r.v[j+1])
+				}
+			}
+			if i == 0 {
+				win.XMin, win.XMax = mm[0], mm[1]
+			} else {
+				win.YMin, win.YMax = mm[0], mm[1]
+			}
+		}
+		switch fof.(type) {
+		case *AtomT, *AtomF:
+			return nil, fmt.Errorf("%s(1st arg): expected non-trivial FOF", name)
+		}
+		var err error
+		cad, err = NewCAD(fof, g)
+		if err != nil {
+			return nil, fmt.Errorf("%s(): %w", name, err)
+		}
+		if _, err = cad.ProjectionContext(g.context(), PROJ_McCallum); err != nil {
+			return nil, fmt.Errorf("%s(): %w", name, err)
+		}
+		if err = cad.LiftContext(g.context()); err != nil {
+			return nil, fmt.Errorf("%s(): %w", name, err)
+		}
+	}
+
+	// 描画に失敗したときにファイルを作らないよう, 先にメモリに書き出す
+	var buf bytes.Buffer
+	if err := cad.PlotSVG(&buf, win); err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	if err := os.WriteFile(fname.s, buf.Bytes(), 0644); err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	return nil, nil
+}
+
 func funcVS(g *Ganrac, name string, args []interface{}) (interface{}, error) {
 	fof, ok := args[0].(FofQ)
 	if !ok || !fof.Fml().IsQff() {
@@ -587,6 +1275,8 @@ func funcPrint(g *Ganrac, name string, args []interface{}) (interface{}, error)
 		switch t {
 		case "org":
 			fmt.Printf("%v\n", cc)
+		case "decimal":
+			fmt.Printf("%#v\n", cc)
 		case "tex":
 			fmt.Printf("%P\n", cc)
 		case "src":
@@ -595,6 +1285,24 @@ func funcPrint(g *Ganrac, name string, args []interface{}) (interface{}, error)
 			fmt.Printf("%V\n", cc)
 		case "qepcad":
 			fmt.Printf("%Q\n", cc)
+		case "redlog":
+			fmt.Printf("%R\n", cc)
+		case "json":
+			m, ok := cc.(json.Marshaler)
+			if !ok {
+				return nil, fmt.Errorf("print(): json is not supported")
+			}
+			b, err := m.MarshalJSON()
+			if err != nil {
+				return nil, err
+			}
+			fmt.Printf("%s\n", b)
+		case "smt2":
+			if f, ok := cc.(Fof); ok {
+				FprintSMT2(os.Stdout, f)
+			} else {
+				fmt.Printf("%M\n", cc)
+			}
 		default:
 			fmt.Printf(t, cc)
 		}
@@ -699,6 +1407,15 @@ func funcSubst(g *Ganrac, name string, args []interface{}) (interface{}, error)
 
 	o := args[0].(GObj)
 	for _, r := range rlv {
+		if _, ok := r.r.(*RealAlg); ok && hasOtherVar(o, r.lv) {
+			return nil, fmt.Errorf("%s(): a real algebraic number is substituted into a multivariate object", name)
+		}
+		if rf, ok := o.(*RatFunc); ok {
+			// 分母が 0 になるか
+			if rf.den.Subst(r.r, r.lv).IsZero() {
+				return nil, fmt.Errorf("%s(): divide by zero", name)
+			}
+		}
 		o = gobjSubst(o, r.r, r.lv)
 	}
 
@@ -775,6 +1492,32 @@ func funcArgBoolVal(val GObj) bool {
 	}
 }
 
+// 秒数 (非負の有理数) を time.Duration に変換する.
+// time.Duration で表現できない値は ok=false
+func funcArgDuration(val GObj) (time.Duration, bool) {
+	var r *big.Rat
+	switch v := val.(type) {
+	case *Int:
+		r = new(big.Rat).SetInt(v.n)
+	case *Rat:
+		r = new(big.Rat).Set(v.n)
+	default:
+		return 0, false
+	}
+	if r.Sign() < 0 {
+		return 0, false
+	}
+	r.Mul(r, new(big.Rat).SetInt64(int64(time.Second)))
+	// ナノ秒未満は切り上げる. 正の値が 0 (無制限) にならないように
+	ns := new(big.Int).Add(r.Num(), r.Denom())
+	ns.Sub(ns, big.NewInt(1))
+	ns.Quo(ns, r.Denom())
+	if !ns.IsInt64() {
+		return 0, false
+	}
+	return time.Duration(ns.Int64()), true
+}
+
 func funcQE(g *Ganrac, name string, args []interface{}) (interface{}, error) {
 	fof, ok := args[0].(Fof)
 	if !ok {
@@ -806,6 +1549,18 @@ func funcQE(g *Ganrac, name string, args []interface{}) (interface{}, error) {
 				opt.SetAlgo(QEALGO_SMPL_TRAN, funcArgBoolVal(v))
 			case getQEoptStr(QEALGO_SMPL_ROTA):
 				opt.SetAlgo(QEALGO_SMPL_ROTA, funcArgBoolVal(v))
+			case "timeout":
+				if val, ok := funcArgDuration(v); ok {
+					opt.SetTimeout(val)
+				} else {
+					return nil, fmt.Errorf("%s(2nd arg): invalid option value: %s: %v.", name, k, v)
+				}
+			case "maxcells":
+				if val, ok := v.(*Int); ok && val.IsInt64() && val.Sign() >= 0 {
+					opt.SetMaxCells(int(val.Int64()))
+				} else {
+					return nil, fmt.Errorf("%s(2nd arg): invalid option value: %s: %v.", name, k, v)
+				}
 			case "verbose":
 				if val, ok := v.(*Int); ok && val.IsInt64() {
 					opt.log_level = int(val.Int64())
@@ -818,7 +1573,11 @@ func funcQE(g *Ganrac, name string, args []interface{}) (interface{}, error) {
 		}
 	}
 
-	return g.QE(fof, opt), nil
+	f, err := g.QEContext(g.context(), fof, opt)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
 }
 
 func funcRealRoot(g *Ganrac, name string, args []interface{}) (interface{}, error) {
@@ -827,12 +1586,245 @@ func funcRealRoot(g *Ganrac, name string, args []interface{}) (interface{}, erro
 		return nil, fmt.Errorf("%s(): expected poly: %v", name, args[0])
 	}
 
-	q, ok := args[1].(*Int)
+	prec := 0
+	if len(args) > 1 {
+		q, ok := args[1].(*Int)
+		if !ok || !q.IsInt64() {
+			return nil, fmt.Errorf("%s(): expected int: %v", name, args[1])
+		}
+		prec = int(q.Int64())
+	}
+
+	rs, err := p.RealRoots()
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %s", name, err.Error())
+	}
+	ret := NewList()
+	for _, r := range rs {
+		if x, ok := r.(*RealAlg); ok {
+			x.Refine(prec)
+		}
+		ret.Append(r)
+	}
+	return ret, nil
+}
+
+func funcComplexRoot(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	p, ok := args[0].(*Poly)
+	if !ok {
+		return nil, fmt.Errorf("%s(): expected poly: %v", name, args[0])
+	}
+
+	var prec *int
+	if len(args) > 1 {
+		q, ok := args[1].(*Int)
+		if !ok || !q.IsInt64() {
+			return nil, fmt.Errorf("%s(): expected int: %v", name, args[1])
+		}
+		m := int(q.Int64())
+		prec = &m
+	}
+
+	bs, err := p.complexRoots(prec)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %s", name, err.Error())
+	}
+	ret := NewList()
+	for _, b := range bs {
+		ret.Append(NewList(
+			NewList(newRatBig(b.x0), newRatBig(b.x1)),
+			NewList(newRatBig(b.y0), newRatBig(b.y1))))
+	}
+	return ret, nil
+}
+
+func funcRealAlg(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	p, ok := args[0].(*Poly)
+	if !ok || !p.isUnivariate() {
+		return nil, fmt.Errorf("%s(1st arg): expected univariate polynomial: %v", name, args[0])
+	}
+	var lu [2]NObj
+	for i, nth := range []string{"2nd", "3rd"} {
+		switch c := args[i+1].(type) {
+		case *Int, *Rat:
+			lu[i] = c.(NObj)
+		default:
+			return nil, fmt.Errorf("%s(%s arg): expected rational number: %v", name, nth, args[i+1])
+		}
+	}
+	if lu[0].Cmp(lu[1]) >= 0 {
+		return nil, fmt.Errorf("%s(): invalid interval (%v, %v)", name, lu[0], lu[1])
+	}
+	r, err := NewRealAlg(p, lu[0], lu[1])
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %s", name, err.Error())
+	}
+	return r, nil
+}
+
+func funcRealSolve(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	fs, ok := args[0].(*List)
+	if !ok {
+		return nil, fmt.Errorf("%s(1st arg): expected poly-list: %v", name, args[0])
+	}
+	ps := make([]RObj, fs.Len())
+	for i, f := range fs.v {
+		if ps[i], ok = f.(RObj); !ok {
+			return nil, fmt.Errorf("%s(1st arg): expected poly-list: %v", name, args[0])
+		}
+	}
+	xs, ok := args[1].(*List)
+	if !ok {
+		return nil, fmt.Errorf("%s(2nd arg): expected var-list: %v", name, args[1])
+	}
+	b := make([]bool, int(radbase)+len(radlist))
+	vars := make([]Level, xs.Len())
+	for i, x := range xs.v {
+		p, ok := x.(*Poly)
+		if !ok || !p.isVar() || b[p.lv] {
+			return nil, fmt.Errorf("%s(2nd arg): expected var-list: %v", name, args[1])
+		}
+		vars[i] = p.lv
+		b[p.lv] = true
+	}
+	for _, p := range ps {
+		c := make([]bool, int(radbase)+len(radlist))
+		if q, ok := p.(*Poly); ok {
+			q.Indets(c)
+		}
+		for lv, u := range c {
+			if u && !b[lv] {
+				return nil, fmt.Errorf("%s(): %s is not in the var-list", name, varstr(Level(lv)))
+			}
+		}
+	}
+
+	ret, err := g.RealSolve(ps, vars)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %s", name, err.Error())
+	}
+	return ret, nil
+}
+
+func funcSolve(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	f, ok := args[0].(Fof)
+	if !ok {
+		return nil, fmt.Errorf("%s(1st arg): expected formula: %v", name, args[0])
+	}
+	x, ok := args[1].(*Poly)
+	if !ok || !x.isVar() {
+		return nil, fmt.Errorf("%s(2nd arg): expected var: %v", name, args[1])
+	}
+	ret, err := Solve(f, x.lv)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %s", name, err.Error())
+	}
+	return ret, nil
+}
+
+func funcNRoots(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	if len(args) == 3 {
+		return nil, fmt.Errorf("%s(): expected 2 or 4 args", name)
+	}
+	x, ok := args[1].(*Poly)
+	if !ok || !x.isVar() {
+		return nil, fmt.Errorf("%s(2nd arg): expected var: %v", name, args[1])
+	}
+	p, ok := args[0].(*Poly)
+	if !ok || p.lv != x.lv || !p.isUnivariate() {
+		return nil, fmt.Errorf("%s(1st arg): expected univariate polynomial in %v: %v", name, x, args[0])
+	}
+	var ab [2]NObj
+	for i := 2; i < len(args); i++ {
+		switch c := args[i].(type) {
+		case *Int, *Rat, *RealAlg:
+			ab[i-2] = c.(NObj)
+		case *String:
+			if (i == 2 && c.s == "-inf") || (i == 3 && c.s == "inf") {
+				break
+			}
+			return nil, fmt.Errorf("%s(%d-th arg): expected number: %v", name, i+1, args[i])
+		default:
+			return nil, fmt.Errorf("%s(%d-th arg): expected number: %v", name, i+1, args[i])
+		}
+	}
+	n, err := p.NumRealRoots(x.lv, ab[0], ab[1])
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %s", name, err.Error())
+	}
+	return NewInt(int64(n)), nil
+}
+
+func funcSturm(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	x, ok := args[1].(*Poly)
+	if !ok || !x.isVar() {
+		return nil, fmt.Errorf("%s(2nd arg): expected var: %v", name, args[1])
+	}
+	p, ok := args[0].(*Poly)
+	if !ok {
+		return nil, fmt.Errorf("%s(1st arg): expected polynomial: %v", name, args[0])
+	}
+	seq, err := p.Sturm(x.lv)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %s", name, err.Error())
+	}
+	ret := NewList()
+	for _, s := range seq {
+		ret.Append(s)
+	}
+	return ret, nil
+}
+
+func funcSturmHabicht(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	x, ok := args[2].(*Poly)
+	if !ok || !x.isVar() {
+		return nil, fmt.Errorf("%s(3rd arg): expected var: %v", name, args[2])
+	}
+	p, ok := args[0].(*Poly)
 	if !ok {
-		return nil, fmt.Errorf("%s(): expected int: %v", name, args[1])
+		return nil, fmt.Errorf("%s(1st arg): expected polynomial: %v", name, args[0])
+	}
+	q, ok := args[1].(RObj)
+	if !ok || q.IsZero() {
+		return nil, fmt.Errorf("%s(2nd arg): expected nonzero polynomial: %v", name, args[1])
+	}
+	seq, err := SturmHabicht(p, q, x.lv)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %s", name, err.Error())
+	}
+	ret := NewList()
+	for _, s := range seq {
+		ret.Append(s)
 	}
+	return ret, nil
+}
 
-	return p.RealRootIsolation(int(q.Int64()))
+func funcSqrt(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	p, ok := args[0].(RObj)
+	if !ok {
+		return nil, fmt.Errorf("%s(): expected polynomial: %v", name, args[0])
+	}
+	r, err := NewRoot(p, 2)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %s", name, err.Error())
+	}
+	return r, nil
+}
+
+func funcRoot(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	p, ok := args[0].(RObj)
+	if !ok {
+		return nil, fmt.Errorf("%s(1st arg): expected polynomial: %v", name, args[0])
+	}
+	k, ok := args[1].(*Int)
+	if !ok || !k.IsInt64() || k.Sign() <= 0 || k.Int64() > 1000 {
+		return nil, fmt.Errorf("%s(2nd arg): expected positive integer: %v", name, args[1])
+	}
+	r, err := NewRoot(p, int(k.Int64()))
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %s", name, err.Error())
+	}
+	return r, nil
 }
 
 func funcRootBound(g *Ganrac, name string, args []interface{}) (interface{}, error) {
@@ -844,7 +1836,7 @@ func funcRootBound(g *Ganrac, name string, args []interface{}) (interface{}, err
 }
 
 func funcIndets(g *Ganrac, name string, args []interface{}) (interface{}, error) {
-	b := make([]bool, len(varlist))
+	b := make([]bool, int(radbase)+len(radlist))
 	p, ok := args[0].(indeter)
 	if !ok {
 		return NewList(), nil
@@ -925,11 +1917,212 @@ func funcIGCD(g *Ganrac, name string, args []interface{}) (interface{}, error) {
 ////////////////////////////////////////////////////////////
 
 func funcLoad(g *Ganrac, name string, args []interface{}) (interface{}, error) {
-	return nil, fmt.Errorf("%s not implemented", name) // @TODO
+	fname, ok := args[0].(*String)
+	if !ok {
+		return nil, fmt.Errorf("%s(): expected string", name)
+	}
+	fp, err := os.Open(fname.s)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	defer fp.Close()
+	p, err := g.LoadScript(fp, fname.s)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	return p, nil
 }
 
 func funcSave(g *Ganrac, name string, args []interface{}) (interface{}, error) {
-	return nil, fmt.Errorf("%s not implemented", name) // @TODO
+	fname, ok := args[1].(*String)
+	if !ok {
+		return nil, fmt.Errorf("%s(2nd arg): expected string", name)
+	}
+	if !saveable(args[0]) {
+		return nil, fmt.Errorf("%s(1st arg): unsupported object", name)
+	}
+	fp, err := os.Create(fname.s)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	err = g.Save(fp, args[0])
+	if cerr := fp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	return nil, nil
+}
+
+func funcSaveSession(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	fname, ok := args[0].(*String)
+	if !ok {
+		return nil, fmt.Errorf("%s(): expected string", name)
+	}
+	if err := g.SaveSessionFile(fname.s); err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	return nil, nil
+}
+
+func funcLoadSession(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	fname, ok := args[0].(*String)
+	if !ok {
+		return nil, fmt.Errorf("%s(): expected string", name)
+	}
+	if err := g.LoadSessionFile(fname.s); err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	return nil, nil
+}
+
+func funcCheckSat(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	f, ok := args[0].(Fof)
+	if !ok {
+		return nil, fmt.Errorf("%s(): expected FOF", name)
+	}
+	s, err := g.CheckSat(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	return NewString(s), nil
+}
+
+func funcSMT2Load(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	fname, ok := args[0].(*String)
+	if !ok {
+		return nil, fmt.Errorf("%s(): expected string", name)
+	}
+	fp, err := os.Open(fname.s)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	defer fp.Close()
+	f, err := g.LoadSMT2(fp)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	return f, nil
+}
+
+func funcQEPCADLoad(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	fname, ok := args[0].(*String)
+	if !ok {
+		return nil, fmt.Errorf("%s(): expected string", name)
+	}
+	fp, err := os.Open(fname.s)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	defer fp.Close()
+	f, err := g.LoadQEPCAD(fp)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	return f, nil
+}
+
+func funcRedlogLoad(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	fname, ok := args[0].(*String)
+	if !ok {
+		return nil, fmt.Errorf("%s(): expected string", name)
+	}
+	fp, err := os.Open(fname.s)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	defer fp.Close()
+	fs, err := g.LoadRedlog(fp)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", name, err)
+	}
+	ret := NewList()
+	for _, f := range fs {
+		ret.Append(f)
+	}
+	return ret, nil
+}
+
+func funcAbs(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	x, ok := args[0].(RObj)
+	if !ok {
+		return nil, fmt.Errorf("%s(): expected polynomial: %v", name, args[0])
+	}
+	return NewAbs(x), nil
+}
+
+func funcMax(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	return funcMinMax(name, args, NewMax)
+}
+
+func funcMin(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	return funcMinMax(name, args, NewMin)
+}
+
+func funcMinMax(name string, args []interface{}, f func(x, y RObj) RObj) (interface{}, error) {
+	var ret RObj
+	for i, a := range args {
+		x, ok := a.(RObj)
+		if !ok {
+			return nil, fmt.Errorf("%s(%dth arg): expected polynomial: %v", name, i+1, a)
+		}
+		if i == 0 {
+			ret = x
+		} else if err := checkRealAlg(ret, x); err != nil {
+			return nil, fmt.Errorf("%s(%dth arg): %w", name, i+1, err)
+		} else {
+			ret = f(ret, x)
+		}
+	}
+	return ret, nil
+}
+
+func funcPiecewise(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	conds := make([]Fof, len(args))
+	vals := make([]RObj, len(args))
+	for i, a := range args {
+		l, ok := a.(*List)
+		if !ok || l.Len() != 2 {
+			return nil, fmt.Errorf("%s(%dth arg): expected [cond, val]", name, i+1)
+		}
+		if conds[i], ok = l.v[0].(Fof); !ok {
+			return nil, fmt.Errorf("%s(%dth arg): expected FOF: %v", name, i+1, l.v[0])
+		}
+		if vals[i], ok = l.v[1].(RObj); !ok {
+			return nil, fmt.Errorf("%s(%dth arg): expected polynomial: %v", name, i+1, l.v[1])
+		}
+	}
+	for _, c := range conds {
+		if _, ok := c.(*AtomF); !ok {
+			return newPiecewise(conds, vals), nil
+		}
+	}
+	return nil, fmt.Errorf("%s(): all conditions are false", name)
+}
+
+func funcRange(g *Ganrac, name string, args []interface{}) (interface{}, error) {
+	var v [3]int64
+	v[0] = 0
+	v[2] = 1
+	for i, a := range args {
+		c, ok := a.(*Int)
+		if !ok || !c.IsInt64() {
+			return nil, fmt.Errorf("%s(%dth arg): expected integer", name, i+1)
+		}
+		v[i] = c.Int64()
+	}
+	if len(args) == 1 {
+		v[0], v[1] = 0, v[0]
+	}
+	if v[2] == 0 {
+		return nil, fmt.Errorf("%s(3rd arg): step should be nonzero", name)
+	}
+	ret := NewList()
+	for i := v[0]; (v[2] > 0 && i < v[1]) || (v[2] < 0 && i > v[1]); i += v[2] {
+		ret.Append(NewInt(i))
+	}
+	return ret, nil
 }
 
 func funcLen(g *Ganrac, name string, args []interface{}) (interface{}, error) {
@@ -959,6 +2152,7 @@ func funcHelps(builtin_func_table []func_table, name string) (interface{}, error
 		fmt.Printf("==========\n")
 		fmt.Printf("TOKENS:\n")
 		fmt.Printf("  integer      : `[0-9]+`\n")
+		fmt.Printf("  decimal      : `[0-9]+(\\.[0-9]*)?([eE][+-]?[0-9]+)?`, an exact rational\n")
 		fmt.Printf("  string       : `\"[^\"]*\"`\n")
 		fmt.Printf("  indeterminate: `[a-z][a-zA-Z0-9_]*`\n")
 		fmt.Printf("  variable     : `[A-Z][a-zA-Z0-9_]*`\n")
@@ -966,8 +2160,20 @@ func funcHelps(builtin_func_table []func_table, name string) (interface{}, error
 		fmt.Printf("\n")
 		fmt.Printf("OPERATORS:\n")
 		fmt.Printf("  + - * / ^\n")
-		fmt.Printf("  < <= > >= == !=\n")
-		fmt.Printf("  && ||\n")
+		fmt.Printf("  < <= > >= == !=      (0 < x <= 1 is 0 < x && x <= 1)\n")
+		fmt.Printf("  ! && || ==> <== <==>\n")
+		fmt.Printf("  ∀x,y (fof)  ∃x (fof)\n")
+		fmt.Printf("  ∧ ∨ ¬ ≤ ≥ ≠ ⇒ ⇐ ⇔\n")
+		fmt.Printf("\n")
+		fmt.Printf("COMMENTS:\n")
+		fmt.Printf("  # ...    /* ... */\n")
+		fmt.Printf("\n")
+		fmt.Printf("STATEMENTS: (a statement at the top level ends with ';')\n")
+		fmt.Printf("  if (fof) { ... } else { ... };\n")
+		fmt.Printf("  while (fof) { ... };\n")
+		fmt.Printf("  for (X in list) { ... };\n")
+		fmt.Printf("  def f(X, Y) { ...; return X + Y; };\n")
+		fmt.Printf("  break; continue;\n")
 		fmt.Printf("\n")
 		fmt.Printf("FUNCTIONS:\n")
 		for _, fv := range builtin_func_table {
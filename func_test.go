@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestBuiltinFuncTable(t *testing.T) {
@@ -81,3 +82,26 @@ func TestHelpExamples(t *testing.T) {
 		}
 	}
 }
+
+func TestFuncArgDuration(t *testing.T) {
+	for ii, s := range []struct {
+		v      GObj
+		ok     bool
+		expect time.Duration
+	}{
+		{NewInt(60), true, 60 * time.Second},
+		{NewInt(0), true, 0},
+		{NewRatInt64(1, 2), true, 500 * time.Millisecond},
+		{NewRatInt64(1, 3), true, 333333334 * time.Nanosecond},
+		{NewRatInt64(1, 3000000000), true, time.Nanosecond},
+		{NewInt(-1), false, 0},
+		{NewRatInt64(-1, 2), false, 0},
+		{NewInt(10000000000), false, 0}, // overflow
+		{NewString("60"), false, 0},
+	} {
+		d, ok := funcArgDuration(s.v)
+		if ok != s.ok || ok && d != s.expect {
+			t.Errorf("[%d] v=%v, expect=(%v,%v), actual=(%v,%v)", ii, s.v, s.expect, s.ok, d, ok)
+		}
+	}
+}
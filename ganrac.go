@@ -1,6 +1,7 @@
 package ganrac
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -23,15 +24,19 @@ type Ganrac struct {
 	sones, sfuns       []token
 	history            []interface{}
 	builtin_func_table []func_table
+	user_func          map[string]*userFunc
+	locals             []map[string]interface{} // 関数呼び出し中の局所変数
 	ox                 *OpenXM
 	logger             *log.Logger
 	verbose            int
 	verbose_cad        int
+	ctx                context.Context // 評価中の文の中断用
 }
 
 func NewGANRAC() *Ganrac {
 	g := new(Ganrac)
 	g.varmap = make(map[string]interface{}, 100)
+	g.user_func = make(map[string]*userFunc)
 	g.InitVarList([]string{
 		"x", "y", "z", "w", "a", "b", "c", "d", "e", "f", "g", "h",
 	})
@@ -52,15 +57,30 @@ func NewGANRAC() *Ganrac {
 		{",", comma},
 		{";", eol},
 		{":", eolq},
+		{"==>", impl},
 		{"==", eqop},
 		{"=", assign},
 		{"!=", neop},
+		{"!", not},
+		{"<==>", equiv},
+		{"<==", repl},
 		{"<=", leop},
 		{"<", ltop},
 		{">=", geop},
 		{">", gtop},
 		{"&&", and},
 		{"||", or},
+		{"∀", all},
+		{"∃", ex},
+		{"∧", and},
+		{"∨", or},
+		{"¬", not},
+		{"≤", leop},
+		{"≥", geop},
+		{"≠", neop},
+		{"⇒", impl},
+		{"⇐", repl},
+		{"⇔", equiv},
 	}
 
 	g.sfuns = []token{
@@ -74,6 +94,15 @@ func NewGANRAC() *Ganrac {
 		{"time", f_time},
 		{"true", f_true},
 		{"false", f_false},
+		{"if", k_if},
+		{"else", k_else},
+		{"while", k_while},
+		{"for", k_for},
+		{"in", k_in},
+		{"def", k_def},
+		{"return", k_return},
+		{"break", k_break},
+		{"continue", k_continue},
 	}
 
 	return g
@@ -132,9 +161,27 @@ func (g *Ganrac) Eval(r io.Reader) (interface{}, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = checkVars(pp); err != nil {
+		return nil, err
+	}
 	return pp, nil
 }
 
+// EvalContext is like Eval, but qe() and the CAD functions
+// return ctx.Err() when ctx is done.
+func (g *Ganrac) EvalContext(ctx context.Context, r io.Reader) (interface{}, error) {
+	g.ctx = ctx
+	defer func() { g.ctx = nil }()
+	return g.Eval(r)
+}
+
+func (g *Ganrac) context() context.Context {
+	if g.ctx == nil {
+		return context.Background()
+	}
+	return g.ctx
+}
+
 func (g *Ganrac) SetLogger(logger *log.Logger) {
 	g.logger = logger
 }
@@ -9,6 +9,8 @@ const (
 	TAG_STR
 	TAG_NUM
 	TAG_POLY
+	TAG_RATFUNC
+	TAG_PIECEWISE
 	TAG_FOF
 	TAG_LIST
 	TAG_CAD
@@ -17,6 +19,8 @@ const (
 	FORMAT_DUMP   = 'V'
 	FORMAT_SRC    = 'S'
 	FORMAT_QEPCAD = 'Q'
+	FORMAT_SMT2   = 'M'
+	FORMAT_REDLOG = 'R'
 )
 
 // ganrac object
@@ -69,6 +69,8 @@ func (x *Int) Add(y RObj) RObj {
 		return z
 	case *BinInt:
 		return yi.AddInt(x)
+	case *RealAlg:
+		return yi.Add(x)
 	case *Interval:
 		if x.IsZero() {
 			return zero.toIntv(1)
@@ -90,6 +92,8 @@ func (x *Int) Sub(y RObj) RObj {
 		z := newRat()
 		z.n.Sub(xr, yi.n)
 		return z
+	case *RealAlg:
+		return yi.Neg().Add(x)
 	}
 	fmt.Printf("sub: x=%v, y=%v\n", x, y)
 	panic("not implememted")
@@ -109,6 +113,8 @@ func (x *Int) Mul(y RObj) RObj {
 		return z.normal()
 	case *BinInt:
 		return yi.MulInt(x)
+	case *RealAlg:
+		return yi.Mul(x)
 	case *Poly:
 		fmt.Printf("mul: x=%v, y=%v\n", x, y)
 		panic("poly!")
@@ -147,6 +153,8 @@ func (x *Int) Div(yy NObj) RObj {
 		z := newRat()
 		z.n.Mul(xr, yr)
 		return z.normal()
+	case *RealAlg:
+		return y.inv().Mul(x)
 	}
 	panic("not implemented") // @TODO
 }
@@ -205,8 +213,14 @@ func (x *Int) Format(s fmt.State, format rune) {
 		}
 		f.SetInt(x.n)
 		f.Format(s, format)
-	case FORMAT_DUMP, FORMAT_TEX, FORMAT_QEPCAD:
+	case FORMAT_DUMP, FORMAT_TEX, FORMAT_QEPCAD, FORMAT_REDLOG:
 		x.n.Format(s, 'd')
+	case FORMAT_SMT2:
+		if x.Sign() < 0 {
+			fmt.Fprintf(s, "(- %v)", new(big.Int).Neg(x.n))
+		} else {
+			x.n.Format(s, 'd')
+		}
 	case FORMAT_SRC:
 		if x.n.IsInt64() {
 			if x.IsZero() {
@@ -268,7 +282,8 @@ func (z *Int) Cmp(xx NObj) int {
 			zr.Lsh(z.n, uint(-x.m))
 			return zr.Cmp(x.n)
 		}
-
+	case *RealAlg:
+		return -x.Cmp(z)
 	}
 	panic("unknown")
 }
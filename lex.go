@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"text/scanner"
 )
 
@@ -27,6 +28,26 @@ func (n *pNode) String() string {
 	return n.str + ":" + strconv.Itoa(n.cmd)
 }
 
+// 子ノードの数
+func (n *pNode) arity() int {
+	switch n.cmd {
+	case plus, minus, mult, div, pow, and, or, impl, repl, equiv,
+		ltop, gtop, leop, geop, neop, eqop, assign, lb, k_while:
+		return 2
+	case unaryminus, not, f_time, eol:
+		return 1
+	case call, list, initvar, eolq, block, k_if, k_return:
+		return n.extra
+	case dict:
+		return 2 * n.extra // key, value
+	case k_for:
+		return 3 // list, body, var
+	case k_def:
+		return n.extra + 1 // args, body
+	}
+	return 0
+}
+
 ///////////////////////////////////////////////////////////////
 // STACK
 ///////////////////////////////////////////////////////////////
@@ -52,6 +73,32 @@ func (s *pStack) Popn(n int) *pStack {
 	return stack
 }
 
+// PopTree は部分木を取り出す
+func (s *pStack) PopTree() *pStack {
+	n := 0
+	for m := 1; m > 0; m-- {
+		n++
+		m += s.v[len(s.v)-n].arity()
+	}
+	return s.Popn(n)
+}
+
+// PopTrees は n 個の部分木を取り出す. 順序は push した順.
+func (s *pStack) PopTrees(n int) []*pStack {
+	ret := make([]*pStack, n)
+	for i := n - 1; i >= 0; i-- {
+		ret[i] = s.PopTree()
+	}
+	return ret
+}
+
+func (s *pStack) Clone() *pStack {
+	stack := new(pStack)
+	stack.v = make([]pNode, len(s.v))
+	copy(stack.v, s.v)
+	return stack
+}
+
 func (s *pStack) Push(v pNode) {
 	s.v = append(s.v, v)
 }
@@ -135,41 +182,82 @@ func (l *pLexer) skip_space() {
 		if l.Peek() != '#' {
 			break
 		}
-		for l.Peek() != '\n' { // 改行までコメント
+		for l.Peek() != '\n' && l.Peek() != scanner.EOF { // 改行までコメント
 			l.Next()
 		}
 	}
 }
 
+func (l *pLexer) isSymbolPrefix(str string) bool {
+	for _, s := range l.sones {
+		if strings.HasPrefix(s.val, str) {
+			return true
+		}
+	}
+	return false
+}
+
 // 字句解析機
 func (l *pLexer) Lex(lval *yySymType) int {
 	l.skip_space()
 
 	c := l.Peek()
-	for i, s := range l.sones { // 記号系
-		// if rune(s.val[0]) == c && (len(s.val) == 1 || l.Peek() == rune(s.val[1])) {
-		if rune(s.val[0]) == c {
-			l.Next()
-			c2 := l.Peek()
-			for ; i < len(l.sones); i++ {
-				s2 := l.sones[i]
-				if rune(s2.val[0]) == c && (len(s2.val) == 1 || rune(s2.val[1]) == c2) {
-					lval.node = newPNode(s2.val, s2.label, 0, l.Pos())
-					if len(s2.val) > 1 {
-						l.Next()
-					}
-					return s2.label
-				}
+	if c == '/' { // ブロックコメント /* ... */
+		l.Next()
+		if l.Peek() != '*' {
+			lval.node = newPNode("/", div, 0, l.Pos())
+			return div
+		}
+		l.Next()
+		for prev := ' '; prev != '*' || l.Peek() != '/'; {
+			if l.Peek() == scanner.EOF {
+				l.Error("unterminated comment")
+				return scanner.EOF
+			}
+			prev = l.Next()
+		}
+		l.Next()
+		return l.Lex(lval)
+	}
+
+	if str := string(c); l.isSymbolPrefix(str) { // 記号系. 最長一致
+		l.Next()
+		for l.isSymbolPrefix(str + string(l.Peek())) {
+			str += string(l.Next())
+		}
+		for _, s := range l.sones {
+			if s.val == str {
+				lval.node = newPNode(s.val, s.label, 0, l.Pos())
+				return s.label
 			}
-			return int(c)
 		}
+		return int(c)
 	}
 
-	if l.isdigit(l.Peek()) { // Integer
+	if l.isdigit(l.Peek()) { // Integer or decimal
 		var ret []rune
 		for l.isdigit(l.Peek()) {
 			ret = append(ret, l.Next())
 		}
+		if l.Peek() == '.' {
+			ret = append(ret, l.Next())
+			for l.isdigit(l.Peek()) {
+				ret = append(ret, l.Next())
+			}
+		}
+		if l.Peek() == 'e' || l.Peek() == 'E' {
+			ret = append(ret, l.Next())
+			if l.Peek() == '+' || l.Peek() == '-' {
+				ret = append(ret, l.Next())
+			}
+			if !l.isdigit(l.Peek()) {
+				l.Error("invalid number: " + string(ret))
+				return number
+			}
+			for l.isdigit(l.Peek()) {
+				ret = append(ret, l.Next())
+			}
+		}
 		lval.node = newPNode(string(ret), number, 0, l.Pos())
 		return number
 	}
@@ -17,15 +17,20 @@ func TestLexer(t *testing.T) {
 		{"1+:", []int{number, plus, eolq, -1}},
 		{":;", []int{eolq, eol, -1}},
 		{"1+x;", []int{number, plus, ident, eol, -1}},
+		{"1.5 2. 3e4 5.6E-7 8e+9x", []int{number, number, number, number, number, ident, -1}},
 		{"1 + > 3 >= 3 ;;;   ", []int{number, plus, gtop, number, geop, number, eol, eol, eol, -1}},
 		{"[<>>>=>====<>=)", []int{lb, ltop, gtop, gtop, geop, geop, eqop, assign, ltop, geop, rp, -1}},
 		{">=1a!=5)", []int{geop, number, ident, neop, number, rp, -1}},
-		{"&&||,,/*", []int{and, or, comma, comma, div, mult, -1}},
+		{"&&||,,/ *", []int{and, or, comma, comma, div, mult, -1}},
+		{"! ==> <== <==> <=> !==", []int{not, impl, repl, equiv, leop, gtop, neop, assign, -1}},
+		{"∀∃∧∨¬≤≥≠⇒⇐⇔", []int{all, ex, and, or, not, leop, geop, neop, impl, repl, equiv, -1}},
+		{"1 /* 2 * 3 */ 4 /**/ 5 /***/ 6 # 7", []int{number, number, number, number, -1}},
 		{"A B a b true True false FALSE", []int{name, name, ident, ident, f_true, name, f_false, name}},
 		{"x = 0", []int{ident, assign, number}},
 		{"\"3\" 3 x", []int{t_str, number, ident}},
 		{"\"3;\" 3 x", []int{t_str, number, ident}},
 		{"{a:1}", []int{lc, ident, eolq, number, rc}},
+		{"if else while for in def return break continue iff", []int{k_if, k_else, k_while, k_for, k_in, k_def, k_return, k_break, k_continue, ident}},
 		{"{a: 1, \"b\" = x}", []int{lc, ident, eolq, number, comma, t_str, assign, ident, rc}},
 	} {
 		l := g.genLexer(strings.NewReader(s.str))
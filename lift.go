@@ -5,6 +5,7 @@ package ganrac
 // symoblic numeric computation 2009.
 
 import (
+	"context"
 	"fmt"
 	"math/big"
 	"time"
@@ -110,13 +111,27 @@ func (cell *Cell) set_parent_and_truth_other(cad *CAD) {
 }
 
 func (cad *CAD) Lift(index ...int) error {
+	return cad.LiftContext(context.Background(), index...)
+}
+
+// LiftContext lifts the cells.
+// It returns ctx.Err() if ctx is done between cell lifts.
+// The canceled lifting is resumed by calling Lift again.
+func (cad *CAD) LiftContext(ctx context.Context, index ...int) error {
 	if cad.stage != 1 {
 		return fmt.Errorf("invalid stage")
 	}
 	cad.log(2, "cad.Lift %v\n", index)
 	if len(index) == 0 { // 指定なしなので，最後までやる.
+		// 保存した CAD から再開したときは, 保存までの時間に加える
 		tm_start := time.Now()
+		tm_lift := cad.stat.tm[1]
+		cad.ckpt_tm = tm_start
 		for !cad.stack.empty() {
+			if err := ctx.Err(); err != nil {
+				cad.stat.tm[1] = tm_lift + time.Since(tm_start)
+				return err
+			}
 			cell := cad.stack.pop()
 			if cell.truth >= 0 {
 				continue
@@ -128,11 +143,24 @@ func (cad *CAD) Lift(index ...int) error {
 				if err := cell.lift(cad); err != nil {
 					return err
 				}
+				cad.progressed()
+				if cad.tooManyCells() {
+					cad.stat.tm[1] = tm_lift + time.Since(tm_start)
+					return CAD_TOO_MANY_CELLS
+				}
+			}
+			// スタックとセルの木が整合しているセルの持ち上げの間で保存する
+			cad.stat.tm[1] = tm_lift + time.Since(tm_start)
+			if err := cad.checkpoint(false); err != nil {
+				return err
 			}
 		}
 		err := cad.root.valid(cad)
 		cad.stage = CAD_STAGE_LIFTED
-		cad.stat.tm[1] = time.Since(tm_start)
+		cad.stat.tm[1] = tm_lift + time.Since(tm_start)
+		if err == nil {
+			err = cad.checkpoint(true)
+		}
 		return err
 	}
 	c := cad.root
@@ -320,6 +348,34 @@ func (cell *Cell) rlift(cad *CAD, lv Level, proj_num []int) error {
 func (cell *Cell) lift(cad *CAD) error {
 	cad.log(2, "lift (%v)\n", cell.Index())
 	cad.stat.lift[cell.lv+1]++
+	if err := cell.makeStack(cad); err != nil {
+		return err
+	}
+
+	cs := cell.children
+	undefined := false
+	for _, c := range cs {
+		switch c.evalTruth(cad.fml, cad).(type) {
+		case *AtomT:
+			cad.stat.true_cell[c.lv]++
+			c.truth = t_true
+		case *AtomF:
+			cad.stat.false_cell[c.lv]++
+			c.truth = t_false
+		default:
+			undefined = true
+		}
+		cad.stat.cell[c.lv]++
+	}
+
+	// 真偽値確認して
+	cell.lift_term(cad, undefined)
+
+	return nil
+}
+
+// makeStack は cell の上のスタックを作り, 子供に設定する.
+func (cell *Cell) makeStack(cad *CAD) error {
 	ciso := make([][]*Cell, cad.proj[cell.lv+1].Len())
 	signs := make([]sign_t, len(ciso))
 	for i, pf := range cad.proj[cell.lv+1].gets() {
@@ -376,26 +432,6 @@ func (cell *Cell) lift(cad *CAD) error {
 
 	// signature 設定して
 	cell.set_signatures(cs, signs)
-
-	cs = cell.children
-	undefined := false
-	for _, c := range cs {
-		switch c.evalTruth(cad.fml, cad).(type) {
-		case *AtomT:
-			cad.stat.true_cell[c.lv]++
-			c.truth = t_true
-		case *AtomF:
-			cad.stat.false_cell[c.lv]++
-			c.truth = t_false
-		default:
-			undefined = true
-		}
-		cad.stat.cell[c.lv]++
-	}
-
-	// 真偽値確認して
-	cell.lift_term(cad, undefined)
-
 	return nil
 }
 
@@ -500,47 +536,40 @@ func (cell *Cell) evalTruth(formula Fof, cad *CAD) Fof {
 	panic("stop")
 }
 
-func (cad *CAD) midSamplePoint(c, d *Cell) NObj {
-	// @TODO 代入しやすいサンプル点を生成する.
-	if c.intv.inf != nil && d.intv.inf != nil {
-		return c.intv.sup.Add(d.intv.inf).Div(two).(NObj)
+// isoBound は c の分離区間の端点を有理数で返す
+func (c *Cell) isoBound(upper bool) *big.Rat {
+	if c.intv.inf != nil {
+		if upper {
+			return toBigRat(c.intv.sup)
+		}
+		return toBigRat(c.intv.inf)
 	}
-
-	var di, ci *Interval
-	if c.nintv == nil {
+	ci := c.nintv
+	if ci == nil {
 		ci = c.getNumIsoIntv(50)
-	} else {
-		ci = c.nintv
 	}
-	if d.nintv == nil {
-		di = d.getNumIsoIntv(50)
+	r := new(big.Rat)
+	if upper {
+		ci.sup.Rat(r)
 	} else {
-		di = d.nintv
+		ci.inf.Rat(r)
 	}
+	return r
+}
 
-	// ciz := new(big.Int)
-	// diz := new(big.Int)
-	// ci.sup.Int(ciz)
-	// di.inf.Int(diz)
-	// fmt.Printf("ci=%f, di=%f\n", ci, di)
-	// fmt.Printf("ciz=%v, diz=%v\n", ciz, diz)
-	// if ci.Cmp(di) <= 0 {
-	// 	return NewIntZ(ciz)
-	// }
-
-	f := new(big.Float)
-	f.Add(ci.sup, di.inf)
-	f.Quo(f, big.NewFloat(2))
-
-	rat := newRat()
-	f.Rat(rat.n)
-
-	return rat
+func (cad *CAD) midSamplePoint(c, d *Cell) NObj {
+	// 代入しやすいように, セクタ内で分母が最小の有理数を選ぶ.
+	lo := c.isoBound(true)
+	hi := d.isoBound(false)
+	if lo.Cmp(hi) < 0 {
+		return newRatBig(simplestRatOpen(lo, hi))
+	}
+	m := new(big.Rat).Add(lo, hi)
+	return newRatBig(m.Quo(m, big.NewRat(2, 1)))
 }
 
 func (cad *CAD) setSamplePoint(cells []*Cell, idx int) {
 	// set a sample point to cs[idx] where cs[idx] is a sector
-	// @TODO 整数を優先するとか
 	if idx%2 != 0 {
 		panic("invalid index")
 	}
@@ -549,31 +578,14 @@ func (cad *CAD) setSamplePoint(cells []*Cell, idx int) {
 		return
 	}
 	if idx == 0 {
-		if cells[1].intv.inf != nil {
-			c.intv.inf = cells[1].intv.inf.Sub(one).(NObj)
-		} else {
-			f_one := big.NewFloat(1)
-			f := new(big.Float)
-			f.Sub(cells[1].nintv.inf, f_one)
-			ii := newInt()
-			f.Int(ii.n)
-			c.intv.inf = ii
-		}
+		// (-inf, cells[1])
+		r := simplestRatOpen(new(big.Rat).Neg(cells[1].isoBound(false)), nil)
+		c.intv.inf = newRatBig(r.Neg(r))
 	} else if idx < len(cells)-1 {
-
-		m := cad.midSamplePoint(cells[idx-1], cells[idx+1])
-		c.intv.inf = m
+		c.intv.inf = cad.midSamplePoint(cells[idx-1], cells[idx+1])
 	} else {
-		if cells[idx-1].intv.inf != nil {
-			c.intv.inf = cells[idx-1].intv.sup.Add(one).(NObj)
-		} else {
-			f_one := big.NewFloat(1)
-			f := new(big.Float)
-			f.Add(cells[idx-1].nintv.sup, f_one)
-			ii := newInt()
-			f.Int(ii.n)
-			c.intv.inf = ii
-		}
+		// (cells[idx-1], inf)
+		c.intv.inf = newRatBig(simplestRatOpen(cells[idx-1].isoBound(true), nil))
 	}
 	c.intv.sup = c.intv.inf
 }
@@ -7,10 +7,11 @@ const (
 	NTAG_BININT
 	NTAG_INTERVAL
 	NTAG_MOD
+	NTAG_REALALG
 )
 
 // numeric
-// *Int, *Rat, *BinInt, *Interval
+// *Int, *Rat, *BinInt, *Interval, *RealAlg
 type NObj interface {
 	RObj
 	numTag() uint
@@ -20,6 +20,8 @@ type varInfo struct {
 func varstr(lv Level) string {
 	if 0 <= lv && int(lv) < len(varlist) {
 		return varlist[lv].v
+	} else if r := getRadical(lv); r != nil {
+		return r.String()
 	} else {
 		return fmt.Sprintf("$%d", lv)
 	}
@@ -67,6 +69,11 @@ func (g *Ganrac) InitVarList(vlist []string) error {
 				return fmt.Errorf("%s is reserved", v)
 			}
 		}
+		for _, t := range g.sfuns {
+			if v == t.val {
+				return fmt.Errorf("%s is reserved", v)
+			}
+		}
 		for j := 0; j < i; j++ {
 			if vlist[j] == v {
 				return fmt.Errorf("%s is duplicated", v)
@@ -80,6 +87,9 @@ func (g *Ganrac) InitVarList(vlist []string) error {
 		varlist[i] = varInfo{vlist[i], NewPolyCoef(Level(i), 0, 1)}
 		varstr2lv[vlist[i]] = Level(i)
 	}
+	// 冪根は変数の後ろのレベルなので作り直す. 古い冪根は checkVars() で弾く
+	radlist = nil
+	radbase = Level(len(vlist))
 
 	return nil
 }
@@ -93,7 +103,7 @@ func Add(x, y RObj) RObj {
 }
 
 func Sub(x, y RObj) RObj {
-	if y.IsNumeric() || !x.IsNumeric() {
+	if x.Tag() >= y.Tag() {
 		return x.Sub(y)
 	} else {
 		// num - poly
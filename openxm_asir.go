@@ -183,6 +183,22 @@ func (ox *OpenXM) GB(p *List, vars *List, n int) *List {
 	return gob.(*List)
 }
 
+// GBLex は辞書式順序のグレブナー基底を返す
+func (ox *OpenXM) GBLex(p *List, vars *List) *List {
+	err := ox.ExecFunction("nd_gr", p, vars, zero, two)
+	if err != nil {
+		panic(fmt.Sprintf("gr failed: %v", err.Error()))
+	}
+	s, err := ox.PopCMO()
+	if err != nil {
+		fmt.Printf("gr failed: %v", err.Error())
+		return nil
+	}
+
+	gob := ox.toGObj(s)
+	return gob.(*List)
+}
+
 func (ox *OpenXM) Reduce(p *Poly, gb *List, vars *List, n int) (RObj, bool) {
 
 	var err error
@@ -37,29 +37,42 @@ const and = 57361
 const or = 57362
 const not = 57363
 const abs = 57364
-const plus = 57365
-const minus = 57366
-const comma = 57367
-const mult = 57368
-const div = 57369
-const pow = 57370
-const ltop = 57371
-const gtop = 57372
-const leop = 57373
-const geop = 57374
-const neop = 57375
-const eqop = 57376
-const assign = 57377
-const eol = 57378
-const eolq = 57379
-const lb = 57380
-const rb = 57381
-const lp = 57382
-const rp = 57383
-const lc = 57384
-const rc = 57385
-const unaryminus = 57386
-const unaryplus = 57387
+const impl = 57365
+const repl = 57366
+const equiv = 57367
+const plus = 57368
+const minus = 57369
+const comma = 57370
+const mult = 57371
+const div = 57372
+const pow = 57373
+const ltop = 57374
+const gtop = 57375
+const leop = 57376
+const geop = 57377
+const neop = 57378
+const eqop = 57379
+const assign = 57380
+const eol = 57381
+const eolq = 57382
+const lb = 57383
+const rb = 57384
+const lp = 57385
+const rp = 57386
+const lc = 57387
+const rc = 57388
+const k_if = 57389
+const k_else = 57390
+const k_while = 57391
+const k_for = 57392
+const k_in = 57393
+const k_def = 57394
+const k_return = 57395
+const k_break = 57396
+const k_continue = 57397
+const block = 57398
+const unaryminus = 57399
+const unaryplus = 57400
 
 var yyToknames = [...]string{
 	"$end",
@@ -84,6 +97,9 @@ var yyToknames = [...]string{
 	"or",
 	"not",
 	"abs",
+	"impl",
+	"repl",
+	"equiv",
 	"plus",
 	"minus",
 	"comma",
@@ -105,6 +121,16 @@ var yyToknames = [...]string{
 	"rp",
 	"lc",
 	"rc",
+	"k_if",
+	"k_else",
+	"k_while",
+	"k_for",
+	"k_in",
+	"k_def",
+	"k_return",
+	"k_break",
+	"k_continue",
+	"block",
 	"unaryminus",
 	"unaryplus",
 }
@@ -115,11 +141,11 @@ const yyEofCode = 1
 const yyErrCode = 2
 const yyInitialStackSize = 16
 
-//line parser.y:134
+//line parser.y:220
 /*  start  of  programs  */
 
 //line yacctab:1
-var yyExca = [...]int{
+var yyExca = [...]int8{
 	-1, 1,
 	1, -1,
 	-2, 0,
@@ -127,112 +153,181 @@ var yyExca = [...]int{
 
 const yyPrivate = 57344
 
-const yyLast = 284
-
-var yyAct = [...]int{
-	47, 4, 77, 84, 75, 75, 44, 50, 41, 39,
-	73, 45, 30, 51, 40, 90, 42, 43, 74, 83,
-	76, 81, 37, 92, 91, 52, 53, 54, 55, 56,
-	57, 58, 59, 60, 61, 62, 63, 64, 65, 66,
-	48, 72, 70, 79, 78, 18, 1, 71, 17, 49,
-	0, 67, 19, 14, 10, 9, 11, 12, 5, 7,
-	8, 6, 26, 27, 86, 28, 29, 30, 16, 15,
-	87, 0, 28, 29, 30, 0, 85, 37, 0, 88,
-	89, 2, 3, 20, 37, 13, 0, 21, 0, 0,
-	0, 0, 93, 94, 19, 14, 10, 9, 11, 12,
-	5, 7, 8, 6, 0, 0, 0, 0, 0, 0,
-	16, 15, 0, 0, 19, 14, 10, 9, 11, 12,
-	5, 7, 8, 6, 0, 20, 0, 13, 68, 21,
-	16, 15, 0, 0, 19, 14, 10, 9, 11, 12,
-	5, 7, 8, 6, 0, 20, 46, 13, 0, 21,
-	16, 15, 0, 26, 27, 0, 28, 29, 30, 31,
-	32, 33, 34, 36, 35, 20, 0, 13, 37, 21,
-	24, 25, 0, 0, 26, 27, 0, 28, 29, 30,
-	31, 32, 33, 34, 36, 35, 38, 0, 0, 37,
-	24, 25, 82, 0, 26, 27, 0, 28, 29, 30,
-	31, 32, 33, 34, 36, 35, 38, 0, 0, 37,
-	24, 25, 69, 0, 26, 27, 0, 28, 29, 30,
-	31, 32, 33, 34, 36, 35, 38, 24, 25, 37,
-	80, 26, 27, 0, 28, 29, 30, 31, 32, 33,
-	34, 36, 35, 38, 22, 23, 37, 24, 25, 0,
-	0, 26, 27, 0, 28, 29, 30, 31, 32, 33,
-	34, 36, 35, 38, 24, 0, 37, 0, 26, 27,
-	0, 28, 29, 30, 31, 32, 33, 34, 36, 35,
-	0, 0, 0, 37,
+const yyLast = 662
+
+var yyAct = [...]uint8{
+	69, 4, 131, 23, 142, 67, 119, 132, 154, 30,
+	109, 132, 107, 135, 113, 56, 107, 57, 72, 59,
+	60, 122, 104, 76, 73, 113, 106, 138, 108, 134,
+	117, 137, 115, 74, 75, 63, 78, 79, 80, 81,
+	82, 83, 84, 85, 86, 87, 88, 89, 90, 91,
+	92, 93, 94, 95, 70, 44, 121, 101, 62, 99,
+	61, 96, 58, 102, 55, 51, 105, 77, 111, 31,
+	32, 53, 54, 110, 162, 112, 161, 15, 130, 21,
+	76, 64, 151, 22, 17, 11, 10, 12, 13, 6,
+	8, 9, 7, 31, 32, 65, 100, 14, 42, 43,
+	44, 125, 19, 18, 103, 66, 1, 126, 124, 27,
+	51, 127, 128, 145, 5, 143, 141, 28, 120, 16,
+	133, 29, 155, 30, 136, 24, 25, 20, 26, 146,
+	147, 148, 139, 144, 71, 0, 0, 150, 152, 153,
+	40, 41, 144, 42, 43, 44, 156, 159, 0, 0,
+	0, 0, 163, 0, 0, 51, 0, 164, 165, 22,
+	17, 11, 10, 12, 13, 6, 8, 9, 7, 31,
+	32, 0, 0, 14, 0, 0, 0, 0, 19, 18,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	0, 143, 0, 28, 0, 16, 0, 29, 140, 30,
+	0, 24, 25, 0, 26, 146, 147, 148, 22, 17,
+	11, 10, 12, 13, 6, 8, 9, 7, 31, 32,
+	0, 0, 14, 0, 0, 0, 0, 19, 18, 0,
+	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	2, 3, 28, 0, 16, 0, 29, 0, 30, 0,
+	24, 25, 0, 26, 22, 17, 11, 10, 12, 13,
+	6, 8, 9, 7, 31, 32, 0, 0, 14, 0,
+	0, 0, 0, 19, 18, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 160, 0, 28, 0,
+	16, 0, 29, 22, 17, 11, 10, 12, 13, 6,
+	8, 9, 7, 31, 32, 0, 0, 14, 0, 0,
+	0, 0, 19, 18, 22, 17, 11, 10, 12, 13,
+	6, 8, 9, 7, 31, 32, 0, 28, 14, 16,
+	97, 29, 0, 19, 18, 0, 40, 41, 0, 42,
+	43, 44, 45, 46, 47, 48, 50, 49, 28, 68,
+	16, 51, 29, 22, 17, 11, 10, 12, 13, 6,
+	8, 9, 7, 31, 32, 0, 0, 14, 35, 0,
+	0, 0, 19, 18, 0, 40, 41, 0, 42, 43,
+	44, 45, 46, 47, 48, 50, 49, 28, 0, 16,
+	51, 29, 35, 36, 0, 0, 37, 38, 39, 40,
+	41, 0, 42, 43, 44, 45, 46, 47, 48, 50,
+	49, 52, 0, 0, 51, 35, 36, 149, 0, 37,
+	38, 39, 40, 41, 0, 42, 43, 44, 45, 46,
+	47, 48, 50, 49, 52, 0, 0, 51, 35, 36,
+	129, 0, 37, 38, 39, 40, 41, 0, 42, 43,
+	44, 45, 46, 47, 48, 50, 49, 52, 0, 0,
+	51, 35, 36, 123, 0, 37, 38, 39, 40, 41,
+	0, 42, 43, 44, 45, 46, 47, 48, 50, 49,
+	52, 0, 0, 51, 35, 36, 118, 0, 37, 38,
+	39, 40, 41, 0, 42, 43, 44, 45, 46, 47,
+	48, 50, 49, 52, 0, 0, 51, 35, 36, 116,
+	0, 37, 38, 39, 40, 41, 0, 42, 43, 44,
+	45, 46, 47, 48, 50, 49, 52, 0, 0, 51,
+	35, 36, 98, 0, 37, 38, 39, 40, 41, 0,
+	42, 43, 44, 45, 46, 47, 48, 50, 49, 52,
+	157, 158, 51, 35, 36, 0, 0, 37, 38, 39,
+	40, 41, 0, 42, 43, 44, 45, 46, 47, 48,
+	50, 49, 52, 35, 36, 51, 114, 37, 38, 39,
+	40, 41, 0, 42, 43, 44, 45, 46, 47, 48,
+	50, 49, 52, 33, 34, 51, 35, 36, 0, 0,
+	37, 38, 39, 40, 41, 0, 42, 43, 44, 45,
+	46, 47, 48, 50, 49, 52, 166, 0, 51, 35,
+	36, 0, 0, 37, 38, 39, 40, 41, 0, 42,
+	43, 44, 45, 46, 47, 48, 50, 49, 52, 35,
+	36, 51, 0, 37, 38, 0, 40, 41, 0, 42,
+	43, 44, 45, 46, 47, 48, 50, 49, 0, 0,
+	0, 51,
 }
 
-var yyPact = [...]int{
-	45, -1000, -1000, -1000, 208, -1000, -1000, -1000, -1000, -31,
-	-1000, -1000, -1000, 127, -32, 127, 127, -1000, -1000, -34,
-	107, -3, -1000, -1000, 127, 127, 127, 127, 127, 127,
-	127, 127, 127, 127, 127, 127, 127, 127, 127, 87,
-	171, 127, -16, -16, 0, -21, -1000, 228, -1000, -23,
-	7, 6, 130, 245, 46, 46, -16, -16, -16, 39,
-	39, 39, 39, 39, 39, 191, 228, -20, -1000, -1000,
-	151, -22, -1000, -1000, -1000, 127, -1000, 54, 127, 127,
-	-1000, -1000, -1000, -1000, 5, 228, -13, -14, 228, 228,
-	-1000, 127, 127, 228, 228,
+var yyPact = [...]int16{
+	201, -32768, -32768, -32768, 554, 32, -32768, -32768, -32768, -32768,
+	21, -32768, -32768, -32768, 346, -32768, 346, 19, 346, 346,
+	-32768, -32768, 17, -32768, 15, -8, 71, 52, 307, 8,
+	-10, 70, 70, -32768, -32768, 346, 346, 346, 346, 346,
+	346, 346, 346, 346, 346, 346, 346, 346, 346, 346,
+	346, 346, 346, -32768, -32768, 286, 310, 488, 346, 24,
+	24, 13, 346, 95, -21, 346, -32768, -16, -32768, 600,
+	-32768, -18, 33, 28, 346, -3, -32768, -3, 310, 349,
+	620, 620, 620, 69, 69, 24, 24, 24, 114, 114,
+	114, 114, 114, 114, 534, 600, -12, -32768, -32768, 465,
+	-14, -32768, 442, -45, 12, 419, -32768, 346, -32768, 91,
+	346, 346, 396, 68, -32768, -32768, -32768, -32768, -34, 346,
+	-15, -34, -32768, -32768, 600, -9, -13, 600, 600, -34,
+	-32768, -32768, 152, 373, -34, 73, -32768, 346, 346, -40,
+	-32768, 76, -32768, -32768, 511, -32768, 247, 37, 35, -34,
+	-32768, -32768, 600, 600, -38, -32768, -32768, -32768, -32768, 577,
+	-32768, -32768, -32768, -32768, -32768, -32768, -32768,
 }
 
-var yyPgo = [...]int{
-	0, 49, 11, 48, 47, 0, 46, 45,
+var yyPgo = [...]uint8{
+	0, 134, 5, 127, 34, 118, 116, 4, 0, 109,
+	106, 113, 3, 2, 77, 79,
 }
 
-var yyR1 = [...]int{
-	0, 6, 6, 6, 6, 5, 5, 5, 5, 5,
-	5, 5, 5, 5, 5, 5, 5, 5, 5, 5,
-	5, 5, 5, 5, 5, 5, 5, 5, 5, 5,
-	5, 5, 5, 5, 5, 5, 5, 5, 7, 7,
-	1, 1, 1, 1, 3, 3, 2, 2, 4, 4,
+var yyR1 = [...]int8{
+	0, 10, 10, 10, 10, 10, 10, 11, 11, 11,
+	11, 11, 12, 12, 12, 13, 13, 6, 6, 7,
+	7, 7, 7, 7, 7, 7, 7, 8, 8, 8,
+	8, 8, 8, 8, 8, 8, 8, 8, 8, 8,
+	8, 8, 8, 8, 8, 8, 8, 8, 8, 8,
+	8, 8, 8, 8, 8, 8, 8, 8, 8, 8,
+	8, 8, 8, 8, 8, 14, 14, 9, 9, 15,
+	15, 1, 1, 1, 1, 3, 3, 2, 2, 4,
+	4, 5, 5,
 }
 
-var yyR2 = [...]int{
-	0, 1, 1, 2, 2, 1, 1, 1, 1, 1,
-	1, 1, 1, 3, 3, 3, 4, 4, 3, 3,
-	3, 3, 3, 3, 2, 2, 3, 3, 3, 3,
-	3, 3, 1, 1, 4, 3, 4, 3, 2, 3,
-	3, 3, 5, 5, 3, 2, 1, 3, 1, 3,
+var yyR2 = [...]int8{
+	0, 1, 1, 2, 2, 2, 2, 1, 5, 7,
+	6, 5, 5, 7, 7, 2, 3, 1, 2, 1,
+	2, 2, 1, 3, 2, 2, 2, 1, 1, 1,
+	1, 1, 1, 1, 1, 3, 3, 3, 3, 3,
+	2, 1, 3, 4, 4, 3, 3, 3, 3, 3,
+	3, 2, 2, 3, 3, 3, 3, 3, 3, 1,
+	1, 4, 3, 4, 3, 4, 2, 2, 2, 2,
+	3, 3, 3, 5, 5, 3, 2, 1, 3, 1,
+	3, 1, 3,
 }
 
-var yyChk = [...]int{
-	-1000, -6, 36, 37, -5, 13, 16, 14, 15, 10,
-	9, 11, 12, 40, 8, 24, 23, -3, -7, 7,
-	38, 42, 36, 37, 19, 20, 23, 24, 26, 27,
-	28, 29, 30, 31, 32, 34, 33, 38, 35, 40,
-	-5, 40, -5, -5, 40, -2, 39, -5, 43, -1,
-	10, 16, -5, -5, -5, -5, -5, -5, -5, -5,
-	-5, -5, -5, -5, -5, -5, -5, -2, 41, 41,
-	-5, -4, 41, 10, 39, 25, 43, 25, 37, 37,
-	39, 41, 41, 41, 25, -5, 10, 16, -5, -5,
-	10, 37, 37, -5, -5,
+var yyChk = [...]int16{
+	-32768, -10, 39, 40, -8, -11, 13, 16, 14, 15,
+	10, 9, 11, 12, 21, -14, 43, 8, 27, 26,
+	-3, -15, 7, -12, 49, 50, 52, -9, 41, 45,
+	47, 17, 18, 39, 40, 19, 20, 23, 24, 25,
+	26, 27, 29, 30, 31, 32, 33, 34, 35, 37,
+	36, 41, 38, 39, 40, 43, -8, -8, 43, -8,
+	-8, 43, 43, 43, 10, 43, -14, -2, 42, -8,
+	46, -1, 10, 16, 43, -4, 10, -4, -8, -8,
+	-8, -8, -8, -8, -8, -8, -8, -8, -8, -8,
+	-8, -8, -8, -8, -8, -8, -2, 44, 44, -8,
+	-4, 44, -8, 9, 43, -8, 42, 28, 46, 28,
+	40, 40, -8, 28, 42, 44, 44, 44, 44, 51,
+	-5, 44, 9, 44, -8, 10, 16, -8, -8, 44,
+	10, -13, 45, -8, 44, 28, -13, 40, 40, -13,
+	46, -6, -7, 39, -8, -11, 53, 54, 55, 44,
+	-13, 9, -8, -8, 48, 46, -7, 39, 40, -8,
+	39, 39, 39, -13, -13, -12, 39,
 }
 
-var yyDef = [...]int{
-	0, -2, 1, 2, 0, 5, 6, 7, 8, 9,
-	10, 11, 12, 0, 0, 0, 0, 32, 33, 0,
-	0, 0, 3, 4, 0, 0, 0, 0, 0, 0,
+var yyDef = [...]int8{
+	0, -2, 1, 2, 0, 0, 27, 28, 29, 30,
+	31, 32, 33, 34, 0, 41, 0, 0, 0, 0,
+	59, 60, 0, 7, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 3, 4, 0, 0, 0, 0, 0,
 	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 0, 24, 25, 0, 0, 45, 46, 38, 0,
-	0, 0, 13, 14, 19, 20, 21, 22, 23, 26,
-	27, 28, 29, 30, 31, 0, 35, 0, 18, 15,
-	0, 0, 37, 48, 44, 0, 39, 0, 0, 0,
-	34, 16, 17, 36, 0, 47, 0, 0, 40, 41,
-	49, 0, 0, 42, 43,
+	0, 0, 0, 5, 6, 0, 40, 0, 0, 51,
+	52, 0, 0, 0, 0, 0, 66, 0, 76, 77,
+	69, 0, 0, 0, 0, 67, 79, 68, 35, 36,
+	37, 38, 39, 46, 47, 48, 49, 50, 53, 54,
+	55, 56, 57, 58, 0, 62, 0, 45, 42, 0,
+	0, 64, 0, 0, 0, 0, 75, 0, 70, 0,
+	0, 0, 0, 0, 61, 43, 44, 63, 0, 0,
+	0, 0, 81, 65, 78, 0, 0, 71, 72, 0,
+	80, 8, 0, 0, 0, 0, 11, 0, 0, 12,
+	15, 0, 17, 19, 0, 22, 0, 0, 0, 0,
+	10, 82, 73, 74, 0, 16, 18, 20, 21, 0,
+	24, 25, 26, 9, 13, 14, 23,
 }
 
-var yyTok1 = [...]int{
+var yyTok1 = [...]int8{
 	1,
 }
 
-var yyTok2 = [...]int{
+var yyTok2 = [...]int8{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
 	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
-	42, 43, 44, 45,
+	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
+	52, 53, 54, 55, 56, 57, 58,
 }
 
-var yyTok3 = [...]int{
+var yyTok3 = [...]int8{
 	0,
 }
 
@@ -275,7 +370,7 @@ func yyNewParser() yyParser {
 	return &yyParserImpl{}
 }
 
-const yyFlag = -1000
+const yyFlag = -32768
 
 func yyTokname(c int) string {
 	if c >= 1 && c-1 < len(yyToknames) {
@@ -314,9 +409,9 @@ func yyErrorMessage(state, lookAhead int) string {
 	expected := make([]int, 0, 4)
 
 	// Look for shiftable tokens.
-	base := yyPact[state]
+	base := int(yyPact[state])
 	for tok := TOKSTART; tok-1 < len(yyToknames); tok++ {
-		if n := base + tok; n >= 0 && n < yyLast && yyChk[yyAct[n]] == tok {
+		if n := base + tok; n >= 0 && n < yyLast && int(yyChk[int(yyAct[n])]) == tok {
 			if len(expected) == cap(expected) {
 				return res
 			}
@@ -326,13 +421,13 @@ func yyErrorMessage(state, lookAhead int) string {
 
 	if yyDef[state] == -2 {
 		i := 0
-		for yyExca[i] != -1 || yyExca[i+1] != state {
+		for yyExca[i] != -1 || int(yyExca[i+1]) != state {
 			i += 2
 		}
 
 		// Look for tokens that we accept or reduce.
 		for i += 2; yyExca[i] >= 0; i += 2 {
-			tok := yyExca[i]
+			tok := int(yyExca[i])
 			if tok < TOKSTART || yyExca[i+1] == 0 {
 				continue
 			}
@@ -363,30 +458,30 @@ func yylex1(lex yyLexer, lval *yySymType) (char, token int) {
 	token = 0
 	char = lex.Lex(lval)
 	if char <= 0 {
-		token = yyTok1[0]
+		token = int(yyTok1[0])
 		goto out
 	}
 	if char < len(yyTok1) {
-		token = yyTok1[char]
+		token = int(yyTok1[char])
 		goto out
 	}
 	if char >= yyPrivate {
 		if char < yyPrivate+len(yyTok2) {
-			token = yyTok2[char-yyPrivate]
+			token = int(yyTok2[char-yyPrivate])
 			goto out
 		}
 	}
 	for i := 0; i < len(yyTok3); i += 2 {
-		token = yyTok3[i+0]
+		token = int(yyTok3[i+0])
 		if token == char {
-			token = yyTok3[i+1]
+			token = int(yyTok3[i+1])
 			goto out
 		}
 	}
 
 out:
 	if token == 0 {
-		token = yyTok2[1] /* unknown char */
+		token = int(yyTok2[1]) /* unknown char */
 	}
 	if yyDebug >= 3 {
 		__yyfmt__.Printf("lex %s(%d)\n", yyTokname(token), uint(char))
@@ -441,7 +536,7 @@ yystack:
 	yyS[yyp].yys = yystate
 
 yynewstate:
-	yyn = yyPact[yystate]
+	yyn = int(yyPact[yystate])
 	if yyn <= yyFlag {
 		goto yydefault /* simple state */
 	}
@@ -452,8 +547,8 @@ yynewstate:
 	if yyn < 0 || yyn >= yyLast {
 		goto yydefault
 	}
-	yyn = yyAct[yyn]
-	if yyChk[yyn] == yytoken { /* valid shift */
+	yyn = int(yyAct[yyn])
+	if int(yyChk[yyn]) == yytoken { /* valid shift */
 		yyrcvr.char = -1
 		yytoken = -1
 		yyVAL = yyrcvr.lval
@@ -466,7 +561,7 @@ yynewstate:
 
 yydefault:
 	/* default state action */
-	yyn = yyDef[yystate]
+	yyn = int(yyDef[yystate])
 	if yyn == -2 {
 		if yyrcvr.char < 0 {
 			yyrcvr.char, yytoken = yylex1(yylex, &yyrcvr.lval)
@@ -475,18 +570,18 @@ yydefault:
 		/* look through exception table */
 		xi := 0
 		for {
-			if yyExca[xi+0] == -1 && yyExca[xi+1] == yystate {
+			if yyExca[xi+0] == -1 && int(yyExca[xi+1]) == yystate {
 				break
 			}
 			xi += 2
 		}
 		for xi += 2; ; xi += 2 {
-			yyn = yyExca[xi+0]
+			yyn = int(yyExca[xi+0])
 			if yyn < 0 || yyn == yytoken {
 				break
 			}
 		}
-		yyn = yyExca[xi+1]
+		yyn = int(yyExca[xi+1])
 		if yyn < 0 {
 			goto ret0
 		}
@@ -508,10 +603,10 @@ yydefault:
 
 			/* find a state where "error" is a legal shift action */
 			for yyp >= 0 {
-				yyn = yyPact[yyS[yyp].yys] + yyErrCode
+				yyn = int(yyPact[yyS[yyp].yys]) + yyErrCode
 				if yyn >= 0 && yyn < yyLast {
-					yystate = yyAct[yyn] /* simulate a shift of "error" */
-					if yyChk[yystate] == yyErrCode {
+					yystate = int(yyAct[yyn]) /* simulate a shift of "error" */
+					if int(yyChk[yystate]) == yyErrCode {
 						goto yystack
 					}
 				}
@@ -547,7 +642,7 @@ yydefault:
 	yypt := yyp
 	_ = yypt // guard against "declared and not used"
 
-	yyp -= yyR2[yyn]
+	yyp -= int(yyR2[yyn])
 	// yyp is now the index of $0. Perform the default action. Iff the
 	// reduced production is ε, $1 is possibly out of range.
 	if yyp+1 >= len(yyS) {
@@ -558,16 +653,16 @@ yydefault:
 	yyVAL = yyS[yyp+1]
 
 	/* consult goto table to find next state */
-	yyn = yyR1[yyn]
-	yyg := yyPgo[yyn]
+	yyn = int(yyR1[yyn])
+	yyg := int(yyPgo[yyn])
 	yyj := yyg + yyS[yyp].yys + 1
 
 	if yyj >= yyLast {
-		yystate = yyAct[yyg]
+		yystate = int(yyAct[yyg])
 	} else {
-		yystate = yyAct[yyj]
-		if yyChk[yystate] != -yyn {
-			yystate = yyAct[yyg]
+		yystate = int(yyAct[yyj])
+		if int(yyChk[yystate]) != -yyn {
+			yystate = int(yyAct[yyg])
 		}
 	}
 	// dummy call; replaced with literal code
@@ -575,338 +670,547 @@ yydefault:
 
 	case 1:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:41
+//line parser.y:46
 		{
 			yylex.(*pLexer).push(newPNode("", eolq, 0, yyDollar[1].node.pos))
 		}
 	case 2:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:42
+//line parser.y:47
 		{
 			yylex.(*pLexer).push(newPNode("", eolq, 0, yyDollar[1].node.pos))
 		}
 	case 3:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:43
+//line parser.y:48
 		{
 			yylex.(*pLexer).push(newPNode("", eol, 0, yyDollar[2].node.pos))
 		}
 	case 4:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:44
+//line parser.y:49
 		{
 			yylex.(*pLexer).push(newPNode("", eolq, 1, yyDollar[2].node.pos))
 		}
 	case 5:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:50
+		{
+		}
+	case 6:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:51
+		{
+		}
+	case 8:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.y:57
+		{
+			yylex.(*pLexer).trace("while")
+			yylex.(*pLexer).push(newPNode("while", k_while, 2, yyDollar[1].node.pos))
+		}
+	case 9:
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line parser.y:61
+		{
+			yylex.(*pLexer).trace("for")
+			yylex.(*pLexer).push(yyDollar[3].node)
+			yylex.(*pLexer).push(newPNode("for", k_for, 3, yyDollar[1].node.pos))
+		}
+	case 10:
+		yyDollar = yyS[yypt-6 : yypt+1]
+//line parser.y:66
+		{
+			yylex.(*pLexer).trace("def " + yyDollar[2].node.str)
+			yylex.(*pLexer).push(newPNode(yyDollar[2].node.str, k_def, yyDollar[4].num, yyDollar[1].node.pos))
+		}
+	case 11:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.y:70
+		{
+			yylex.(*pLexer).trace("def " + yyDollar[2].node.str)
+			yylex.(*pLexer).push(newPNode(yyDollar[2].node.str, k_def, 0, yyDollar[1].node.pos))
+		}
+	case 12:
+		yyDollar = yyS[yypt-5 : yypt+1]
+//line parser.y:77
+		{
+			yylex.(*pLexer).trace("if")
+			yylex.(*pLexer).push(newPNode("if", k_if, 2, yyDollar[1].node.pos))
+		}
+	case 13:
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line parser.y:81
+		{
+			yylex.(*pLexer).trace("if-else")
+			yylex.(*pLexer).push(newPNode("if", k_if, 3, yyDollar[1].node.pos))
+		}
+	case 14:
+		yyDollar = yyS[yypt-7 : yypt+1]
+//line parser.y:85
+		{
+			yylex.(*pLexer).trace("if-elseif")
+			yylex.(*pLexer).push(newPNode("if", k_if, 3, yyDollar[1].node.pos))
+		}
+	case 15:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:92
+		{
+			yylex.(*pLexer).push(newPNode("{}", block, 0, yyDollar[1].node.pos))
+		}
+	case 16:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:93
+		{
+			yylex.(*pLexer).push(newPNode("{}", block, yyDollar[2].num, yyDollar[1].node.pos))
+		}
+	case 17:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:48
+//line parser.y:97
+		{
+			yyVAL.num = yyDollar[1].num
+		}
+	case 18:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:98
+		{
+			yyVAL.num = yyDollar[1].num + yyDollar[2].num
+		}
+	case 19:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:102
+		{
+			yyVAL.num = 0
+		}
+	case 20:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:103
+		{
+			yyVAL.num = 1
+		}
+	case 21:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:104
+		{
+			yyVAL.num = 1
+		}
+	case 22:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:105
+		{
+			yyVAL.num = 1
+		}
+	case 23:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:106
+		{
+			yyVAL.num = 1
+			yylex.(*pLexer).push(newPNode("return", k_return, 1, yyDollar[1].node.pos))
+		}
+	case 24:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:107
+		{
+			yyVAL.num = 1
+			yylex.(*pLexer).push(newPNode("return", k_return, 0, yyDollar[1].node.pos))
+		}
+	case 25:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:108
+		{
+			yyVAL.num = 1
+			yylex.(*pLexer).push(newPNode("break", k_break, 0, yyDollar[1].node.pos))
+		}
+	case 26:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:109
+		{
+			yyVAL.num = 1
+			yylex.(*pLexer).push(newPNode("continue", k_continue, 0, yyDollar[1].node.pos))
+		}
+	case 27:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:113
 		{
 			yylex.(*pLexer).trace("int:" + yyDollar[1].node.str)
 			yylex.(*pLexer).push(yyDollar[1].node)
 		}
-	case 6:
+	case 28:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:49
+//line parser.y:114
 		{
 			yylex.(*pLexer).trace("string")
 			yylex.(*pLexer).push(yyDollar[1].node)
 		}
-	case 7:
+	case 29:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:50
+//line parser.y:115
 		{
 			yylex.(*pLexer).trace("true")
 			yylex.(*pLexer).push(yyDollar[1].node)
 		}
-	case 8:
+	case 30:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:51
+//line parser.y:116
 		{
 			yylex.(*pLexer).trace("false")
 			yylex.(*pLexer).push(yyDollar[1].node)
 		}
-	case 9:
+	case 31:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:52
+//line parser.y:117
 		{
 			yylex.(*pLexer).trace("ident: " + yyDollar[1].node.str)
 			yylex.(*pLexer).push(yyDollar[1].node)
 		}
-	case 10:
+	case 32:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:53
+//line parser.y:118
 		{
 			yylex.(*pLexer).trace("name: " + yyDollar[1].node.str)
 			yylex.(*pLexer).push(yyDollar[1].node)
 		}
-	case 11:
+	case 33:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:54
+//line parser.y:119
 		{
 			yylex.(*pLexer).trace("vardol: " + yyDollar[1].node.str)
 			yylex.(*pLexer).push(yyDollar[1].node)
 		}
-	case 12:
+	case 34:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:55
+//line parser.y:120
 		{
 			yylex.(*pLexer).trace("varhist: " + yyDollar[1].node.str)
 			yylex.(*pLexer).push(yyDollar[1].node)
 		}
-	case 13:
+	case 35:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:56
+//line parser.y:121
 		{
 			yylex.(*pLexer).trace("and")
 			yylex.(*pLexer).push(yyDollar[2].node)
 		}
-	case 14:
+	case 36:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:57
+//line parser.y:122
 		{
 			yylex.(*pLexer).trace("or")
 			yylex.(*pLexer).push(yyDollar[2].node)
 		}
-	case 15:
+	case 37:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:123
+		{
+			yylex.(*pLexer).trace("==>")
+			yylex.(*pLexer).push(yyDollar[2].node)
+		}
+	case 38:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:124
+		{
+			yylex.(*pLexer).trace("<==")
+			yylex.(*pLexer).push(yyDollar[2].node)
+		}
+	case 39:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:58
+//line parser.y:125
+		{
+			yylex.(*pLexer).trace("<==>")
+			yylex.(*pLexer).push(yyDollar[2].node)
+		}
+	case 40:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:126
+		{
+			yylex.(*pLexer).trace("!")
+			yylex.(*pLexer).push(yyDollar[1].node)
+		}
+	case 41:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:127
+		{
+		}
+	case 42:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:128
 		{
 			yyVAL.node = yyDollar[2].node
 		}
-	case 16:
+	case 43:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:59
+//line parser.y:129
 		{
 			yylex.(*pLexer).trace("call")
 			yylex.(*pLexer).push(newPNode(yyDollar[1].node.str, call, yyDollar[3].num, yyDollar[1].node.pos))
 		}
-	case 17:
+	case 44:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:60
+//line parser.y:130
 		{
 			yylex.(*pLexer).trace("time")
 			yylex.(*pLexer).push(newPNode(yyDollar[1].node.str, f_time, f_time, yyDollar[1].node.pos))
 		}
-	case 18:
+	case 45:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:61
+//line parser.y:131
 		{
 			yylex.(*pLexer).trace("call")
 			yylex.(*pLexer).push(newPNode(yyDollar[1].node.str, call, 0, yyDollar[1].node.pos))
 		}
-	case 19:
+	case 46:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:62
+//line parser.y:132
 		{
 			yylex.(*pLexer).trace("+")
 			yylex.(*pLexer).push(yyDollar[2].node)
 		}
-	case 20:
+	case 47:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:63
+//line parser.y:133
 		{
 			yylex.(*pLexer).trace("-")
 			yylex.(*pLexer).push(yyDollar[2].node)
 		}
-	case 21:
+	case 48:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:64
+//line parser.y:134
 		{
 			yylex.(*pLexer).trace("*")
 			yylex.(*pLexer).push(yyDollar[2].node)
 		}
-	case 22:
+	case 49:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:65
+//line parser.y:135
 		{
 			yylex.(*pLexer).trace("/")
 			yylex.(*pLexer).push(yyDollar[2].node)
 		}
-	case 23:
+	case 50:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:66
+//line parser.y:136
 		{
 			yylex.(*pLexer).trace("^")
 			yylex.(*pLexer).push(yyDollar[2].node)
 		}
-	case 24:
+	case 51:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:67
+//line parser.y:137
 		{
 			yylex.(*pLexer).trace("-")
 			yylex.(*pLexer).push(newPNode("-.", unaryminus, 0, yyDollar[1].node.pos))
 		}
-	case 25:
+	case 52:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:68
+//line parser.y:138
 		{
 			yylex.(*pLexer).trace("+.")
 		}
-	case 26:
+	case 53:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:69
+//line parser.y:139
 		{
 			yylex.(*pLexer).trace("<")
 			yylex.(*pLexer).push(yyDollar[2].node)
 		}
-	case 27:
+	case 54:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:70
+//line parser.y:140
 		{
 			yylex.(*pLexer).trace(">")
 			yylex.(*pLexer).push(yyDollar[2].node)
 		}
-	case 28:
+	case 55:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:71
+//line parser.y:141
 		{
 			yylex.(*pLexer).trace("<=")
 			yylex.(*pLexer).push(yyDollar[2].node)
 		}
-	case 29:
+	case 56:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:72
+//line parser.y:142
 		{
 			yylex.(*pLexer).trace(">=")
 			yylex.(*pLexer).push(yyDollar[2].node)
 		}
-	case 30:
+	case 57:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:73
+//line parser.y:143
 		{
 			yylex.(*pLexer).trace("==")
 			yylex.(*pLexer).push(yyDollar[2].node)
 		}
-	case 31:
+	case 58:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:74
+//line parser.y:144
 		{
 			yylex.(*pLexer).trace("!=")
 			yylex.(*pLexer).push(yyDollar[2].node)
 		}
-	case 32:
+	case 59:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:75
+//line parser.y:145
 		{
 		}
-	case 33:
+	case 60:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:76
+//line parser.y:146
 		{
 			yylex.(*pLexer).trace("dict")
 		}
-	case 34:
+	case 61:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:77
+//line parser.y:147
 		{
 			yylex.(*pLexer).trace("[]")
 			yylex.(*pLexer).push(newPNode("[]", lb, 0, yyDollar[1].node.pos))
 		}
-	case 35:
+	case 62:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:78
+//line parser.y:148
 		{
 			yylex.(*pLexer).trace("=")
 			yylex.(*pLexer).push(newPNode("=", assign, 0, yyDollar[1].node.pos))
 		}
-	case 36:
+	case 63:
 		yyDollar = yyS[yypt-4 : yypt+1]
-//line parser.y:79
+//line parser.y:149
 		{
 			yylex.(*pLexer).push(newPNode(yyDollar[1].node.str, initvar, yyDollar[3].num, yyDollar[1].node.pos))
 		}
-	case 37:
+	case 64:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:80
+//line parser.y:150
 		{
 			yylex.(*pLexer).push(newPNode(yyDollar[1].node.str, initvar, 0, yyDollar[1].node.pos))
 		}
-	case 38:
+	case 65:
+		yyDollar = yyS[yypt-4 : yypt+1]
+//line parser.y:155
+		{
+			yylex.(*pLexer).push(newPNode(yyDollar[1].node.str, call, 2, yyDollar[1].node.pos))
+		}
+	case 66:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:85
+//line parser.y:156
+		{
+			yylex.(*pLexer).push(newPNode(yyDollar[1].node.str, call, 2, yyDollar[1].node.pos))
+		}
+	case 67:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:160
+		{
+			yyVAL.node = newPNode("all", all, 0, yyDollar[1].node.pos)
+			yylex.(*pLexer).push(newPNode("_list", list, yyDollar[2].num, yyDollar[1].node.pos))
+		}
+	case 68:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:161
+		{
+			yyVAL.node = newPNode("ex", ex, 0, yyDollar[1].node.pos)
+			yylex.(*pLexer).push(newPNode("_list", list, yyDollar[2].num, yyDollar[1].node.pos))
+		}
+	case 69:
+		yyDollar = yyS[yypt-2 : yypt+1]
+//line parser.y:166
 		{
 			yylex.(*pLexer).trace("dict0")
 			yylex.(*pLexer).push(newPNode("_dict", dict, 0, yyDollar[1].node.pos))
 		}
-	case 39:
+	case 70:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:89
+//line parser.y:170
 		{
 			yylex.(*pLexer).trace(fmt.Sprintf("dictn%d", yyDollar[2].num))
 			yylex.(*pLexer).push(newPNode("_dict", dict, yyDollar[2].num, yyDollar[1].node.pos))
 		}
-	case 40:
+	case 71:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:96
+//line parser.y:177
 		{
 			yylex.(*pLexer).trace("seqdi1:" + yyDollar[1].node.str)
 			yyVAL.num = 1
 			yylex.(*pLexer).push(yyDollar[1].node)
 		}
-	case 41:
+	case 72:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:101
+//line parser.y:182
 		{
 			yylex.(*pLexer).trace("seqds1:" + yyDollar[1].node.str)
 			yyVAL.num = 1
 			yylex.(*pLexer).push(yyDollar[1].node)
 		}
-	case 42:
+	case 73:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:106
+//line parser.y:187
 		{
 			yylex.(*pLexer).trace("seqdin")
 			yyVAL.num = yyDollar[1].num + 1
 			yylex.(*pLexer).push(yyDollar[3].node)
 		}
-	case 43:
+	case 74:
 		yyDollar = yyS[yypt-5 : yypt+1]
-//line parser.y:111
+//line parser.y:192
 		{
 			yylex.(*pLexer).trace("seqdsn")
 			yyVAL.num = yyDollar[1].num + 1
 			yylex.(*pLexer).push(yyDollar[3].node)
 		}
-	case 44:
+	case 75:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:119
+//line parser.y:200
 		{
 			yylex.(*pLexer).trace(fmt.Sprintf("list%d", (yyDollar[2].num)))
 			yylex.(*pLexer).push(newPNode("_list", list, yyDollar[2].num, yyDollar[1].node.pos))
 		}
-	case 45:
+	case 76:
 		yyDollar = yyS[yypt-2 : yypt+1]
-//line parser.y:120
+//line parser.y:201
 		{
 			yylex.(*pLexer).trace("list0")
 			yylex.(*pLexer).push(newPNode("_list", list, 0, yyDollar[1].node.pos))
 		}
-	case 46:
+	case 77:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:124
+//line parser.y:205
 		{
 			yyVAL.num = 1
 		}
-	case 47:
+	case 78:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:125
+//line parser.y:206
 		{
 			yyVAL.num = yyDollar[1].num + 1
 		}
-	case 48:
+	case 79:
 		yyDollar = yyS[yypt-1 : yypt+1]
-//line parser.y:129
+//line parser.y:210
 		{
 			yyVAL.num = 1
 			yylex.(*pLexer).push(newPNode(yyDollar[1].node.str, ident, 0, yyDollar[1].node.pos))
 		}
-	case 49:
+	case 80:
 		yyDollar = yyS[yypt-3 : yypt+1]
-//line parser.y:130
+//line parser.y:211
 		{
 			yyVAL.num = yyDollar[1].num + 1
 			yylex.(*pLexer).push(newPNode(yyDollar[3].node.str, ident, 0, yyDollar[3].node.pos))
 		}
+	case 81:
+		yyDollar = yyS[yypt-1 : yypt+1]
+//line parser.y:215
+		{
+			yyVAL.num = 1
+			yylex.(*pLexer).push(yyDollar[1].node)
+		}
+	case 82:
+		yyDollar = yyS[yypt-3 : yypt+1]
+//line parser.y:216
+		{
+			yyVAL.num = yyDollar[1].num + 1
+			yylex.(*pLexer).push(yyDollar[3].node)
+		}
 	}
 	goto yystack /* stack new state and value */
 }
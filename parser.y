@@ -13,21 +13,26 @@ import (
 
 %token call list dict initvar f_time
 %token name ident vardol varhist number f_true f_false t_str
-%token all ex and or not abs
+%token all ex and or not abs impl repl equiv
 %token plus minus comma mult div pow
 %token ltop gtop leop geop neop eqop assign
 %token eol eolq lb rb lp rp lc rc
+%token k_if k_else k_while k_for k_in k_def k_return k_break k_continue block
 
-%type <num> seq_dict_arg seq_mobj list_mobj seq_ident
+%type <num> seq_dict_arg seq_mobj list_mobj seq_ident seq_name seq_stmt stmt
 %type <node> f_true f_false eol eolq
 %type <node> mobj lb initvar f_time
+%type <node> k_if k_while k_for k_def k_return k_break k_continue
 %type <node> vardol varhist number name ident t_str
-%type <node> plus minus mult div pow and or
+%type <node> plus minus mult div pow and or not impl repl equiv all ex qvars
 %type <node> ltop gtop leop geop neop eqop assign lb lp lc
 
 %right assign
+%left equiv
+%right impl repl
 %left or
 %left and
+%right not
 %left ltop gtop leop geop neop eqop
 %left plus minus
 %left mult div
@@ -42,6 +47,66 @@ expr
 	| eolq                         { yylex.(*pLexer).push(newPNode("", eolq, 0, $1.pos))}
 	| mobj eol                     { yylex.(*pLexer).push(newPNode("", eol, 0, $2.pos)) }
 	| mobj eolq                    { yylex.(*pLexer).push(newPNode("", eolq, 1, $2.pos)) }
+	| ctrl eol                     {}
+	| ctrl eolq                    {}
+	;
+
+// 制御構文. 本体はかならず {} で囲む
+ctrl
+	: if_stmt
+	| k_while lp mobj rp stmt_block {
+			yylex.(*pLexer).trace("while");
+			yylex.(*pLexer).push(newPNode("while", k_while, 2, $1.pos))
+		}
+	| k_for lp name k_in mobj rp stmt_block {
+			yylex.(*pLexer).trace("for");
+			yylex.(*pLexer).push($3)
+			yylex.(*pLexer).push(newPNode("for", k_for, 3, $1.pos))
+		}
+	| k_def ident lp seq_name rp stmt_block {
+			yylex.(*pLexer).trace("def " + $2.str);
+			yylex.(*pLexer).push(newPNode($2.str, k_def, $4, $1.pos))
+		}
+	| k_def ident lp rp stmt_block {
+			yylex.(*pLexer).trace("def " + $2.str);
+			yylex.(*pLexer).push(newPNode($2.str, k_def, 0, $1.pos))
+		}
+	;
+
+if_stmt
+	: k_if lp mobj rp stmt_block {
+			yylex.(*pLexer).trace("if");
+			yylex.(*pLexer).push(newPNode("if", k_if, 2, $1.pos))
+		}
+	| k_if lp mobj rp stmt_block k_else stmt_block {
+			yylex.(*pLexer).trace("if-else");
+			yylex.(*pLexer).push(newPNode("if", k_if, 3, $1.pos))
+		}
+	| k_if lp mobj rp stmt_block k_else if_stmt {
+			yylex.(*pLexer).trace("if-elseif");
+			yylex.(*pLexer).push(newPNode("if", k_if, 3, $1.pos))
+		}
+	;
+
+stmt_block
+	: lc rc { yylex.(*pLexer).push(newPNode("{}", block, 0, $1.pos)) }
+	| lc seq_stmt rc { yylex.(*pLexer).push(newPNode("{}", block, $2, $1.pos)) }
+	;
+
+seq_stmt
+	: stmt { $$ = $1 }
+	| seq_stmt stmt { $$ = $1 + $2 }
+	;
+
+stmt
+	: eol { $$ = 0 }
+	| mobj eol { $$ = 1 }
+	| mobj eolq { $$ = 1 }
+	| ctrl { $$ = 1 }
+	| k_return mobj eol { $$ = 1; yylex.(*pLexer).push(newPNode("return", k_return, 1, $1.pos)) }
+	| k_return eol { $$ = 1; yylex.(*pLexer).push(newPNode("return", k_return, 0, $1.pos)) }
+	| k_break eol { $$ = 1; yylex.(*pLexer).push(newPNode("break", k_break, 0, $1.pos)) }
+	| k_continue eol { $$ = 1; yylex.(*pLexer).push(newPNode("continue", k_continue, 0, $1.pos)) }
 	;
 
 mobj
@@ -55,6 +120,11 @@ mobj
 	| varhist		{ yylex.(*pLexer).trace("varhist: " + $1.str ); yylex.(*pLexer).push($1) }
 	| mobj and mobj { yylex.(*pLexer).trace("and"); yylex.(*pLexer).push($2)}
 	| mobj or mobj  { yylex.(*pLexer).trace("or");  yylex.(*pLexer).push($2)}
+	| mobj impl mobj  { yylex.(*pLexer).trace("==>");  yylex.(*pLexer).push($2)}
+	| mobj repl mobj  { yylex.(*pLexer).trace("<==");  yylex.(*pLexer).push($2)}
+	| mobj equiv mobj { yylex.(*pLexer).trace("<==>"); yylex.(*pLexer).push($2)}
+	| not mobj { yylex.(*pLexer).trace("!"); yylex.(*pLexer).push($1)}
+	| qmobj {}
 	| lp mobj rp { $$ = $2 }
 	| ident lp seq_mobj rp { yylex.(*pLexer).trace("call"); yylex.(*pLexer).push(newPNode($1.str, call, $3, $1.pos)) }
 	| f_time lp mobj rp { yylex.(*pLexer).trace("time"); yylex.(*pLexer).push(newPNode($1.str, f_time, f_time, $1.pos)) }
@@ -80,6 +150,17 @@ mobj
 	| initvar lp rp           { yylex.(*pLexer).push(newPNode($1.str, initvar,  0, $1.pos)); }
 	;
 
+// 限量子 ∀x,y (...), ∃x ∀y (...)
+qmobj
+	: qvars lp mobj rp { yylex.(*pLexer).push(newPNode($1.str, call, 2, $1.pos)) }
+	| qvars qmobj { yylex.(*pLexer).push(newPNode($1.str, call, 2, $1.pos)) }
+	;
+
+qvars
+	: all seq_ident { $$ = newPNode("all", all, 0, $1.pos); yylex.(*pLexer).push(newPNode("_list", list, $2, $1.pos)) }
+	| ex seq_ident { $$ = newPNode("ex", ex, 0, $1.pos); yylex.(*pLexer).push(newPNode("_list", list, $2, $1.pos)) }
+	;
+
 // 辞書 {a: 1, b: x^2, c: "gao"}
 dict_mobj
 	: lc rc {
@@ -130,5 +211,10 @@ seq_ident
 	| seq_ident comma ident { $$ = $1 + 1; yylex.(*pLexer).push(newPNode($3.str, ident, 0, $3.pos)) }
 	;
 
+seq_name
+	: name	{ $$ = 1; yylex.(*pLexer).push($1) }
+	| seq_name comma name { $$ = $1 + 1; yylex.(*pLexer).push($3) }
+	;
+
 
 %%      /*  start  of  programs  */
@@ -51,6 +51,15 @@ func TestParseValid(t *testing.T) {
 		{"func();", []int{eol, call}},
 		{"help();", []int{eol, call}},
 		{"help(\"all\");", []int{eol, call, t_str}},
+		{"if (A) {};", []int{k_if, block, name}},
+		{"if (A) { 1; } else { B = 2; 3; };", []int{k_if, block, number, assign, number, name, block, number, name}},
+		{"while (true) { break; };", []int{k_while, block, k_break, f_true}},
+		{"for (I in A) { continue; };", []int{k_for, name, block, k_continue, name}},
+		{"def f(X, Y) { return X; };", []int{k_def, block, k_return, name, name, name}},
+		{"def f() { return; };", []int{k_def, block, k_return}},
+		{"!x > 0 ==> y <= 1;", []int{eol, impl, leop, number, ident, not, gtop, number, ident}},
+		{"a < b < c;", []int{eol, ltop, ident, ltop, ident, ident}},
+		{"∃x,y (x > y);", []int{eol, call, gtop, ident, ident, list, ident, ident}},
 	} {
 		stack, err := g.parse(strings.NewReader(s.str))
 		if err != nil {
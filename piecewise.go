@@ -0,0 +1,297 @@
+package ganrac
+
+// 区分的な項. abs(), min(), max() から作られる.
+// 原子論理式を作るときに場合分けした論理式に展開する.
+
+import (
+	"fmt"
+)
+
+// Piecewise は cond[i] が成り立つとき val[i] となる項.
+// cond は互いに排他的. 0 除算の場合を除くなどで全体を覆わないことがあり,
+// どの cond も成り立たない点では定義されない.
+// 場合がひとつもなければ, どこでも定義されない.
+type Piecewise struct {
+	cond []Fof
+	val  []RObj
+}
+
+// newPiecewise は正規化した項を返す.
+// 偽の場合は除き, 同じ値の場合はまとめる.
+// 条件が真の場合しか残らなければ, その値を返す.
+func newPiecewise(conds []Fof, vals []RObj) RObj {
+	z := new(Piecewise)
+	for i, c := range conds {
+		if _, ok := c.(*AtomF); ok {
+			continue
+		}
+		if p, ok := vals[i].(*Piecewise); ok {
+			// 入れ子は展開する
+			for j, d := range p.cond {
+				z.add(NewFmlAnd(c, d), p.val[j])
+			}
+		} else {
+			z.add(c, vals[i])
+		}
+	}
+	if len(z.cond) == 1 {
+		if _, ok := z.cond[0].(*AtomT); ok {
+			return z.val[0]
+		}
+	}
+	return z
+}
+
+func (z *Piecewise) add(c Fof, v RObj) {
+	c = c.simplBasic(trueObj, falseObj)
+	if _, ok := c.(*AtomF); ok {
+		return
+	}
+	for i, u := range z.val {
+		if Sub(u, v).IsZero() {
+			z.cond[i] = NewFmlOr(z.cond[i], c).simplBasic(trueObj, falseObj)
+			return
+		}
+	}
+	z.cond = append(z.cond, c)
+	z.val = append(z.val, v)
+}
+
+// NewAbs returns |x|.
+func NewAbs(x RObj) RObj {
+	return newPiecewise(
+		[]Fof{NewAtom(x, GE), NewAtom(x, LT)},
+		[]RObj{x, x.Neg()})
+}
+
+// NewMax returns max(x, y).
+func NewMax(x, y RObj) RObj {
+	d := Sub(x, y)
+	return newPiecewise(
+		[]Fof{NewAtom(d, GE), NewAtom(d, LT)},
+		[]RObj{x, y})
+}
+
+// NewMin returns min(x, y).
+func NewMin(x, y RObj) RObj {
+	d := Sub(x, y)
+	return newPiecewise(
+		[]Fof{NewAtom(d, LE), NewAtom(d, GT)},
+		[]RObj{x, y})
+}
+
+// mapVal は各場合の値に f を適用する
+func (z *Piecewise) mapVal(f func(v RObj) RObj) RObj {
+	vals := make([]RObj, len(z.val))
+	for i, v := range z.val {
+		vals[i] = f(v)
+	}
+	return newPiecewise(z.cond, vals)
+}
+
+func (z *Piecewise) Tag() uint {
+	return TAG_PIECEWISE
+}
+
+func (z *Piecewise) String() string {
+	return fmt.Sprintf("%v", z)
+}
+
+func (z *Piecewise) Format(s fmt.State, format rune) {
+	switch format {
+	case FORMAT_DUMP:
+		fmt.Fprintf(s, "(piecewise")
+		for i, c := range z.cond {
+			fmt.Fprintf(s, " (")
+			c.Format(s, format)
+			fmt.Fprintf(s, " ")
+			z.val[i].Format(s, format)
+			fmt.Fprintf(s, ")")
+		}
+		fmt.Fprintf(s, ")")
+	case FORMAT_TEX:
+		fmt.Fprintf(s, "\\begin{cases}")
+		for i, c := range z.cond {
+			if i > 0 {
+				fmt.Fprintf(s, " \\\\ ")
+			}
+			z.val[i].Format(s, format)
+			fmt.Fprintf(s, " & ")
+			c.Format(s, format)
+		}
+		fmt.Fprintf(s, "\\end{cases}")
+	default:
+		fmt.Fprintf(s, "piecewise(")
+		for i, c := range z.cond {
+			if i > 0 {
+				fmt.Fprintf(s, ", ")
+			}
+			fmt.Fprintf(s, "[")
+			c.Format(s, format)
+			fmt.Fprintf(s, ", ")
+			z.val[i].Format(s, format)
+			fmt.Fprintf(s, "]")
+		}
+		fmt.Fprintf(s, ")")
+	}
+}
+
+func (z *Piecewise) Equals(xx interface{}) bool {
+	x, ok := xx.(*Piecewise)
+	if !ok || len(x.cond) != len(z.cond) {
+		return false
+	}
+	for i, c := range z.cond {
+		if !c.Equals(x.cond[i]) || !z.val[i].Equals(x.val[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// binop は z op x を計算する
+func (z *Piecewise) binop(xx RObj, op func(a, b RObj) RObj) RObj {
+	x, ok := xx.(*Piecewise)
+	if !ok {
+		return z.mapVal(func(v RObj) RObj { return op(v, xx) })
+	}
+	conds := make([]Fof, 0, len(z.cond)*len(x.cond))
+	vals := make([]RObj, 0, len(z.cond)*len(x.cond))
+	for i, c := range z.cond {
+		for j, d := range x.cond {
+			conds = append(conds, NewFmlAnd(c, d))
+			vals = append(vals, op(z.val[i], x.val[j]))
+		}
+	}
+	return newPiecewise(conds, vals)
+}
+
+func (z *Piecewise) Add(x RObj) RObj {
+	return z.binop(x, Add)
+}
+
+func (z *Piecewise) Sub(x RObj) RObj {
+	return z.binop(x, Sub)
+}
+
+func (z *Piecewise) Mul(x RObj) RObj {
+	return z.binop(x, Mul)
+}
+
+func (z *Piecewise) Div(x NObj) RObj {
+	return z.mapVal(func(v RObj) RObj { return v.Div(x) })
+}
+
+func (z *Piecewise) Pow(x *Int) RObj {
+	return z.mapVal(func(v RObj) RObj { return v.Pow(x) })
+}
+
+func (z *Piecewise) Subst(x RObj, lv Level) RObj {
+	conds := make([]Fof, len(z.cond))
+	vals := make([]RObj, len(z.val))
+	for i, c := range z.cond {
+		conds[i] = c.Subst(x, lv)
+		vals[i] = z.val[i].Subst(x, lv)
+	}
+	return newPiecewise(conds, vals)
+}
+
+func (z *Piecewise) Neg() RObj {
+	return z.mapVal(func(v RObj) RObj { return v.Neg() })
+}
+
+func (z *Piecewise) Sign() int {
+	return 0
+}
+
+func (z *Piecewise) IsZero() bool {
+	return false
+}
+
+func (z *Piecewise) IsOne() bool {
+	return false
+}
+
+func (z *Piecewise) IsMinusOne() bool {
+	return false
+}
+
+func (z *Piecewise) IsNumeric() bool {
+	return false
+}
+
+func (z *Piecewise) valid() error {
+	if len(z.cond) == 0 {
+		return fmt.Errorf("piecewise: undefined")
+	}
+	if len(z.cond) != len(z.val) {
+		return fmt.Errorf("piecewise: invalid # of cases")
+	}
+	for i, c := range z.cond {
+		if err := c.valid(); err != nil {
+			return err
+		}
+		if err := z.val[i].valid(); err != nil {
+			return err
+		}
+		if _, ok := z.val[i].(*Piecewise); ok {
+			return fmt.Errorf("piecewise: nested")
+		}
+	}
+	return nil
+}
+
+func (z *Piecewise) mul_2exp(m uint) RObj {
+	return z.mapVal(func(v RObj) RObj { return v.mul_2exp(m) })
+}
+
+func (z *Piecewise) toIntv(prec uint) RObj {
+	p := new(Piecewise)
+	p.cond = z.cond
+	p.val = make([]RObj, len(z.val))
+	for i, v := range z.val {
+		p.val[i] = v.toIntv(prec)
+	}
+	return p
+}
+
+func (z *Piecewise) Indets(b []bool) {
+	for i, c := range z.cond {
+		c.Indets(b)
+		if v, ok := z.val[i].(indeter); ok {
+			v.Indets(b)
+		}
+	}
+}
+
+// atom は z op 0 と同値な論理式を返す
+func (z *Piecewise) atom(op OP) Fof {
+	var ret Fof = falseObj
+	for i, c := range z.cond {
+		ret = NewFmlOr(ret, NewFmlAnd(c, NewAtom(z.val[i], op)))
+	}
+	return ret.simplBasic(trueObj, falseObj)
+}
+
+// divRObj は x/y を返す. y は 0 でない.
+func divRObj(x, y RObj) RObj {
+	switch c := y.(type) {
+	case NObj:
+		return x.Div(c)
+	case *Piecewise:
+		// y の値が 0 になる場合は定義されない
+		conds := make([]Fof, 0, len(c.cond))
+		vals := make([]RObj, 0, len(c.val))
+		for i, v := range c.val {
+			if !v.IsZero() {
+				conds = append(conds, c.cond[i])
+				vals = append(vals, divRObj(x, v))
+			}
+		}
+		return newPiecewise(conds, vals)
+	}
+	if p, ok := x.(*Piecewise); ok {
+		return p.mapVal(func(v RObj) RObj { return divRObj(v, y) })
+	}
+	return NewRatFunc(x, y)
+}
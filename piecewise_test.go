@@ -0,0 +1,59 @@
+package ganrac
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPiecewise(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"abs(-3/2);", "3/2"},
+		{"max(1, 2, -3);", "2"},
+		{"min(x, x);", "x"},
+		{"abs(x)-abs(x);", "0"},
+		{"abs(x)^2;", "x^2"},
+		{"abs(x-y) <= e;", "y-x<=0 && e+y-x>=0 || y-x>0 && e-y+x>=0"},
+		{"max(a, b) < c;", "b-a<=0 && c-a>0 || b-a>0 && c-b>0"},
+		{"abs(x) > 0;", "x != 0 || x < 0"},
+		{"min(x, 0) == 0;", "x >= 0"},
+		{"piecewise([x > 0, x+1], [x <= 0, 1]) > 1;", "x > 0"},
+		{"1/abs(x) > y;", "x-x^2*y > 0 && x >= 0 || x < 0 && -x-x^2*y > 0"},
+		{"subst(abs(x) > 1, x, -y);", "y+1 < 0 || y-1 > 0"},
+		// 0 除算の場合を除いても条件は残る
+		{"1/max(x, 0) < 0;", "false"},
+		{"1/max(x, 0) > 0;", "x > 0"},
+		{"1/max(x, 0);", "piecewise([x >= 0, 1/x])"},
+		{"subst(1/max(x, 0), x, 2);", "1/2"},
+	} {
+		u, err := g.Eval(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		v, err := g.Eval(strings.NewReader(s.expect + ";"))
+		if err != nil {
+			t.Errorf("%d: expect=%s: err=%s", i, s.expect, err)
+			continue
+		}
+		if e, ok := u.(equaler); !ok || !e.Equals(v) {
+			t.Errorf("%d: input=%s: expect=%v, actual=%v", i, s.input, v, u)
+		}
+	}
+
+	for i, s := range []string{
+		"abs(x, y);",
+		"max();",
+		"piecewise([x > 0, 1], [x > 1]);",
+		"1/piecewise([x >= 0, 0], [x < 0, 0]);",
+		"subst(piecewise([x > 0, 1], [x < 0, 2]), x, 0);",
+		"subst(1/max(x, 0), x, -1);",
+	} {
+		if _, err := g.Eval(strings.NewReader(s)); err == nil {
+			t.Errorf("%d: input=%s: error is expected", i, s)
+		}
+	}
+}
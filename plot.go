@@ -0,0 +1,321 @@
+package ganrac
+
+// 2 変数の CAD の SVG 出力.
+//
+// x 方向のセクタ上のセクションは, いくつかの x で level 1 の射影因子の積の
+// 実根を数値的に求めて折れ線で描く. セクタ上ではセクションの数が一定なので,
+// 各 x での j 番目の根をつなげばよい.
+// x 方向のセクション上のセルは分離区間 (nintv) の中点に描く.
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+)
+
+// PlotWindow は描画する範囲
+type PlotWindow struct {
+	XMin, XMax, YMin, YMax float64
+}
+
+const (
+	plot_width   = 480
+	plot_height  = 480
+	plot_margin  = 40
+	plot_samples = 64
+)
+
+type cadPlot struct {
+	cad  *CAD
+	win  PlotWindow
+	w    io.Writer
+	prod RObj // level 1 の射影因子の積
+}
+
+func plotFill(truth int8) string {
+	switch truth {
+	case t_true:
+		return "#9ecae1"
+	case t_false:
+		return "#f0f0f0"
+	}
+	return "#fff3b0"
+}
+
+func plotStroke(truth int8) string {
+	switch truth {
+	case t_true:
+		return "#08519c"
+	case t_false:
+		return "#969696"
+	}
+	return "#d4a017"
+}
+
+func ratFloat(x *big.Rat) float64 {
+	f, _ := x.Float64()
+	return f
+}
+
+// cellCoord は cell の座標の近似値を返す
+func cellCoord(c *Cell) float64 {
+	lo := c.isoBound(false)
+	hi := c.isoBound(true)
+	m := new(big.Rat).Add(lo, hi)
+	return ratFloat(m.Quo(m, big.NewRat(2, 1)))
+}
+
+func (pl *cadPlot) sx(x float64) float64 {
+	return plot_margin + (x-pl.win.XMin)/(pl.win.XMax-pl.win.XMin)*plot_width
+}
+
+func (pl *cadPlot) sy(y float64) float64 {
+	// 描画範囲から大きく外れる点は切り詰める. 表示は clipPath で切り取る
+	h := pl.win.YMax - pl.win.YMin
+	y = math.Max(math.Min(y, pl.win.YMax+h), pl.win.YMin-h)
+	return plot_margin + (pl.win.YMax-y)/h*plot_height
+}
+
+// rootsAt は x での level 1 の射影因子の積の実根の近似値を小さい順に返す.
+// 恒等的に 0 になる場合は nil
+func (pl *cadPlot) rootsAt(x *big.Rat) []float64 {
+	p := pl.prod.Subst(newRatBig(x), 0)
+	q, ok := p.(*Poly)
+	if !ok {
+		if p.IsZero() {
+			return nil
+		}
+		return []float64{}
+	}
+	if q.lv != 1 || !q.isUnivariate() {
+		return nil
+	}
+	q = uniSqfree(q)
+	rs := q.realRootIsolation(-20)
+	ret := make([]float64, len(rs))
+	for i, r := range rs {
+		m := new(big.Rat).Set(toBigRat(r.low))
+		if !r.point {
+			m.Add(m, toBigRat(r.low.upperBound()))
+			m.Quo(m, big.NewRat(2, 1))
+		}
+		ret[i] = ratFloat(m)
+	}
+	return ret
+}
+
+// sector は x 方向のセクタ cs[i] の上のセルを描く
+func (pl *cadPlot) sector(cs []*Cell, i int) {
+	cell := cs[i]
+	a, b := pl.win.XMin, pl.win.XMax
+	var ts []float64
+	ta, tb := []float64{0}, []float64{1}
+	if i > 0 {
+		if x := cellCoord(cs[i-1]); x > a {
+			a = x
+			ta = []float64{0.001, 0.01}
+		}
+	}
+	if i < len(cs)-1 {
+		if x := cellCoord(cs[i+1]); x < b {
+			b = x
+			tb = []float64{0.99, 0.999}
+		}
+	}
+	if a >= b {
+		return
+	}
+	ts = append(ts, ta...)
+	for k := 1; k < plot_samples; k++ {
+		ts = append(ts, float64(k)/plot_samples)
+	}
+	ts = append(ts, tb...)
+
+	nsec := -1
+	if cell.children != nil {
+		nsec = len(cell.children) / 2
+	}
+	xs := make([]float64, 0, len(ts))
+	ys := make([][]float64, 0, len(ts))
+	for _, t := range ts {
+		x := a + (b-a)*t
+		rs := pl.rootsAt(new(big.Rat).SetFloat64(x))
+		if rs == nil {
+			continue
+		}
+		if nsec < 0 {
+			nsec = len(rs)
+		}
+		if len(rs) == nsec {
+			xs = append(xs, x)
+			ys = append(ys, rs)
+		}
+	}
+	if len(xs) < 2 {
+		return
+	}
+	truth := func(j int) int8 {
+		if cell.children == nil {
+			return cell.truth
+		}
+		return cell.children[j].truth
+	}
+
+	// 領域
+	for j := 0; j <= nsec; j++ {
+		fmt.Fprintf(pl.w, `<polygon fill="%s" stroke="none" points="`, plotFill(truth(2*j)))
+		for k, x := range xs {
+			y := pl.win.YMin - (pl.win.YMax - pl.win.YMin)
+			if j > 0 {
+				y = ys[k][j-1]
+			}
+			fmt.Fprintf(pl.w, "%.2f,%.2f ", pl.sx(x), pl.sy(y))
+		}
+		for k := len(xs) - 1; k >= 0; k-- {
+			y := pl.win.YMax + (pl.win.YMax - pl.win.YMin)
+			if j < nsec {
+				y = ys[k][j]
+			}
+			fmt.Fprintf(pl.w, "%.2f,%.2f ", pl.sx(xs[k]), pl.sy(y))
+		}
+		fmt.Fprintf(pl.w, "\"/>\n")
+	}
+
+	// セクション
+	for j := 0; j < nsec; j++ {
+		fmt.Fprintf(pl.w, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="`, plotStroke(truth(2*j+1)))
+		for k, x := range xs {
+			fmt.Fprintf(pl.w, "%.2f,%.2f ", pl.sx(x), pl.sy(ys[k][j]))
+		}
+		fmt.Fprintf(pl.w, "\"/>\n")
+	}
+}
+
+// section は x 方向のセクション cell の上のセルを描く
+func (pl *cadPlot) section(cell *Cell) {
+	x := pl.sx(cellCoord(cell))
+	if cell.children == nil {
+		fmt.Fprintf(pl.w, `<line x1="%.2f" y1="%d" x2="%.2f" y2="%d" stroke="%s" stroke-width="1.5"/>`+"\n",
+			x, plot_margin, x, plot_margin+plot_height, plotStroke(cell.truth))
+		return
+	}
+	cs := cell.children
+	ys := make([]float64, len(cs)/2)
+	for j := range ys {
+		ys[j] = pl.sy(cellCoord(cs[2*j+1]))
+	}
+	for j := 0; j <= len(ys); j++ {
+		y1, y2 := float64(plot_margin+plot_height), float64(plot_margin)
+		if j > 0 {
+			y1 = ys[j-1]
+		}
+		if j < len(ys) {
+			y2 = ys[j]
+		}
+		fmt.Fprintf(pl.w, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-width="1.5"/>`+"\n",
+			x, y1, x, y2, plotStroke(cs[2*j].truth))
+	}
+	for j, y := range ys {
+		fmt.Fprintf(pl.w, `<circle cx="%.2f" cy="%.2f" r="3" fill="%s"/>`+"\n",
+			x, y, plotStroke(cs[2*j+1].truth))
+	}
+}
+
+// samples は標本点を描く
+func (pl *cadPlot) samples() {
+	for _, c := range pl.cad.root.children {
+		if c.index%2 == 0 && c.intv.inf == nil {
+			continue
+		}
+		x := cellCoord(c)
+		for _, d := range c.children {
+			if d.index%2 == 0 && d.intv.inf == nil {
+				continue
+			}
+			fmt.Fprintf(pl.w, `<circle cx="%.2f" cy="%.2f" r="1.5" fill="black"/>`+"\n",
+				pl.sx(x), pl.sy(cellCoord(d)))
+		}
+	}
+}
+
+// autoWindow はセクションと標本点が入る範囲を返す
+func (pl *cadPlot) autoWindow() PlotWindow {
+	xs := make([]float64, 0)
+	ys := make([]float64, 0)
+	for _, c := range pl.cad.root.children {
+		if c.index%2 != 0 {
+			xs = append(xs, cellCoord(c))
+		}
+		for _, d := range c.children {
+			if d.index%2 != 0 {
+				ys = append(ys, cellCoord(d))
+			}
+		}
+	}
+	lim := func(vs []float64) (float64, float64) {
+		if len(vs) == 0 {
+			return -1, 1
+		}
+		lo, hi := vs[0], vs[0]
+		for _, v := range vs {
+			lo = math.Min(lo, v)
+			hi = math.Max(hi, v)
+		}
+		d := math.Max(hi-lo, 1) * 0.2
+		return lo - d, hi + d
+	}
+	var w PlotWindow
+	w.XMin, w.XMax = lim(xs)
+	w.YMin, w.YMax = lim(ys)
+	return w
+}
+
+// PlotSVG は CAD の level 0, 1 のセルを SVG で書き出す.
+// 射影因子の零点, セル, 標本点と真偽値を描く.
+// win が nil なら描画範囲は自動で決める
+func (cad *CAD) PlotSVG(w io.Writer, win *PlotWindow) error {
+	if len(cad.q) < 2 || cad.q[0] != q_free || cad.q[1] != q_free {
+		return fmt.Errorf("two free variables are required")
+	}
+	if cad.stage < CAD_STAGE_LIFTED {
+		return fmt.Errorf("CAD is not lifted")
+	}
+	pl := &cadPlot{cad: cad, w: w, prod: cad.projProduct(1)}
+	if win == nil {
+		pl.win = pl.autoWindow()
+	} else {
+		pl.win = *win
+	}
+	if pl.win.XMin >= pl.win.XMax || pl.win.YMin >= pl.win.YMax {
+		return fmt.Errorf("invalid range")
+	}
+
+	size := plot_width + 2*plot_margin
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", size, size, size, size)
+	fmt.Fprintf(w, `<defs><clipPath id="plotarea"><rect x="%d" y="%d" width="%d" height="%d"/></clipPath></defs>`+"\n",
+		plot_margin, plot_margin, plot_width, plot_height)
+	fmt.Fprintf(w, `<g clip-path="url(#plotarea)">`+"\n")
+	cs := cad.root.children
+	for i := 0; i < len(cs); i += 2 {
+		pl.sector(cs, i)
+	}
+	for i := 1; i < len(cs); i += 2 {
+		pl.section(cs[i])
+	}
+	pl.samples()
+	fmt.Fprintf(w, "</g>\n")
+
+	// 枠と範囲
+	fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="black"/>`+"\n",
+		plot_margin, plot_margin, plot_width, plot_height)
+	fmt.Fprintf(w, `<g font-family="sans-serif" font-size="12">`+"\n")
+	fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="start">%g</text>`+"\n", plot_margin, size-plot_margin/2, pl.win.XMin)
+	fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="end">%g</text>`+"\n", size-plot_margin, size-plot_margin/2, pl.win.XMax)
+	fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n", size/2, size-plot_margin/2, varstr(0))
+	fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="end">%g</text>`+"\n", plot_margin-4, size-plot_margin, pl.win.YMin)
+	fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="end">%g</text>`+"\n", plot_margin-4, plot_margin+12, pl.win.YMax)
+	fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n", plot_margin-4, size/2, varstr(1))
+	fmt.Fprintf(w, "</g>\n</svg>\n")
+	return nil
+}
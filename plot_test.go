@@ -0,0 +1,106 @@
+package ganrac
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlotRootsAt(t *testing.T) {
+	x := NewPolyVar(0)
+	y := NewPolyVar(1)
+	pl := &cadPlot{prod: Mul(Sub(Mul(y, y), x), Sub(y, x))}
+	for i, s := range []struct {
+		x      int64
+		expect []float64
+	}{
+		{4, []float64{-2, 2, 4}},
+		{-1, []float64{-1}},
+		{1, []float64{-1, 1}},
+	} {
+		rs := pl.rootsAt(big.NewRat(s.x, 1))
+		if len(rs) != len(s.expect) {
+			t.Errorf("%d: x=%d: expect=%v, actual=%v", i, s.x, s.expect, rs)
+			continue
+		}
+		for j := range rs {
+			if math.Abs(rs[j]-s.expect[j]) > 1e-5 {
+				t.Errorf("%d: x=%d: expect=%v, actual=%v", i, s.x, s.expect, rs)
+				break
+			}
+		}
+	}
+
+	// 恒等的に 0
+	pl = &cadPlot{prod: Mul(x, y)}
+	if rs := pl.rootsAt(big.NewRat(0, 1)); rs != nil {
+		t.Errorf("nullified: expect=nil, actual=%v", rs)
+	}
+}
+
+func TestPlot(t *testing.T) {
+	g := NewGANRAC()
+	connc, connd := testConnectOx(g)
+	if g.ox == nil {
+		fmt.Printf("skip TestPlot... (no ox)\n")
+		return
+	}
+	defer connc.Close()
+	defer connd.Close()
+
+	dir := t.TempDir()
+	for i, s := range [][]string{
+		{"plot(x^2+y^2 < 1 && y > x^2-1/2, [x, -2, 2], [y, -2, 2], \"%s\");"},
+		{"C = cadinit(y^2 < x^3 - x);", "cadproj(C);", "cadlift(C);", "plot(C, \"%s\");"},
+	} {
+		fname := filepath.Join(dir, fmt.Sprintf("plot%d.svg", i))
+		var err error
+		for _, cmd := range s {
+			if strings.Contains(cmd, "%s") {
+				cmd = fmt.Sprintf(cmd, fname)
+			}
+			if _, err = g.Eval(strings.NewReader(cmd)); err != nil {
+				t.Errorf("%d: %s: %v", i, cmd, err)
+				break
+			}
+		}
+		if err != nil {
+			continue
+		}
+		b, err := os.ReadFile(fname)
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		svg := string(b)
+		if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>\n") ||
+			!strings.Contains(svg, "<polygon") || !strings.Contains(svg, "<polyline") {
+			t.Errorf("%d: invalid svg: %s", i, svg)
+		}
+	}
+
+	// 描画できないときはファイルを作らない
+	for i, cmd := range []string{
+		"C = cadinit(x^2 < 2);",
+		"cadproj(C);",
+		"cadlift(C);",
+		"plot(C, \"%s\");",
+	} {
+		fname := filepath.Join(dir, "invalid.svg")
+		if strings.Contains(cmd, "%s") {
+			cmd = fmt.Sprintf(cmd, fname)
+			if _, err := g.Eval(strings.NewReader(cmd)); err == nil {
+				t.Errorf("%d: %s: error is expected", i, cmd)
+			}
+			if _, err := os.Stat(fname); !os.IsNotExist(err) {
+				t.Errorf("%d: %s: file is created", i, cmd)
+			}
+		} else if _, err := g.Eval(strings.NewReader(cmd)); err != nil {
+			t.Errorf("%d: %s: %v", i, cmd, err)
+		}
+	}
+}
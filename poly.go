@@ -121,6 +121,8 @@ func (z *Poly) valid() error {
 			if cp.lv >= z.lv {
 				return fmt.Errorf("invalid level z=%d, coef[%d][%v]", z.lv, i, cp)
 			}
+		} else if _, ok := c.(*RealAlg); ok {
+			return fmt.Errorf("real algebraic coefficient is not supported: coef[%d][%v]", i, c)
 		}
 
 	}
@@ -243,6 +245,8 @@ func (z *Poly) Format(s fmt.State, format rune) {
 		fmt.Fprintf(s, "))")
 	case FORMAT_SRC: // source
 		z.write_src(s)
+	case FORMAT_SMT2:
+		z.write_smt2(s)
 	case FORMAT_TEX, FORMAT_QEPCAD:
 		z.write(s, format, false, " ")
 	default:
@@ -261,7 +265,18 @@ func (z *Poly) write(b fmt.State, format rune, out_sgn bool, mul string) {
 		if z.c[i].IsZero() {
 			continue
 		} else {
-			if z.c[i].IsNumeric() {
+			if _, ok := z.c[i].(*RealAlg); ok {
+				// 実代数的数は realalg(...) と出力されるので括弧でくくる
+				if i != len(z.c)-1 || out_sgn {
+					fmt.Fprintf(b, "+")
+				}
+				fmt.Fprintf(b, "(")
+				z.c[i].Format(b, format)
+				fmt.Fprintf(b, ")")
+				if i != 0 {
+					fmt.Fprintf(b, "%s", mul)
+				}
+			} else if z.c[i].IsNumeric() {
 				s := z.c[i].Sign()
 				if s >= 0 {
 					if i != len(z.c)-1 || out_sgn {
@@ -304,7 +319,7 @@ func (z *Poly) write(b fmt.State, format rune, out_sgn bool, mul string) {
 			}
 			if i > 0 {
 				fmt.Fprintf(b, "%s", varstr(z.lv))
-				if i >= 10 && mul == " " { // TeX
+				if i >= 10 && format == FORMAT_TEX {
 					fmt.Fprintf(b, "^{%d}", i)
 				} else if i > 1 {
 					fmt.Fprintf(b, "^%d", i)
@@ -740,6 +755,10 @@ func (z *Poly) subst_intv(x, x2 *Interval, lv Level, prec uint) RObj {
 func (z *Poly) Subst(xs RObj, lv Level) RObj {
 	// lvs: sorted
 
+	if x, ok := xs.(*RealAlg); ok && z.lv == lv && z.isUnivariate() {
+		return x.evalPoly(z)
+	}
+
 	var p RObj
 	if z.lv == lv {
 		x := xs
@@ -1,6 +1,7 @@
 package ganrac
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -60,6 +61,9 @@ type ProjFactors interface {
 	addPoly(p *Poly, isInput bool) ProjFactor
 
 	doProj(cad *CAD, idx int)
+
+	// 先頭の n 個の射影因子だけを残す
+	truncate(n int)
 }
 
 type ProjLink struct {
@@ -220,20 +224,33 @@ func (pl *ProjLink) merge(p *ProjLink) {
 }
 
 func (cad *CAD) getU() []*Interval {
-	fml := cad.fml.simplFctr(cad.g)
+	cad.u = cad.fmlU(cad.fml)
+	return cad.u
+}
+
+// fmlU は限量子のない論理式 fml が真になりうる範囲を変数ごとに返す
+func (cad *CAD) fmlU(fml Fof) []*Interval {
+	fml = fml.simplFctr(cad.g)
 	_, t, f := fml.simplNum(cad.g, nil, nil)
-	cad.u = make([]*Interval, len(cad.q))
+	ret := make([]*Interval, len(cad.q))
 	for lv := 0; lv < len(cad.q); lv++ {
 		us := t.getU(f, Level(lv))
 		u := newInterval(53)
 		u.sup = us[len(us)-1].sup
 		u.inf = us[0].inf
-		cad.u[lv] = u
+		ret[lv] = u
 	}
-	return cad.u
+	return ret
 }
 
 func (cad *CAD) Projection(algo ProjectionAlgo) (*List, error) {
+	return cad.ProjectionContext(context.Background(), algo)
+}
+
+// ProjectionContext computes the projection factors.
+// It returns ctx.Err() if ctx is done between projection steps.
+// A canceled CAD can not be projected again.
+func (cad *CAD) ProjectionContext(ctx context.Context, algo ProjectionAlgo) (*List, error) {
 	if cad.stage >= CAD_STAGE_PROJED {
 		return nil, fmt.Errorf("already projected")
 	}
@@ -261,7 +278,11 @@ func (cad *CAD) Projection(algo ProjectionAlgo) (*List, error) {
 		})
 
 		for i := 0; i < cad.proj[lv].Len(); i++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
 			cad.proj[lv].doProj(cad, i)
+			cad.progressed()
 		}
 	}
 	{
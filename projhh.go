@@ -36,6 +36,13 @@ func (pfs *ProjFactorsHH) addPoly(p *Poly, isInput bool) ProjFactor {
 	return pf
 }
 
+func (pfs *ProjFactorsHH) truncate(n int) {
+	pfs.pf = pfs.pf[:n]
+	if len(pfs.pscs) > n {
+		pfs.pscs = pfs.pscs[:n]
+	}
+}
+
 func (pfs *ProjFactorsHH) gets() []ProjFactor {
 	return pfs.pf
 }
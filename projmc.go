@@ -38,6 +38,13 @@ func (pfs *ProjFactorsMC) addPoly(p *Poly, isInput bool) ProjFactor {
 	return pf
 }
 
+func (pfs *ProjFactorsMC) truncate(n int) {
+	pfs.pf = pfs.pf[:n]
+	if len(pfs.resultant) > n {
+		pfs.resultant = pfs.resultant[:n]
+	}
+}
+
 func (pfs *ProjFactorsMC) gets() []ProjFactor {
 	return pfs.pf
 }
@@ -5,8 +5,11 @@ package ganrac
 // ADG2014
 
 import (
+	"context"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 )
 
 type algo_t int64
@@ -33,6 +36,43 @@ type QEopt struct {
 	g         *Ganrac
 	seqno     int
 	assert    bool
+	ctx       context.Context
+	progress  func(*CADProgress)
+	timeout   time.Duration // 各アルゴリズムの制限時間. 0 なら無制限
+	maxcells  int           // CAD のセルの数の上限. 0 なら無制限
+	mstat     *QEMethodStat // 実行中のアルゴリズムの統計
+}
+
+// QEMethodStat は予算を超えて打ち切ったアルゴリズムの統計
+type QEMethodStat struct {
+	Method string
+	Time   time.Duration
+	Cells  []int // [level] CAD のセルの数
+	Err    error // 打ち切った理由
+}
+
+// QEBudgetError は全てのアルゴリズムが予算を超えたときのエラー
+type QEBudgetError struct {
+	Fml   Fof
+	Stats []*QEMethodStat
+}
+
+func (e *QEBudgetError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "budget exceeded: %v", e.Fml)
+	for _, st := range e.Stats {
+		fmt.Fprintf(&b, "; %s %.3fs", st.Method, st.Time.Seconds())
+		if st.Cells != nil {
+			fmt.Fprintf(&b, " cells=%v", st.Cells)
+		}
+		fmt.Fprintf(&b, ": %v", st.Err)
+	}
+	return b.String()
+}
+
+// qeCanceled は中断したときの panic の値
+type qeCanceled struct {
+	err error
 }
 
 type qeCond struct {
@@ -80,6 +120,69 @@ func (qeopt *QEopt) SetAlgo(algo algo_t, v bool) {
 	}
 }
 
+// SetProgress sets a callback function which reports the progress of CAD.
+func (qeopt *QEopt) SetProgress(f func(*CADProgress)) {
+	qeopt.progress = f
+}
+
+// SetTimeout limits the time of each QE algorithm.
+// An algorithm which exceeds the limit is abandoned,
+// and the next applicable algorithm is tried.
+// d = 0 means unlimited.  It is used by QEContext, not by QE.
+func (qeopt *QEopt) SetTimeout(d time.Duration) {
+	qeopt.timeout = d
+}
+
+// SetMaxCells limits the number of cells of CAD.  see SetTimeout.
+func (qeopt *QEopt) SetMaxCells(n int) {
+	qeopt.maxcells = n
+}
+
+// budget は予算内で f を実行する.
+// 予算を超えたら打ち切って統計を stats に追加し, nil を返す
+func (qeopt QEopt) budget(name string, stats *[]*QEMethodStat, cond qeCond, f func(qeopt QEopt) Fof) (ret Fof) {
+	qeopt.checkCanceled()
+	if qeopt.timeout <= 0 && qeopt.maxcells <= 0 {
+		return f(qeopt)
+	}
+
+	st := &QEMethodStat{Method: name}
+	sub := qeopt
+	var cancel context.CancelFunc
+	if qeopt.timeout > 0 {
+		sub.ctx, cancel = context.WithTimeout(qeopt.ctx, qeopt.timeout)
+	} else {
+		sub.ctx, cancel = context.WithCancel(qeopt.ctx)
+	}
+	sub.mstat = st
+	tm_start := time.Now()
+	defer func() {
+		cancel()
+		r := recover()
+		if r == nil {
+			return
+		}
+		c, ok := r.(qeCanceled)
+		if !ok || qeopt.ctx.Err() != nil {
+			// 外側の予算か中断
+			panic(r)
+		}
+		st.Time = time.Since(tm_start)
+		st.Err = c.err
+		*stats = append(*stats, st)
+		qeopt.log(cond, 1, "budget", "%s: %v\n", name, c.err)
+		ret = nil
+	}()
+	return f(sub)
+}
+
+// checkCanceled は中断されていたら QEContext か budget まで戻る
+func (qeopt *QEopt) checkCanceled() {
+	if err := qeopt.ctx.Err(); err != nil {
+		panic(qeCanceled{err})
+	}
+}
+
 func (qeopt *QEopt) log(cond qeCond, level int, label, fmt string, args ...interface{}) {
 	if qeopt.log_level < level {
 		return
@@ -108,6 +211,15 @@ func (qeopt *QEopt) num_var(f Fof) int {
 func (qeopt *QEopt) new_var() Level {
 	v := qeopt.varn
 	qeopt.varn += 1
+	// OX に渡すため, 名前をつけておく. QE() の終了時に削除する
+	for n := len(varlist); int(v) >= len(varlist); n++ {
+		name := fmt.Sprintf("t_%d", n)
+		if _, ok := varstr2lv[name]; !ok {
+			lv := Level(len(varlist))
+			varlist = append(varlist, varInfo{name, newPolyVarn(lv, 1)})
+			varstr2lv[name] = lv
+		}
+	}
 	return v
 }
 
@@ -172,7 +284,14 @@ func (qeopt *QEopt) fmlcmp(f1, f2 Fof) bool {
 
 func (qeopt *QEopt) qe_init(g *Ganrac, fof Fof) {
 	qeopt.varn = fof.maxVar() + 1
+	if n := radbase + Level(len(radlist)); qeopt.varn < n {
+		// 新しい変数が冪根のレベルと重ならないようにする
+		qeopt.varn = n
+	}
 	qeopt.g = g
+	if qeopt.ctx == nil {
+		qeopt.ctx = context.Background()
+	}
 	if qeopt.Algo == 0 {
 		qeopt.Algo = -1
 	}
@@ -183,11 +302,50 @@ func (cond *qeCond) qecond_init() {
 	cond.sufcon = falseObj
 }
 
+// QE eliminates the quantifiers of fof.
+// The budgets given by SetTimeout and SetMaxCells are ignored.
+// Use QEContext to limit them.
 func (g *Ganrac) QE(fof Fof, qeopt *QEopt) Fof {
+	timeout, maxcells := qeopt.timeout, qeopt.maxcells
+	qeopt.timeout, qeopt.maxcells = 0, 0
+	defer func() {
+		qeopt.timeout, qeopt.maxcells = timeout, maxcells
+	}()
+	f, err := g.QEContext(context.Background(), fof, qeopt)
+	if err != nil {
+		// 予算も中断もないので起こらない
+		panic(err)
+	}
+	return f
+}
+
+// QEContext eliminates the quantifiers of fof.
+// It returns ctx.Err() if ctx is done between QE steps,
+// cell lifts or projection steps of CAD.
+// It returns *QEBudgetError if all the algorithms exceed the budget.
+func (g *Ganrac) QEContext(ctx context.Context, fof Fof, qeopt *QEopt) (ret Fof, err error) {
 	var cond qeCond
+	n := len(varlist)
+	defer func() {
+		for _, v := range varlist[n:] {
+			delete(varstr2lv, v.v)
+		}
+		varlist = varlist[:n]
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			c, ok := r.(qeCanceled)
+			if !ok {
+				panic(r)
+			}
+			ret, err = nil, c.err
+		}
+	}()
+	qeopt.ctx = ctx
 	qeopt.qe_init(g, fof)
+	fof = qeopt.elimRadical(fof)
 	cond.qecond_init()
-	return qeopt.qe(fof, cond)
+	return qeopt.qe(fof, cond), nil
 }
 
 func (qeopt QEopt) qe(fof Fof, cond qeCond) Fof {
@@ -195,6 +353,7 @@ func (qeopt QEopt) qe(fof Fof, cond qeCond) Fof {
 	qeopt.seqno++
 	qeopt.log(cond, 2, "qe", "%v\n", fof)
 	for {
+		qeopt.checkCanceled()
 		fof = fof.nonPrenex()
 		qeopt.log(cond, 2, "qe", "1:%v\n", fof)
 		switch fq := fof.(type) {
@@ -219,6 +378,7 @@ func (qeopt QEopt) qe(fof Fof, cond qeCond) Fof {
 }
 
 func (qeopt QEopt) simplify(qff Fof, cond qeCond) Fof {
+	qeopt.checkCanceled()
 	return qeopt.g.simplFof(qff, cond.neccon, cond.sufcon)
 }
 
@@ -273,6 +433,7 @@ func (qeopt QEopt) qe_prenex(fof FofQ, cond qeCond) Fof {
 }
 
 func (qeopt QEopt) reconstruct(fqs []FofQ, ff Fof, cond qeCond) Fof {
+	qeopt.checkCanceled()
 	for i := len(fqs) - 1; i >= 0; i-- {
 		ff = fqs[i].gen(fqs[i].Qs(), ff)
 	}
@@ -326,16 +487,26 @@ func (qeopt QEopt) qe_prenex_main(prenex_formula FofQ, cond qeCond) Fof {
 	// quantifier がひとつの場合のみに限定してみる
 	////////////////////////////////
 
+	// 各アルゴリズムは予算を超えたら打ち切り, 次を試す.
+	// 予算には結果の論理式の QE も含む
+	var stats []*QEMethodStat
+
 	////////////////////////////////
 	// Hong93
 	// 線形か2次の等式制約が含まれる場合.
 	////////////////////////////////
 	if qeopt.Algo&(QEALGO_EQLIN|QEALGO_EQQUAD) != 0 {
-		if ff := qeopt.qe_quadeq(fof, cond); ff != nil {
+		if ff := qeopt.budget("eqcon", &stats, cond, func(qeopt QEopt) Fof {
+			ff := qeopt.qe_quadeq(fof, cond)
+			if ff == nil {
+				return nil
+			}
 			ff = qeopt.reconstruct(fqs, ff, cond)
 			ff = qeopt.simplify(ff, cond)
 			qeopt.log(cond, 2, "eqret", "%v\n", fof)
 			return ff
+		}); ff != nil {
+			return ff
 		}
 	}
 
@@ -343,11 +514,17 @@ func (qeopt QEopt) qe_prenex_main(prenex_formula FofQ, cond qeCond) Fof {
 	// VS を適用できるか.
 	////////////////////////////////
 	if (qeopt.Algo & QEALGO_VSLIN) != 0 {
-		if ff := qeopt.qe_vslin(fof, cond); ff != nil {
+		if ff := qeopt.budget("vslin", &stats, cond, func(qeopt QEopt) Fof {
+			ff := qeopt.qe_vslin(fof, cond)
+			if ff == nil {
+				return nil
+			}
 			ff = qeopt.reconstruct(fqs, ff, cond)
 			ff = qeopt.simplify(ff, cond)
 			qeopt.log(cond, 2, "vsret", "%v\n", fof)
 			return ff
+		}); ff != nil {
+			return ff
 		}
 	}
 
@@ -355,11 +532,17 @@ func (qeopt QEopt) qe_prenex_main(prenex_formula FofQ, cond qeCond) Fof {
 	// 非等式 QE
 	////////////////////////////////
 	if (qeopt.Algo & QEALGO_NEQ) != 0 {
-		if ff := qeopt.qe_neq(fof, cond); ff != nil {
+		if ff := qeopt.budget("neq", &stats, cond, func(qeopt QEopt) Fof {
+			ff := qeopt.qe_neq(fof, cond)
+			if ff == nil {
+				return nil
+			}
 			ff = qeopt.reconstruct(fqs, ff, cond)
 			ff = qeopt.simplify(ff, cond)
 			qeopt.log(cond, 2, "neq", "%v\n", fof)
 			return ff
+		}); ff != nil {
+			return ff
 		}
 	}
 
@@ -367,7 +550,9 @@ func (qeopt QEopt) qe_prenex_main(prenex_formula FofQ, cond qeCond) Fof {
 	// CAD ではどうしようもないが, VS 2 次が使えるかも?
 	////////////////////////////////
 
-	if ff := qeopt.qe_simpl(fof, cond); ff != nil {
+	if ff := qeopt.budget("simpl", &stats, cond, func(qeopt QEopt) Fof {
+		return qeopt.qe_simpl(fof, cond)
+	}); ff != nil {
 		return ff
 	}
 
@@ -375,7 +560,14 @@ func (qeopt QEopt) qe_prenex_main(prenex_formula FofQ, cond qeCond) Fof {
 	// CAD
 	// @TODO 前調査で多項式がおおかったら分配する、のも手ではないか.
 	////////////////////////////////
-	return qeopt.qe_cad(fof, cond)
+	if ff := qeopt.budget("cad", &stats, cond, func(qeopt QEopt) Fof {
+		return qeopt.qe_cad(fof, cond)
+	}); ff != nil {
+		return ff
+	}
+
+	// 全て予算を超えた
+	panic(qeCanceled{&QEBudgetError{Fml: prenex_formula, Stats: stats}})
 }
 
 func (qeopt QEopt) is_easy_cond(fof Fof, cond Fof) bool {
@@ -520,8 +712,8 @@ func (qeopt QEopt) qe_cad(fof FofQ, cond qeCond) Fof {
 	if err != nil {
 		panic(fmt.Sprintf("cad.lift() input=%v\nerr=%v", fof2, err))
 	}
-	cad.Projection(PROJ_McCallum)
-	err = cad.Lift()
+	cad.SetProgress(qeopt.progress)
+	err = qeopt.cadLift(cad, PROJ_McCallum)
 	for err != nil {
 		if err != CAD_NO_WO {
 			panic(fmt.Sprintf("cad.lift() input=%v\nerr=%v", fof, err))
@@ -530,8 +722,8 @@ func (qeopt QEopt) qe_cad(fof FofQ, cond qeCond) Fof {
 
 		// NOT well-oriented で Hong-proj へ
 		cad, _ = NewCAD(fof2, qeopt.g)
-		cad.Projection(PROJ_HONG)
-		err = cad.Lift()
+		cad.SetProgress(qeopt.progress)
+		err = qeopt.cadLift(cad, PROJ_HONG)
 	}
 	fof3, err := cad.Sfc()
 	if err != nil {
@@ -549,6 +741,24 @@ func (qeopt QEopt) qe_cad(fof FofQ, cond qeCond) Fof {
 	return fof3
 }
 
+// cadLift は CAD を射影して持ち上げる. 中断されるか, 予算を超えたら戻る
+func (qeopt QEopt) cadLift(cad *CAD, algo ProjectionAlgo) error {
+	cad.SetMaxCells(qeopt.maxcells)
+	_, err := cad.ProjectionContext(qeopt.ctx, algo)
+	if err == nil {
+		err = cad.LiftContext(qeopt.ctx)
+	}
+	if qeopt.mstat != nil {
+		qeopt.mstat.Cells = append([]int(nil), cad.stat.cell...)
+	}
+	if err != nil && qeopt.ctx.Err() != nil {
+		panic(qeCanceled{qeopt.ctx.Err()})
+	} else if err == CAD_TOO_MANY_CELLS {
+		panic(qeCanceled{err})
+	}
+	return err
+}
+
 func (qeopt QEopt) qe_nonpreq(fofq FofQ, cond qeCond) Fof {
 	qeopt.log(cond, 2, "qenpr", "%v\n", fofq)
 	fs := make([]FofQ, 1)
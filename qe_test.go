@@ -1,8 +1,11 @@
 package ganrac
 
 import (
+	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 )
 
 func benchmarkQE(b *testing.B, name string) {
@@ -27,3 +30,128 @@ func BenchmarkAdam1(b *testing.B) {
 
 func TestBench(t *testing.T) {
 }
+
+func TestQEContext(t *testing.T) {
+	g := NewGANRAC()
+	f, err := g.Eval(strings.NewReader("ex([x], a*x^2+b*x+c == 0);"))
+	if err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	n := len(varlist)
+	if r, err := g.QEContext(ctx, f.(Fof), NewQEopt()); err != context.Canceled || r != nil {
+		t.Errorf("qe: expect=%v, actual=(%v, %v)", context.Canceled, r, err)
+	}
+	if len(varlist) != n {
+		t.Errorf("qe: varlist %d => %d", n, len(varlist))
+	}
+}
+
+func TestQEIgnoreBudget(t *testing.T) {
+	g := NewGANRAC()
+	f, err := g.Eval(strings.NewReader("ex([x], x > 0);"))
+	if err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	// QE() は予算を無視する
+	opt := NewQEopt()
+	opt.SetTimeout(time.Nanosecond)
+	opt.SetMaxCells(1)
+	if r := g.QE(f.(Fof), opt); r != trueObj {
+		t.Errorf("qe: expect=true, actual=%v", r)
+	}
+	if opt.timeout != time.Nanosecond || opt.maxcells != 1 {
+		t.Errorf("qe: budget is changed: %v, %d", opt.timeout, opt.maxcells)
+	}
+}
+
+func TestQECanceled(t *testing.T) {
+	g := NewGANRAC()
+	f, err := g.Eval(strings.NewReader("ex([x], a*x+b > 0 && x < 1);"))
+	if err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	fq := f.(FofQ)
+	var cond qeCond
+	cond.qecond_init()
+	opt := NewQEopt()
+	opt.qe_init(g, fq)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	opt.ctx = ctx
+
+	// VS, 簡単化, 再構成でも中断を確認する
+	for i, fn := range []func(){
+		func() { opt.qe_vslin(fq, cond) },
+		func() { opt.simplify(fq.Fml(), cond) },
+		func() { opt.reconstruct([]FofQ{fq}, fq.Fml(), cond) },
+	} {
+		func() {
+			defer func() {
+				if r, ok := recover().(qeCanceled); !ok || r.err != context.Canceled {
+					t.Errorf("%d: expect=%v, actual=%v", i, context.Canceled, r)
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestQEBudget(t *testing.T) {
+	g := NewGANRAC()
+	f, err := g.Eval(strings.NewReader("ex([x], x^2+y < 0);"))
+	if err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	var cond qeCond
+	cond.qecond_init()
+	opt := NewQEopt()
+	opt.qe_init(g, f.(Fof))
+	opt.SetTimeout(10 * time.Millisecond)
+	opt.SetMaxCells(100)
+
+	var stats []*QEMethodStat
+	if ff := opt.budget("ok", &stats, cond, func(qeopt QEopt) Fof {
+		return trueObj
+	}); ff != trueObj || len(stats) != 0 {
+		t.Errorf("ok: %v %v", ff, stats)
+	}
+	if ff := opt.budget("timeout", &stats, cond, func(qeopt QEopt) Fof {
+		<-qeopt.ctx.Done()
+		qeopt.checkCanceled()
+		return trueObj
+	}); ff != nil || len(stats) != 1 || stats[0].Err != context.DeadlineExceeded || stats[0].Method != "timeout" {
+		t.Errorf("timeout: %v %v", ff, stats)
+	}
+	if ff := opt.budget("cad", &stats, cond, func(qeopt QEopt) Fof {
+		qeopt.mstat.Cells = []int{3, 120}
+		panic(qeCanceled{CAD_TOO_MANY_CELLS})
+	}); ff != nil || len(stats) != 2 || stats[1].Err != CAD_TOO_MANY_CELLS {
+		t.Errorf("maxcells: %v %v", ff, stats)
+	}
+
+	e := &QEBudgetError{Fml: f.(Fof), Stats: stats}
+	if s := e.Error(); !strings.Contains(s, "timeout") || !strings.Contains(s, "cells=[3 120]") {
+		t.Errorf("error: %s", s)
+	}
+
+	// 外側の中断は打ち切らずに伝える
+	ctx, cancel := context.WithCancel(context.Background())
+	opt.ctx = ctx
+	func() {
+		defer func() {
+			if r, ok := recover().(qeCanceled); !ok || r.err != context.Canceled {
+				t.Errorf("cancel: %v", r)
+			}
+		}()
+		opt.budget("cancel", &stats, cond, func(qeopt QEopt) Fof {
+			cancel()
+			qeopt.checkCanceled()
+			return trueObj
+		})
+	}()
+	if len(stats) != 2 {
+		t.Errorf("cancel: %v", stats)
+	}
+}
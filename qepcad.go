@@ -0,0 +1,398 @@
+package ganrac
+
+// QEPCAD B の入力ファイル
+//
+//   [ informal description ]
+//   (a,b,x)
+//   2
+//   (E x)[ a x^2 + b x + 1 = 0 /\ x > 0 ].
+//
+// 変数リストが変数順序になる.
+// 論理式のあとのコマンド (assume, go, finish, ...) は無視する.
+
+import (
+	"io"
+)
+
+var qepcadOps = []string{
+	"[", "]", "(", ")", ",", ".",
+	"+", "-", "*", "/", "^",
+	"=", "/=", "<", ">", "<=", ">=",
+	"/\\", "\\/", "~", "==>", "<==", "<==>",
+}
+
+type qepcadParser struct {
+	*fmlScanner
+}
+
+// LoadQEPCAD reads a QEPCAD B input file.
+// The variable order is initialized by the variable list of the input.
+func (g *Ganrac) LoadQEPCAD(r io.Reader) (Fof, error) {
+	p := &qepcadParser{newFmlScanner(r, "qepcad", qepcadOps)}
+
+	// informal description
+	c, err := p.skipSpace()
+	if err != nil || c != '[' {
+		return nil, p.errorf(p.line, "informal description `[ ... ]` is expected")
+	}
+	if err := p.skipUntil(']'); err != nil {
+		return nil, err
+	}
+
+	// variable list
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	vars := make([]string, 0)
+	for {
+		if p.tok.kind != fmlTokIdent {
+			return nil, p.unexpected("variable")
+		}
+		vars = append(vars, p.tok.s)
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.isOp(")") {
+			break
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	// number of free variables
+	if p.tok.kind != fmlTokNum {
+		return nil, p.unexpected("number of free variables")
+	}
+	nfree := ParseInt(p.tok.s, 10)
+	if !nfree.IsInt64() || nfree.Int64() > int64(len(vars)) {
+		return nil, p.errorf(p.tok.line, "invalid number of free variables: %v", nfree)
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	// prenex formula
+	line := p.tok.line
+	node, err := p.equiv()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOp(".") {
+		return nil, p.unexpected(".")
+	}
+
+	qvars := make([]string, 0)
+	for n := node; n.cmd == fmlNodeEx || n.cmd == fmlNodeAll; n = n.args[0] {
+		qvars = append(qvars, n.vars...)
+	}
+	free := int(nfree.Int64())
+	if len(qvars) != len(vars)-free {
+		return nil, p.errorf(line, "%d quantified variables are expected", len(vars)-free)
+	}
+	for i, v := range qvars {
+		if vars[free+i] != v {
+			return nil, p.errorf(line, "quantified variable `%s` is expected, but `%s`", vars[free+i], v)
+		}
+	}
+
+	if err := g.InitVarList(vars); err != nil {
+		return nil, err
+	}
+	return node.fof(p.name)
+}
+
+func (p *qepcadParser) equiv() (*fmlNode, error) {
+	n, err := p.impl()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("<==>") {
+		line := p.tok.line
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.impl()
+		if err != nil {
+			return nil, err
+		}
+		n = newFmlNode(fmlNodeEquiv, line, n, m)
+	}
+	return n, nil
+}
+
+func (p *qepcadParser) impl() (*fmlNode, error) {
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("==>") || p.isOp("<==") {
+		op := p.tok
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if op.s == "==>" {
+			n = newFmlNode(fmlNodeImpl, op.line, n, m)
+		} else {
+			n = newFmlNode(fmlNodeImpl, op.line, m, n)
+		}
+	}
+	return n, nil
+}
+
+func (p *qepcadParser) or() (*fmlNode, error) {
+	n, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOp("\\/") {
+		return n, nil
+	}
+	n = newFmlNode(fmlNodeOr, p.tok.line, n)
+	for p.isOp("\\/") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		n.args = append(n.args, m)
+	}
+	return n, nil
+}
+
+func (p *qepcadParser) and() (*fmlNode, error) {
+	n, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isOp("/\\") {
+		return n, nil
+	}
+	n = newFmlNode(fmlNodeAnd, p.tok.line, n)
+	for p.isOp("/\\") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		n.args = append(n.args, m)
+	}
+	return n, nil
+}
+
+func (p *qepcadParser) unary() (*fmlNode, error) {
+	line := p.tok.line
+	switch {
+	case p.isOp("~"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return newFmlNode(fmlNodeNot, line, n), nil
+	case p.isOp("["):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.equiv()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect("]")
+	case p.tok.kind == fmlTokIdent && p.tok.s == "TRUE":
+		return newFmlNode(fmlNodeTrue, line), p.next()
+	case p.tok.kind == fmlTokIdent && p.tok.s == "FALSE":
+		return newFmlNode(fmlNodeFalse, line), p.next()
+	case p.isOp("("):
+		// (E x) or (A x)
+		t0 := p.tok
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == fmlTokIdent && (p.tok.s == "E" || p.tok.s == "A") {
+			t1 := p.tok
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if p.tok.kind == fmlTokIdent {
+				n := newFmlNode(fmlNodeEx, line)
+				if t1.s == "A" {
+					n.cmd = fmlNodeAll
+				}
+				n.vars = []string{p.tok.s}
+				if err := p.next(); err != nil {
+					return nil, err
+				}
+				if err := p.expect(")"); err != nil {
+					return nil, err
+				}
+				m, err := p.unary()
+				if err != nil {
+					return nil, err
+				}
+				n.args = []*fmlNode{m}
+				return n, nil
+			}
+			p.unget(t1)
+		}
+		p.unget(t0)
+	}
+	return p.atom()
+}
+
+var qepcadRelop = map[string]OP{
+	"<": LT, "=": EQ, "<=": LE, ">": GT, "/=": NE, ">=": GE,
+}
+
+func (p *qepcadParser) atom() (*fmlNode, error) {
+	lhs, err := p.poly()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := qepcadRelop[p.tok.s]
+	if p.tok.kind != fmlTokOp || !ok {
+		return nil, p.unexpected("relational operator")
+	}
+	line := p.tok.line
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	rhs, err := p.poly()
+	if err != nil {
+		return nil, err
+	}
+	n := newFmlNode(fmlNodeAtom, line, lhs, rhs)
+	n.op = op
+	return n, nil
+}
+
+func (p *qepcadParser) poly() (*fmlNode, error) {
+	var n *fmlNode
+	line := p.tok.line
+	if p.isOp("+") || p.isOp("-") {
+		neg := p.isOp("-")
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		if neg {
+			m = newFmlNode(fmlNodeNeg, line, m)
+		}
+		n = m
+	} else {
+		m, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		n = m
+	}
+	for p.isOp("+") || p.isOp("-") {
+		cmd := fmlNodeAdd
+		if p.isOp("-") {
+			cmd = fmlNodeSub
+		}
+		line := p.tok.line
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		n = newFmlNode(cmd, line, n, m)
+	}
+	return n, nil
+}
+
+// term: 積は空白でも表現される
+func (p *qepcadParser) term() (*fmlNode, error) {
+	n, err := p.power()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		line := p.tok.line
+		cmd := fmlNodeMul
+		if p.isOp("*") || p.isOp("/") {
+			if p.isOp("/") {
+				cmd = fmlNodeDiv
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		} else if !(p.isOp("(") || p.tok.kind == fmlTokNum || p.tok.kind == fmlTokIdent) {
+			return n, nil
+		}
+		m, err := p.power()
+		if err != nil {
+			return nil, err
+		}
+		n = newFmlNode(cmd, line, n, m)
+	}
+}
+
+func (p *qepcadParser) power() (*fmlNode, error) {
+	n, err := p.prim()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOp("^") {
+		line := p.tok.line
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind != fmlTokNum {
+			return nil, p.unexpected("integer")
+		}
+		e := newFmlNode(fmlNodeNum, p.tok.line)
+		e.s = p.tok.s
+		n = newFmlNode(fmlNodePow, line, n, e)
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func (p *qepcadParser) prim() (*fmlNode, error) {
+	line := p.tok.line
+	switch {
+	case p.tok.kind == fmlTokNum:
+		n := newFmlNode(fmlNodeNum, line)
+		n.s = p.tok.s
+		return n, p.next()
+	case p.tok.kind == fmlTokIdent:
+		n := newFmlNode(fmlNodeVar, line)
+		n.s = p.tok.s
+		return n, p.next()
+	case p.isOp("("):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.poly()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(")")
+	}
+	return nil, p.unexpected("polynomial")
+}
@@ -0,0 +1,250 @@
+package ganrac
+
+// 冪根 sqrt(p), root(p, k).
+// 冪根は varlist の後ろのレベルの変数で表し,
+// QE の前に新しい変数 t と定義式 t^k == p (&& t >= 0) で消去する.
+
+import (
+	"fmt"
+	"math/big"
+)
+
+type radInfo struct {
+	p RObj // *Poly or NObj
+	k int
+}
+
+// radlist[i] は レベル radbase+i の変数が表す冪根
+var radlist []radInfo
+var radbase Level
+
+func (r *radInfo) String() string {
+	if r.k == 2 {
+		return fmt.Sprintf("sqrt(%v)", r.p)
+	}
+	return fmt.Sprintf("root(%v, %d)", r.p, r.k)
+}
+
+func getRadical(lv Level) *radInfo {
+	if radbase <= lv && int(lv-radbase) < len(radlist) {
+		return &radlist[lv-radbase]
+	}
+	return nil
+}
+
+// iroot は n の k 乗根が整数なら返す. n >= 0
+func iroot(n *big.Int, k int) (*big.Int, bool) {
+	if n.Sign() == 0 {
+		return n, true
+	}
+	lo := big.NewInt(1)
+	hi := new(big.Int).Lsh(lo, uint(n.BitLen()/k+1))
+	kk := big.NewInt(int64(k))
+	for lo.Cmp(hi) <= 0 {
+		m := new(big.Int).Add(lo, hi)
+		m.Rsh(m, 1)
+		c := new(big.Int).Exp(m, kk, nil).Cmp(n)
+		if c == 0 {
+			return m, true
+		} else if c < 0 {
+			lo = m.Add(m, one.n)
+		} else {
+			hi = m.Sub(m, one.n)
+		}
+	}
+	return nil, false
+}
+
+// nrootExact は x の k 乗根が有理数なら返す
+func nrootExact(x NObj, k int) (NObj, bool) {
+	switch c := x.(type) {
+	case *Int:
+		if n, ok := iroot(c.n, k); ok {
+			z := newInt()
+			z.n = n
+			return z, true
+		}
+	case *Rat:
+		n, ok := iroot(c.n.Num(), k)
+		if !ok {
+			return nil, false
+		}
+		d, ok := iroot(c.n.Denom(), k)
+		if !ok {
+			return nil, false
+		}
+		z := newRat()
+		z.n.SetFrac(n, d)
+		return z, true
+	}
+	return nil, false
+}
+
+// NewRoot returns the real k-th root of p.
+// If k is even, it is the nonnegative one.
+func NewRoot(p RObj, k int) (RObj, error) {
+	if k <= 0 {
+		return nil, fmt.Errorf("invalid index: %d", k)
+	}
+	if k == 1 || p.IsZero() || p.IsOne() {
+		return p, nil
+	}
+	switch c := p.(type) {
+	case *Int, *Rat:
+		x := c.(NObj)
+		if x.Sign() < 0 {
+			if k%2 == 0 {
+				return nil, fmt.Errorf("negative radicand: %v", p)
+			}
+			r, err := NewRoot(x.Neg(), k)
+			if err != nil {
+				return nil, err
+			}
+			return r.Neg(), nil
+		}
+		if z, ok := nrootExact(x, k); ok {
+			return z, nil
+		}
+	case *Poly:
+	default:
+		return nil, fmt.Errorf("unsupported radicand: %v", p)
+	}
+
+	for i, r := range radlist {
+		if r.k == k && r.p.Equals(p) {
+			return NewPolyVar(radbase + Level(i)), nil
+		}
+	}
+	radlist = append(radlist, radInfo{p, k})
+	return NewPolyVar(radbase + Level(len(radlist)-1)), nil
+}
+
+// radVars は冪根 r に現れる変数. 入れ子の冪根は展開する
+func (r *radInfo) radVars(b []bool) {
+	bb := make([]bool, len(b))
+	if p, ok := r.p.(*Poly); ok {
+		p.Indets(bb)
+	}
+	for i, v := range bb {
+		if !v || b[i] {
+			continue
+		}
+		b[i] = true
+		if s := getRadical(Level(i)); s != nil {
+			s.radVars(b)
+		}
+	}
+}
+
+// hasRadical は f に冪根が現れるか
+func hasRadical(f Fof) bool {
+	n := int(f.maxVar())
+	if m := int(radbase) + len(radlist); n < m {
+		n = m
+	}
+	b := make([]bool, n)
+	f.Indets(b)
+	for i, v := range b {
+		if v && getRadical(Level(i)) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkVars は x に変数でも冪根でもないレベルがあればエラーを返す.
+// 変数順序を初期化すると, それより前に作った冪根は使えなくなる
+func checkVars(x interface{}) error {
+	p, ok := x.(interface {
+		maxVar() Level
+		Indets(b []bool)
+	})
+	if !ok {
+		return nil
+	}
+	n := p.maxVar()
+	m := radbase + Level(len(radlist))
+	if n <= m {
+		return nil
+	}
+	b := make([]bool, n)
+	p.Indets(b)
+	for i := m; i < n; i++ {
+		if b[i] {
+			return fmt.Errorf("undefined variable %s. radicals are reset by %s()", varstr(i), init_var_funcname)
+		}
+	}
+	return nil
+}
+
+// elimRadical は冪根を新しい変数で置き換え, 限量子で束縛する.
+func (qeopt *QEopt) elimRadical(fof Fof) Fof {
+	for {
+		b := make([]bool, fof.maxVar())
+		fof.Indets(b)
+		lv := Level(-1)
+		for i := len(b) - 1; i >= int(radbase); i-- {
+			if b[i] && getRadical(Level(i)) != nil {
+				lv = Level(i)
+				break
+			}
+		}
+		if lv < 0 {
+			return fof
+		}
+		r := getRadical(lv)
+		vars := make([]bool, len(b))
+		r.radVars(vars)
+		fof = qeopt.elimRadical1(fof, lv, r, vars, qeopt.new_var())
+	}
+}
+
+func (qeopt *QEopt) elimRadical1(fof Fof, lv Level, r *radInfo, vars []bool, t Level) Fof {
+	if !fof.hasVar(lv) {
+		return fof
+	}
+	if radBound(fof, lv, vars) {
+		// 冪根に現れる変数が内側で束縛されている
+		switch f := fof.(type) {
+		case FofQ:
+			return f.gen(f.Qs(), qeopt.elimRadical1(f.Fml(), lv, r, vars, t))
+		case FofAO:
+			fmls := make([]Fof, len(f.Fmls()))
+			for i, g := range f.Fmls() {
+				fmls[i] = qeopt.elimRadical1(g, lv, r, vars, t)
+			}
+			return f.gen(fmls)
+		}
+	}
+
+	// ex([t], t^k == p && t >= 0 && fof(t))
+	x := NewPolyVar(t)
+	var def Fof = NewAtom(Sub(x.Pow(NewInt(int64(r.k))), r.p), EQ)
+	if r.k%2 == 0 {
+		def = NewFmlAnd(def, NewAtom(x, GE))
+	}
+	return NewExists([]Level{t}, NewFmlAnd(def, fof.Subst(x, lv)))
+}
+
+// radBound は lv を含み, vars の変数を束縛する限量子が fof 内にあるか
+func radBound(fof Fof, lv Level, vars []bool) bool {
+	switch f := fof.(type) {
+	case FofQ:
+		if !f.Fml().hasVar(lv) {
+			return false
+		}
+		for _, q := range f.Qs() {
+			if int(q) < len(vars) && vars[q] {
+				return true
+			}
+		}
+		return radBound(f.Fml(), lv, vars)
+	case FofAO:
+		for _, g := range f.Fmls() {
+			if radBound(g, lv, vars) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package ganrac
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRadical(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"sqrt(9/4);", "3/2"},
+		{"sqrt(0);", "0"},
+		{"root(-27/8, 3);", "-3/2"},
+		{"root(x+y, 1);", "y+x"},
+		{"sqrt(2)^2;", "sqrt(2)^2"},
+		{"sqrt(x)+sqrt(x);", "2*sqrt(x)"},
+		{"sqrt(x)+root(x, 3);", "root(x, 3)+sqrt(x)"},
+		{"sqrt(x^2+y^2) <= 1;", "sqrt(y^2+x^2)-1<=0"},
+		{"root(sqrt(x)+1, 3) > y;", "root(sqrt(x)+1, 3)-y>0"},
+	} {
+		u, err := g.Eval(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		if str := strings.ReplaceAll(u.(GObj).String(), " ", ""); str != strings.ReplaceAll(s.expect, " ", "") {
+			t.Errorf("%d: input=%s: expect=%s, actual=%s", i, s.input, s.expect, str)
+		}
+	}
+
+	for i, s := range []string{
+		"sqrt(-1);",
+		"root(-4, 2);",
+		"root(x, 0);",
+		"root(x, y);",
+		"sqrt(1/x);",
+		"cadinit(sqrt(x) > 0);",
+		"A = sqrt(x) + y; " + init_var_funcname + "(x, y); A;",
+		"A = sqrt(x) > y; " + init_var_funcname + "(x, y); A;",
+	} {
+		if _, err := g.Eval(strings.NewReader(s)); err == nil {
+			t.Errorf("%d: input=%s: error is expected", i, s)
+		}
+	}
+}
+
+func TestElimRadical(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"x > 0;", "x > 0"},
+		{"sqrt(x) > y;", "ex([t_5], t_5^2-x == 0 && t_5 >= 0 && t_5-y > 0)"},
+		{"root(x, 3) > y;", "ex([t_5], t_5^3-x == 0 && t_5-y > 0)"},
+		{"all([y], sqrt(x) > y || y > 0);",
+			"ex([t_5], t_5^2-x == 0 && t_5 >= 0 && all([y], t_5-y > 0 || y > 0))"},
+		{"ex([x], sqrt(x) > y && x < 1);",
+			"ex([x], ex([t_5], t_5^2-x == 0 && t_5 >= 0 && t_5-y > 0 && x-1 < 0))"},
+		{"sqrt(sqrt(x)+1) > y;",
+			"ex([t_7], t_7^2-x == 0 && t_7 >= 0 && ex([t_6], t_7-t_6^2+1 == 0 && t_6 >= 0 && t_6-y > 0))"},
+		{"sqrt(y)+sqrt(z)-sqrt(y)-sqrt(z)+sqrt(x) > 0;", "ex([t_6], t_6^2-x == 0 && t_6 >= 0 && t_6 > 0)"},
+	} {
+		if _, err := g.Eval(strings.NewReader(init_var_funcname + "(x,y,z);")); err != nil {
+			t.Errorf("%d: err=%s", i, err)
+			return
+		}
+		u, err := g.Eval(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		f := u.(Fof)
+		n := len(varlist)
+		opt := NewQEopt()
+		opt.qe_init(g, f)
+		f = opt.elimRadical(f)
+		str := strings.ReplaceAll(f.String(), " ", "")
+		for _, v := range varlist[n:] {
+			delete(varstr2lv, v.v)
+		}
+		varlist = varlist[:n]
+		if hasRadical(f) {
+			t.Errorf("%d: input=%s: radical remains: %v", i, s.input, f)
+		}
+		if str != strings.ReplaceAll(s.expect, " ", "") {
+			t.Errorf("%d: input=%s: expect=%s, actual=%s", i, s.input, s.expect, str)
+		}
+	}
+}
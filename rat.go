@@ -3,6 +3,8 @@ package ganrac
 import (
 	"fmt"
 	"math/big"
+	"strconv"
+	"strings"
 )
 
 var brat_one = big.NewRat(1, 1)
@@ -31,6 +33,59 @@ func NewRatFrac(num, den *Int) *Rat {
 	return v
 }
 
+// ParseDecimal は 1.25, 3e-2 のような 10 進数を有理数に変換する
+func ParseDecimal(s string) (NObj, error) {
+	m := s
+	e := 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		m = s[:i]
+		e, err = strconv.Atoi(s[i+1:])
+		if err != nil || e > 10000 || e < -10000 {
+			return nil, fmt.Errorf("invalid number: %s", s)
+		}
+	}
+	if i := strings.IndexByte(m, '.'); i >= 0 {
+		e -= len(m) - i - 1
+		m = m[:i] + m[i+1:]
+	}
+	n, ok := new(big.Int).SetString(m, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid number: %s", s)
+	}
+	z := newRat()
+	if e >= 0 {
+		z.n.SetInt(n.Mul(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(e)), nil)))
+	} else {
+		z.n.SetFrac(n, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-e)), nil))
+	}
+	return z.normal().(NObj), nil
+}
+
+// decimal は x が有限小数で表せるなら 10 進表記を返す
+func (x *Rat) decimal() (string, bool) {
+	d := new(big.Int).Set(x.n.Denom())
+	k := 0
+	for _, p := range []int64{2, 5} {
+		bp := big.NewInt(p)
+		r := new(big.Int)
+		for n := 0; ; n++ {
+			q, m := new(big.Int).QuoRem(d, bp, r)
+			if m.Sign() != 0 {
+				if n > k {
+					k = n
+				}
+				break
+			}
+			d = q
+		}
+	}
+	if !d.IsInt64() || d.Int64() != 1 {
+		return "", false
+	}
+	return x.n.FloatString(k), true
+}
+
 func (x *Rat) numtag() uint {
 	return NTAG_RAT
 }
@@ -64,6 +119,8 @@ func (x *Rat) Add(yy RObj) RObj {
 	case *BinInt:
 		z := y.ToIntRat()
 		return x.Add(z)
+	case *RealAlg:
+		return y.Add(x)
 	}
 	panic("stop")
 }
@@ -80,6 +137,8 @@ func (x *Rat) Sub(yy RObj) RObj {
 		z := newRat()
 		z.n.Sub(x.n, y.n)
 		return z.normal()
+	case *RealAlg:
+		return y.Neg().Add(x)
 	}
 	panic("stop")
 }
@@ -96,6 +155,8 @@ func (x *Rat) Mul(yy RObj) RObj {
 		z := newRat()
 		z.n.Mul(x.n, y.n)
 		return z.normal()
+	case *RealAlg:
+		return y.Mul(x)
 	}
 	panic("stop")
 }
@@ -116,6 +177,8 @@ func (x *Rat) Div(yy NObj) RObj {
 		z := newRat()
 		z.n.Mul(x.n, yr)
 		return z.normal()
+	case *RealAlg:
+		return y.inv().Mul(x)
 	}
 
 	panic("stop")
@@ -167,7 +230,13 @@ func (x *Rat) Format(s fmt.State, format rune) {
 		f.Format(s, format)
 	case FORMAT_TEX:
 		fmt.Fprintf(s, "\\frac{%v}{%v}", x.n.Num(), x.n.Denom())
-	case FORMAT_DUMP:
+	case FORMAT_SMT2:
+		if x.Sign() < 0 {
+			fmt.Fprintf(s, "(- (/ %v %v))", new(big.Int).Neg(x.n.Num()), x.n.Denom())
+		} else {
+			fmt.Fprintf(s, "(/ %v %v)", x.n.Num(), x.n.Denom())
+		}
+	case FORMAT_DUMP, FORMAT_QEPCAD, FORMAT_REDLOG:
 		x.n.Num().Format(s, 'd')
 		fmt.Fprintf(s, "/")
 		x.n.Denom().Format(s, 'd')
@@ -178,6 +247,12 @@ func (x *Rat) Format(s fmt.State, format rune) {
 			fmt.Fprintf(s, "NewRatInt64(ParseInt(\"%v\", 10), ParseInt(\"%v\", 10))", x.n.Num(), x.n.Denom())
 		}
 	default:
+		if s.Flag('#') { // 10 進表記
+			if str, ok := x.decimal(); ok {
+				fmt.Fprint(s, str)
+				return
+			}
+		}
 		x.n.Num().Format(s, format)
 		fmt.Fprintf(s, "/")
 		x.n.Denom().Format(s, format)
@@ -211,6 +286,8 @@ func (z *Rat) Cmp(xx NObj) int {
 	case *BinInt:
 		xr := x.ToIntRat()
 		return z.Cmp(xr)
+	case *RealAlg:
+		return -x.Cmp(z)
 	}
 	panic(fmt.Sprintf("unknown: z=%v, x=%v", z, xx))
 }
@@ -1,6 +1,7 @@
 package ganrac
 
 import (
+	"fmt"
 	"testing"
 )
 
@@ -107,3 +108,34 @@ func TestRatIntOp2(t *testing.T) {
 		}
 	}
 }
+
+func TestRatDecimal(t *testing.T) {
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"0.25", "0.25"},
+		{"-0.0125e2", "-1.25"},
+		{"1.5e-3", "0.0015"},
+		{"12.5", "12.5"},
+	} {
+		x, err := ParseDecimal(s.input)
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		if str := fmt.Sprintf("%#v", x); str != s.expect {
+			t.Errorf("%d: input=%s: expect=%s, actual=%s", i, s.input, s.expect, str)
+		}
+	}
+
+	// 有限小数で表せない
+	if str := fmt.Sprintf("%#v", NewRatInt64(-1, 3)); str != "-1/3" {
+		t.Errorf("expect=-1/3, actual=%s", str)
+	}
+	for _, s := range []string{"1e100000", "1.2.3", "1e"} {
+		if _, err := ParseDecimal(s); err == nil {
+			t.Errorf("input=%s: error is expected", s)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package ganrac
+
+// 有理関数 num/den
+
+import (
+	"fmt"
+)
+
+// RatFunc は有理関数 num/den を表す.
+// den は定数でない原始的な整数係数多項式で, 主係数は正.
+// num/den は約分しない. 約分すると den != 0 という条件が失われるため.
+// 同じ理由で num が 0 でも 0 にしない.
+type RatFunc struct {
+	num RObj // *Poly or NObj
+	den *Poly
+}
+
+// NewRatFunc returns num/den.  den should not be zero.
+func NewRatFunc(num, den RObj) RObj {
+	switch d := den.(type) {
+	case NObj:
+		if num.IsZero() {
+			return zero
+		}
+		return num.Div(d)
+	case *RatFunc:
+		// num/(a/b) = num*b^2/(a*b). b != 0 を残す
+		return NewRatFunc(Mul(num, Mul(d.den, d.den)), Mul(d.num, d.den))
+	case *Poly:
+		if n, ok := num.(*RatFunc); ok {
+			return NewRatFunc(n.num, Mul(n.den, d))
+		}
+
+		// den = c * dp
+		dp, k := d.pp()
+		var c NObj
+		switch kk := k.(type) {
+		case *Int:
+			c = kk
+		case *Rat:
+			c = one.Div(kk).(NObj)
+		}
+		if dp.Sign() < 0 {
+			dp = dp.Neg().(*Poly)
+			c = c.Neg().(NObj)
+		}
+		if !c.IsOne() {
+			num = num.Div(c)
+		}
+		return &RatFunc{num: num, den: dp}
+	}
+	panic(fmt.Sprintf("unsupported denominator: %v", den))
+}
+
+// isZeroDivisor は r で割れないか. 0 か, 分子が 0 の有理関数
+func isZeroDivisor(r RObj) bool {
+	if rf, ok := r.(*RatFunc); ok {
+		return rf.num.IsZero()
+	}
+	return r.IsZero()
+}
+
+func (z *RatFunc) Num() RObj {
+	return z.num
+}
+
+func (z *RatFunc) Den() *Poly {
+	return z.den
+}
+
+func (z *RatFunc) Tag() uint {
+	return TAG_RATFUNC
+}
+
+func (z *RatFunc) String() string {
+	return fmt.Sprintf("%v", z)
+}
+
+// x^n なら括弧が不要
+func (z *Poly) isVarPow() bool {
+	for i := 0; i < len(z.c)-1; i++ {
+		if !z.c[i].IsZero() {
+			return false
+		}
+	}
+	return z.c[len(z.c)-1].IsOne()
+}
+
+func (z *RatFunc) Format(s fmt.State, format rune) {
+	switch format {
+	case FORMAT_DUMP:
+		fmt.Fprintf(s, "(ratfunc ")
+		z.num.Format(s, format)
+		fmt.Fprintf(s, " ")
+		z.den.Format(s, format)
+		fmt.Fprintf(s, ")")
+	case FORMAT_SRC:
+		fmt.Fprintf(s, "NewRatFunc(%S, %S)", z.num, z.den)
+	case FORMAT_TEX:
+		fmt.Fprintf(s, "\\frac{")
+		z.num.Format(s, format)
+		fmt.Fprintf(s, "}{")
+		z.den.Format(s, format)
+		fmt.Fprintf(s, "}")
+	case FORMAT_SMT2:
+		fmt.Fprintf(s, "(/ ")
+		z.num.Format(s, format)
+		fmt.Fprintf(s, " ")
+		z.den.Format(s, format)
+		fmt.Fprintf(s, ")")
+	default:
+		if p, ok := z.num.(*Poly); ok && !p.isMono() {
+			fmt.Fprintf(s, "(")
+			p.Format(s, format)
+			fmt.Fprintf(s, ")")
+		} else {
+			z.num.Format(s, format)
+		}
+		fmt.Fprintf(s, "/")
+		if z.den.isVarPow() {
+			z.den.Format(s, format)
+		} else {
+			fmt.Fprintf(s, "(")
+			z.den.Format(s, format)
+			fmt.Fprintf(s, ")")
+		}
+	}
+}
+
+func (z *RatFunc) Equals(xx interface{}) bool {
+	x, ok := xx.(*RatFunc)
+	if !ok {
+		return false
+	}
+	return Sub(Mul(z.num, x.den), Mul(x.num, z.den)).IsZero()
+}
+
+func (z *RatFunc) Add(xx RObj) RObj {
+	switch x := xx.(type) {
+	case *RatFunc:
+		if z.den.Equals(x.den) {
+			return NewRatFunc(Add(z.num, x.num), z.den)
+		}
+		return NewRatFunc(Add(Mul(z.num, x.den), Mul(x.num, z.den)), Mul(z.den, x.den))
+	default:
+		return NewRatFunc(Add(z.num, Mul(x, z.den)), z.den)
+	}
+}
+
+func (z *RatFunc) Sub(x RObj) RObj {
+	return z.Add(x.Neg())
+}
+
+func (z *RatFunc) Mul(xx RObj) RObj {
+	switch x := xx.(type) {
+	case *RatFunc:
+		return NewRatFunc(Mul(z.num, x.num), Mul(z.den, x.den))
+	default:
+		return NewRatFunc(Mul(z.num, x), z.den)
+	}
+}
+
+func (z *RatFunc) Div(x NObj) RObj {
+	return NewRatFunc(z.num.Div(x), z.den)
+}
+
+func (z *RatFunc) Pow(x *Int) RObj {
+	if x.IsZero() {
+		return &RatFunc{num: z.den, den: z.den}
+	}
+	return NewRatFunc(z.num.Pow(x), z.den.Pow(x))
+}
+
+func (z *RatFunc) Subst(x RObj, lv Level) RObj {
+	return NewRatFunc(z.num.Subst(x, lv), z.den.Subst(x, lv))
+}
+
+func (z *RatFunc) Neg() RObj {
+	return &RatFunc{num: z.num.Neg(), den: z.den}
+}
+
+func (z *RatFunc) Sign() int {
+	return z.num.Sign()
+}
+
+func (z *RatFunc) IsZero() bool {
+	return false
+}
+
+func (z *RatFunc) IsOne() bool {
+	return false
+}
+
+func (z *RatFunc) IsMinusOne() bool {
+	return false
+}
+
+func (z *RatFunc) IsNumeric() bool {
+	return false
+}
+
+func (z *RatFunc) valid() error {
+	if z.num == nil || z.den == nil {
+		return fmt.Errorf("ratfunc: null")
+	}
+	if z.den.Sign() <= 0 {
+		return fmt.Errorf("ratfunc: leading coefficient of denominator should be positive")
+	}
+	if err := z.num.valid(); err != nil {
+		return err
+	}
+	return z.den.valid()
+}
+
+func (z *RatFunc) mul_2exp(m uint) RObj {
+	return &RatFunc{num: z.num.mul_2exp(m), den: z.den}
+}
+
+func (z *RatFunc) toIntv(prec uint) RObj {
+	return &RatFunc{num: z.num.toIntv(prec), den: z.den.toIntv(prec).(*Poly)}
+}
+
+func (z *RatFunc) Indets(b []bool) {
+	if p, ok := z.num.(*Poly); ok {
+		p.Indets(b)
+	}
+	z.den.Indets(b)
+}
+
+func (z *RatFunc) maxVar() Level {
+	lv := z.den.lv
+	if p, ok := z.num.(*Poly); ok && p.lv > lv {
+		lv = p.lv
+	}
+	return lv + 1
+}
+
+// atom は num/den op 0 と同値な多項式の論理式を返す
+func (z *RatFunc) atom(op OP) Fof {
+	nd := Mul(z.num, z.den)
+	switch op {
+	case LT, GT, NE:
+		return NewAtom(nd, op)
+	case EQ:
+		return NewFmlAnd(NewAtom(z.num, EQ), NewAtom(z.den, NE))
+	case LE, GE:
+		return NewFmlAnd(NewAtom(nd, op), NewAtom(z.den, NE))
+	}
+	return NewBool(op == OP_TRUE)
+}
@@ -0,0 +1,102 @@
+package ganrac
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRatFunc(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"x/(x+1);", "x/(x+1)"},
+		{"x/(x+1) + 1/(x+1);", "(x+1)/(x+1)"},
+		{"x/(x+1) * (x+1);", "(x^2+x)/(x+1)"},
+		{"x/(x+1) - 1;", "-1/(x+1)"},
+		{"(x^2-1)/(x-1);", "(x^2-1)/(x-1)"},
+		{"(x+y)/(-2*x+4);", "(-1/2*y-1/2*x)/(x-2)"},
+		{"1/(x*y);", "1/(x*y)"},
+		{"(1/x)/(1/y);", "y^2/(x*y)"},
+		{"(x/y)^2;", "x^2/y^2"},
+		{"x^(-2);", "1/x^2"},
+		{"2^(-3);", "1/8"},
+		{"subst(x/(x+1), x, y/2);", "y/(y+2)"},
+		{"subst(x/(x+1), x, 1);", "1/2"},
+	} {
+		u, err := g.Eval(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		if fmt.Sprintf("%v", u) != s.expect {
+			t.Errorf("%d: input=%s: expect=%s, actual=%v", i, s.input, s.expect, u)
+		}
+		if r, ok := u.(*RatFunc); ok {
+			if err := r.valid(); err != nil {
+				t.Errorf("%d: input=%s: invalid %v", i, s.input, err)
+			}
+			// 出力を読み込むと元にもどる
+			v, err := g.Eval(strings.NewReader(s.expect + ";"))
+			if err != nil || !r.Equals(v) {
+				t.Errorf("%d: input=%s: reread %v, err=%v", i, s.input, v, err)
+			}
+		}
+	}
+
+	for i, s := range []string{
+		"x/0;",
+		"x/(x-x);",
+		"x/(1/x-1/x);",
+		"(1/x-1/x)^(-1);",
+		"0^(-1);",
+		"subst(x/(x+1), x, -1);",
+	} {
+		if _, err := g.Eval(strings.NewReader(s)); err == nil {
+			t.Errorf("%d: input=%s: error is expected", i, s)
+		}
+	}
+}
+
+func TestRatFuncAtom(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"x/y > 0;", "x*y > 0"},
+		{"x/y < 0;", "x*y < 0"},
+		{"x/y >= 0;", "x*y >= 0 && y != 0"},
+		{"x/y <= 0;", "x*y <= 0 && y != 0"},
+		{"x/y == 0;", "x == 0 && y != 0"},
+		{"x/y != 0;", "x*y != 0"},
+		{"x/(y+1) > 1/(y-1);", "(x*(y-1)-(y+1))*(y+1)*(y-1) > 0"},
+		{"1/x <= x;", "(1-x^2)*x <= 0 && x != 0"},
+		{"ex([x], 1/x > 1);", "ex([x], x-x^2 > 0)"},
+		{"subst(y > x, y, 1/x);", "x*(1-x^2) > 0"},
+		// 約分できても分母 != 0 は残る
+		{"x/x > 0;", "x^2 > 0"},
+		{"x^2/x <= 0;", "x^3 <= 0 && x != 0"},
+		{"(x^2-1)/(x-1) != 0;", "(x^2-1)*(x-1) != 0"},
+		{"(1/x)*x == 1;", "x != 0"},
+		{"1/x-1/x == 0;", "x != 0"},
+		{"1/(1/x) > 0;", "x^3 > 0"},
+	} {
+		u, err := g.Eval(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		v, err := g.Eval(strings.NewReader(s.expect + ";"))
+		if err != nil {
+			t.Errorf("%d: expect=%s: err=%s", i, s.expect, err)
+			continue
+		}
+		f, ok := u.(Fof)
+		if !ok || !f.Equals(v) {
+			t.Errorf("%d: input=%s: expect=%v, actual=%v", i, s.input, v, u)
+		}
+	}
+}
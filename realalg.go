@@ -0,0 +1,733 @@
+package ganrac
+
+// 実代数的数.
+// 定義多項式と分離区間で表す.
+// 有理数になる場合は *Int, *Rat で表し, RealAlg は無理数のみを表す.
+// 演算は終結式で定義多項式を求め, 区間演算で根を選ぶ.
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// RealAlg は p の開区間 (low, low+2^m) にある唯一の実根.
+type RealAlg struct {
+	p   *Poly   // 無平方で原始的な整数係数の1変数多項式 (level 0)
+	low *BinInt // 端点は p の零点ではない
+	sgn int     // p(low) の符号
+	Number
+}
+
+func (x *RealAlg) numTag() uint {
+	return NTAG_REALALG
+}
+
+// uniLv は1変数多項式 p の変数を lv に変えたものを返す
+func uniLv(p *Poly, lv Level) *Poly {
+	if p.lv == lv {
+		return p
+	}
+	q := p.Clone()
+	q.lv = lv
+	return q
+}
+
+// uniGcd は1変数多項式 f, g の最大公約因子を返す.
+// 定数の場合は one
+func uniGcd(f, g *Poly) RObj {
+	if len(f.c) < len(g.c) {
+		f, g = g, f
+	}
+	f, _ = f.pp()
+	g, _ = g.pp()
+	for {
+		_, _, r := f.pquorem(g)
+		rp, ok := r.(*Poly)
+		if !ok {
+			if !r.IsZero() {
+				return one
+			}
+			if g.Sign() < 0 {
+				return g.Neg()
+			}
+			return g
+		}
+		f = g
+		g, _ = rp.pp()
+	}
+}
+
+// uniSqfree は1変数多項式 p の無平方部分を整数係数の原始多項式で返す
+func uniSqfree(p *Poly) *Poly {
+	p, _ = p.pp()
+	if p.Sign() < 0 {
+		p = p.Neg().(*Poly)
+	}
+	if len(p.c) == 2 {
+		return p
+	}
+	g, ok := uniGcd(p, p.diff(p.lv).(*Poly)).(*Poly)
+	if !ok {
+		return p
+	}
+	q, _ := divExact(p, g).(*Poly).pp()
+	if q.Sign() < 0 {
+		q = q.Neg().(*Poly)
+	}
+	return q
+}
+
+// divExact は x/y を返す. y は x を割り切る.
+func divExact(x, y RObj) RObj {
+	switch yy := y.(type) {
+	case NObj:
+		return x.Div(yy)
+	case *Poly:
+		if xx, ok := x.(*Poly); ok {
+			return xx.sdiv(yy)
+		} else if x.IsZero() {
+			return x
+		}
+	}
+	panic(fmt.Sprintf("divExact: x=%v, y=%v", x, y))
+}
+
+// resultant は主変数が同じ f, g の終結式を返す.
+// 部分終結式 PRS による.
+func (f *Poly) resultant(g *Poly) RObj {
+	s := 1
+	if len(f.c) < len(g.c) {
+		f, g = g, f
+		if f.deg()%2 == 1 && g.deg()%2 == 1 {
+			s = -1
+		}
+	}
+	var gc, h RObj = one, one
+	a := f
+	b := g
+	for {
+		d := int64(len(a.c) - len(b.c))
+		if a.deg()%2 == 1 && b.deg()%2 == 1 {
+			s = -s
+		}
+		_, _, r := a.pquorem(b)
+		a = b
+		r = divExact(r, Mul(gc, h.Pow(NewInt(d))))
+		gc = a.lc()
+		if d == 1 {
+			h = gc
+		} else if d > 1 {
+			h = divExact(gc.Pow(NewInt(d)), h.Pow(NewInt(d-1)))
+		}
+		rp, ok := r.(*Poly)
+		if !ok || rp.lv != a.lv {
+			if r.IsZero() {
+				return zero
+			}
+			n := int64(a.deg())
+			h = divExact(r.Pow(NewInt(n)), h.Pow(NewInt(n-1)))
+			if s < 0 {
+				return h.Neg()
+			}
+			return h
+		}
+		b = rp
+	}
+}
+
+// simplestRat は閉区間 [lo, hi] に含まれる分母が最小の有理数を返す
+func simplestRat(lo, hi *big.Rat) *big.Rat {
+	if lo.Sign() <= 0 && hi.Sign() >= 0 {
+		return new(big.Rat)
+	}
+	if hi.Sign() < 0 {
+		r := simplestRat(new(big.Rat).Neg(hi), new(big.Rat).Neg(lo))
+		return r.Neg(r)
+	}
+	fl := new(big.Int).Quo(lo.Num(), lo.Denom())
+	if lo.IsInt() {
+		return new(big.Rat).SetInt(fl)
+	}
+	r := new(big.Rat).SetInt(fl.Add(fl, one.n))
+	if r.Cmp(hi) <= 0 {
+		return r
+	}
+	// fl < lo <= hi < fl+1
+	r.SetInt(fl.Sub(fl, one.n))
+	l := new(big.Rat).Sub(hi, r)
+	u := new(big.Rat).Sub(lo, r)
+	s := simplestRat(l.Inv(l), u.Inv(u))
+	return s.Add(s.Inv(s), r)
+}
+
+// simplestRatOpen は開区間 (lo, hi) に含まれる分母が最小の有理数を返す.
+// hi が nil なら (lo, inf)
+func simplestRatOpen(lo, hi *big.Rat) *big.Rat {
+	if hi != nil && lo.Sign() < 0 && hi.Sign() > 0 {
+		return new(big.Rat)
+	}
+	if hi != nil && hi.Sign() <= 0 {
+		r := simplestRatOpen(new(big.Rat).Neg(hi), new(big.Rat).Neg(lo))
+		return r.Neg(r)
+	}
+	if hi == nil && lo.Sign() < 0 {
+		return new(big.Rat)
+	}
+	// 0 <= lo
+	fl := new(big.Int).Quo(lo.Num(), lo.Denom())
+	r := new(big.Rat).SetInt(new(big.Int).Add(fl, one.n))
+	if hi == nil || r.Cmp(hi) < 0 {
+		return r
+	}
+	// fl <= lo < hi <= fl+1
+	r.SetInt(fl)
+	l := new(big.Rat).Sub(hi, r)
+	l.Inv(l)
+	var u *big.Rat
+	if lo.Cmp(r) != 0 {
+		u = new(big.Rat).Sub(lo, r)
+		u.Inv(u)
+	}
+	s := simplestRatOpen(l, u)
+	return s.Add(s.Inv(s), r)
+}
+
+func toBigRat(x NObj) *big.Rat {
+	switch c := x.(type) {
+	case *Int:
+		return new(big.Rat).SetInt(c.n)
+	case *Rat:
+		return c.n
+	case *BinInt:
+		return toBigRat(c.ToIntRat())
+	}
+	panic(fmt.Sprintf("unsupported: %v", x))
+}
+
+func newRatBig(r *big.Rat) NObj {
+	z := newRat()
+	z.n.Set(r)
+	return z.normal().(NObj)
+}
+
+// newRealAlgDcsr は realRootIsolation() で分離した q の根を返す
+func newRealAlgDcsr(q *Poly, r *dcsr) NObj {
+	if r.point {
+		return toNObj(r.low)
+	}
+	x := &RealAlg{p: q, low: r.low, sgn: r.m}
+	return x.normal()
+}
+
+// normal は x が有理数ならその値を返す.
+func (x *RealAlg) normal() NObj {
+	if len(x.p.c) == 2 {
+		return x.p.c[0].Neg().(NObj).Div(x.p.c[1].(NObj)).(NObj)
+	}
+
+	// 有理根 a/b は b | lc(p) なので, 幅が 1/lc(p)^2 未満の区間には
+	// 高々 1 つしかない. それは区間内で分母が最小の有理数.
+	m := -2*x.p.lc().(*Int).n.BitLen() - 1
+	for x.low.m > m {
+		mid := x.low.midBinIntv()
+		s := x.p.Subst(mid, x.p.lv).Sign()
+		if s == 0 {
+			return toNObj(mid)
+		} else if s == x.sgn {
+			x.low = mid
+		} else {
+			x.low = x.low.halveIntv()
+		}
+	}
+	r := newRatBig(simplestRat(toBigRat(x.low), toBigRat(x.upper())))
+	if x.p.Subst(r, x.p.lv).IsZero() {
+		return r
+	}
+	return x
+}
+
+func (x *RealAlg) upper() *BinInt {
+	return x.low.upperBound()
+}
+
+// refine は分離区間を半分にする
+func (x *RealAlg) refine() {
+	mid := x.low.midBinIntv()
+	s := x.p.Subst(mid, x.p.lv).Sign()
+	if s == x.sgn {
+		x.low = mid
+	} else if s != 0 {
+		x.low = x.low.halveIntv()
+	} else {
+		panic(fmt.Sprintf("realalg: rational root %v of %v", mid, x.p))
+	}
+}
+
+// Refine は分離区間の幅が 2^m 以下になるまで狭める
+func (x *RealAlg) Refine(m int) {
+	for x.low.m > m {
+		x.refine()
+	}
+}
+
+// Interval は分離区間の端点を返す
+func (x *RealAlg) Interval() (NObj, NObj) {
+	return toNObj(x.low), toNObj(x.upper())
+}
+
+func toNObj(x *BinInt) NObj {
+	if r, ok := x.ToIntRat().(*Rat); ok {
+		return r.normal().(NObj)
+	}
+	return x.ToIntRat()
+}
+
+// hasOtherVar は o に lv 以外の変数が現れるか
+func hasOtherVar(o GObj, lv Level) bool {
+	p, ok := o.(indeter)
+	if !ok {
+		return false
+	}
+	b := make([]bool, int(radbase)+len(radlist))
+	p.Indets(b)
+	for i, v := range b {
+		if v && Level(i) != lv {
+			return true
+		}
+	}
+	return false
+}
+
+// DefPoly は定義多項式を返す
+func (x *RealAlg) DefPoly() *Poly {
+	return x.p
+}
+
+// realRoots は無平方な整数係数の1変数多項式 p の実根を小さい順に返す
+func (p *Poly) realRoots() []NObj {
+	if len(p.c) == 2 {
+		return []NObj{p.c[0].Neg().(NObj).Div(p.c[1].(NObj)).(NObj)}
+	}
+	rs := p.realRootIsolation(0)
+	ret := make([]NObj, len(rs))
+	for i, r := range rs {
+		ret[i] = newRealAlgDcsr(p, r)
+	}
+	return ret
+}
+
+// RealRoots は1変数多項式 p の実根を小さい順に返す.
+// 無理数は *RealAlg, 有理数は *Int か *Rat で表す.
+func (p *Poly) RealRoots() ([]NObj, error) {
+	if !p.isUnivariate() {
+		return nil, fmt.Errorf("not a univariate polynomial")
+	}
+	return uniSqfree(uniLv(p, 0)).realRoots(), nil
+}
+
+// NewRealAlg は p の開区間 (lo, hi) にある唯一の実根を返す.
+func NewRealAlg(p *Poly, lo, hi NObj) (NObj, error) {
+	rs, err := p.RealRoots()
+	if err != nil {
+		return nil, err
+	}
+	var ret NObj
+	for _, r := range rs {
+		if r.Cmp(lo) > 0 && r.Cmp(hi) < 0 {
+			if ret != nil {
+				return nil, fmt.Errorf("more than one real root in (%v, %v)", lo, hi)
+			}
+			ret = r
+		}
+	}
+	if ret == nil {
+		return nil, fmt.Errorf("no real root in (%v, %v)", lo, hi)
+	}
+	return ret, nil
+}
+
+// intvMul は [a, b] * [c, d] を返す
+func intvMul(a, b, c, d NObj) (NObj, NObj) {
+	lo := Mul(a, c).(NObj)
+	hi := lo
+	for _, v := range []RObj{Mul(a, d), Mul(b, c), Mul(b, d)} {
+		if v.(NObj).Cmp(lo) < 0 {
+			lo = v.(NObj)
+		} else if v.(NObj).Cmp(hi) > 0 {
+			hi = v.(NObj)
+		}
+	}
+	return lo, hi
+}
+
+// hornerIntv は区間 [lo, hi] での1変数多項式 q の値を含む区間を返す
+func hornerIntv(q *Poly, lo, hi NObj) (NObj, NObj) {
+	a := q.c[len(q.c)-1].(NObj)
+	b := a
+	for i := len(q.c) - 2; i >= 0; i-- {
+		a, b = intvMul(a, b, lo, hi)
+		a = Add(a, q.c[i]).(NObj)
+		b = Add(b, q.c[i]).(NObj)
+	}
+	return a, b
+}
+
+// algop は Res_y(p(y), g(x, y)) の根のうち, enc() の区間にあるものを返す.
+// x, y の分離区間を狭めながら 1 つに絞る.
+func (x *RealAlg) algop(g *Poly, y *RealAlg, enc func() (NObj, NObj)) NObj {
+	r := uniLv(x.p, 1).resultant(g).(*Poly)
+	q := uniSqfree(r)
+	if len(q.c) == 2 {
+		return q.realRoots()[0]
+	}
+	rs := q.realRootIsolation(0)
+	for {
+		lo, hi := enc()
+		var c *dcsr
+		n := 0
+		for _, r := range rs {
+			if r.low.Cmp(hi) <= 0 && r.upperBound().Cmp(lo) >= 0 {
+				c = r
+				n++
+			}
+		}
+		if n == 1 {
+			return newRealAlgDcsr(q, c)
+		}
+		x.refine()
+		if y != nil {
+			y.refine()
+		}
+		for _, r := range rs {
+			realRootImprove(q, r)
+		}
+	}
+}
+
+// checkRealAlg は x と y の演算ができるか調べる.
+// 実代数的数は有理数と実代数的数としか演算できない
+func checkRealAlg(x, y RObj) error {
+	for _, p := range [][2]RObj{{x, y}, {y, x}} {
+		if _, ok := p[0].(*RealAlg); ok {
+			switch p[1].(type) {
+			case *Int, *Rat, *BinInt, *RealAlg:
+			default:
+				return fmt.Errorf("a real algebraic number can not be combined with %v", p[1])
+			}
+		}
+	}
+	return nil
+}
+
+// evalPoly は1変数多項式 q の x での値を返す
+func (x *RealAlg) evalPoly(q *Poly) NObj {
+	// Res_y(p(y), x - q(y))
+	g, _ := Sub(NewPolyVar(0), uniLv(q, 1)).(*Poly).pp()
+	return x.algop(g, nil, func() (NObj, NObj) {
+		lo, hi := x.Interval()
+		return hornerIntv(q, lo, hi)
+	})
+}
+
+// signPoly は1変数多項式 f の x での符号を返す
+func (x *RealAlg) signPoly(f *Poly) int {
+	f = uniLv(f, 0)
+	if g, ok := uniGcd(x.p, f).(*Poly); ok {
+		// g は p の因子なので, 分離区間で高々 1 つの根を持つ
+		if g.Subst(x.low, 0).Sign()*g.Subst(x.upper(), 0).Sign() < 0 {
+			return 0
+		}
+	}
+	for {
+		lo, hi := x.Interval()
+		a, b := hornerIntv(f, lo, hi)
+		if a.Sign() > 0 {
+			return 1
+		} else if b.Sign() < 0 {
+			return -1
+		}
+		x.refine()
+	}
+}
+
+// inv は 1/x を返す
+func (x *RealAlg) inv() NObj {
+	x.Sign()
+	g := NewPolyCoef(1, -1, NewPolyVar(0)) // xy-1
+	return x.algop(g, nil, func() (NObj, NObj) {
+		for x.low.IsZero() || x.upper().IsZero() {
+			x.refine()
+		}
+		lo, hi := x.Interval()
+		return one.Div(hi).(NObj), one.Div(lo).(NObj)
+	})
+}
+
+func (x *RealAlg) Equals(yy interface{}) bool {
+	y, ok := yy.(NObj)
+	return ok && x.Cmp(y) == 0
+}
+
+func (x *RealAlg) String() string {
+	return fmt.Sprintf("%v", x)
+}
+
+func (x *RealAlg) Format(s fmt.State, format rune) {
+	switch format {
+	case 'e', 'E', 'f', 'F', 'g', 'G':
+		w, ok := s.Precision()
+		if !ok {
+			w = 6
+		}
+		// 10 進 w 桁は約 3.33*w ビット
+		bits := int(math.Ceil(3.33*float64(w))) + 10
+		y := *x
+		m := -bits
+		if format != 'f' && format != 'F' {
+			// 有効桁数なので, 0 に近い端点の指数だけずらす
+			for y.low.Sign() <= 0 && y.upper().Sign() >= 0 {
+				y.refine()
+			}
+			lo := y.low
+			if lo.Sign() < 0 {
+				lo = y.upper()
+			}
+			m += new(big.Int).Abs(lo.n).BitLen() + lo.m
+		}
+		y.Refine(m)
+		prec := uint(y.low.n.BitLen())
+		if prec < 64 {
+			prec = 64
+		}
+		f := new(big.Float).SetPrec(prec).SetInt(y.low.n)
+		f.SetMantExp(f, y.low.m)
+		f.Format(s, format)
+	case FORMAT_DUMP:
+		fmt.Fprintf(s, "(realalg ")
+		x.p.Format(s, format)
+		fmt.Fprintf(s, " ")
+		x.low.Format(s, format)
+		fmt.Fprintf(s, ")")
+	default:
+		lo, hi := x.Interval()
+		fmt.Fprintf(s, "realalg(")
+		x.p.Format(s, format)
+		fmt.Fprintf(s, ", ")
+		lo.Format(s, format)
+		fmt.Fprintf(s, ", ")
+		hi.Format(s, format)
+		fmt.Fprintf(s, ")")
+	}
+}
+
+func (x *RealAlg) Add(yy RObj) RObj {
+	switch y := yy.(type) {
+	case *RealAlg:
+		// Res_y(p(y), q(x-y))
+		t := NewPolyCoef(1, NewPolyVar(0), -1)
+		return x.algop(y.p.Subst(t, 0).(*Poly), y, func() (NObj, NObj) {
+			a, b := x.Interval()
+			c, d := y.Interval()
+			return Add(a, c).(NObj), Add(b, d).(NObj)
+		})
+	case *BinInt:
+		return x.Add(y.ToIntRat())
+	case *Int, *Rat:
+		if y.IsZero() {
+			return x
+		}
+		return x.evalPoly(NewPolyCoef(0, y, 1))
+	}
+	panic(fmt.Sprintf("realalg: add %v", yy))
+}
+
+func (x *RealAlg) Sub(yy RObj) RObj {
+	return x.Add(yy.Neg())
+}
+
+func (x *RealAlg) Mul(yy RObj) RObj {
+	switch y := yy.(type) {
+	case *RealAlg:
+		// Res_y(p(y), y^n q(x/y))
+		n := len(y.p.c) - 1
+		var g RObj = zero
+		for i, c := range y.p.c {
+			var t RObj = c
+			if i > 0 {
+				t = Mul(t, newPolyVarn(0, i))
+			}
+			if i < n {
+				t = Mul(t, newPolyVarn(1, n-i))
+			}
+			g = Add(g, t)
+		}
+		return x.algop(g.(*Poly), y, func() (NObj, NObj) {
+			a, b := x.Interval()
+			c, d := y.Interval()
+			return intvMul(a, b, c, d)
+		})
+	case *BinInt:
+		return x.Mul(y.ToIntRat())
+	case *Int, *Rat:
+		if y.IsZero() {
+			return zero
+		} else if y.IsOne() {
+			return x
+		}
+		return x.evalPoly(NewPolyCoef(0, 0, y))
+	}
+	panic(fmt.Sprintf("realalg: mul %v", yy))
+}
+
+func (x *RealAlg) Div(yy NObj) RObj {
+	switch y := yy.(type) {
+	case *RealAlg:
+		return x.Mul(y.inv())
+	case *BinInt:
+		return x.Div(y.ToIntRat())
+	case *Int, *Rat:
+		return x.Mul(one.Div(y.(NObj)))
+	}
+	panic(fmt.Sprintf("realalg: div %v", yy))
+}
+
+func (x *RealAlg) Pow(y *Int) RObj {
+	if y.Sign() < 0 {
+		return x.inv().Pow(y.Neg().(*Int))
+	} else if y.IsZero() {
+		return one
+	} else if y.IsOne() {
+		return x
+	} else if !y.IsInt64() {
+		panic("realalg: too large exponent")
+	}
+	return x.evalPoly(newPolyVarn(0, int(y.Int64())))
+}
+
+func (x *RealAlg) Subst(y RObj, lv Level) RObj {
+	return x
+}
+
+func (x *RealAlg) Neg() RObj {
+	// p(-x) の (-up, -low) にある根
+	z := new(RealAlg)
+	z.p = x.p.Subst(NewPolyCoef(0, 0, -1), 0).(*Poly)
+	z.low = x.upper().Neg().(*BinInt)
+	z.sgn = -x.sgn
+	if z.p.Sign() < 0 {
+		z.p = z.p.Neg().(*Poly)
+		z.sgn = -z.sgn
+	}
+	return z
+}
+
+func (x *RealAlg) Sign() int {
+	for {
+		if x.low.Sign() >= 0 {
+			return 1
+		} else if x.upper().Sign() <= 0 {
+			return -1
+		}
+		x.refine()
+	}
+}
+
+func (x *RealAlg) IsZero() bool {
+	return false
+}
+
+func (x *RealAlg) IsOne() bool {
+	return false
+}
+
+func (x *RealAlg) IsMinusOne() bool {
+	return false
+}
+
+func (x *RealAlg) valid() error {
+	if x.p == nil || x.p.lv != 0 || !x.p.isIntPoly() || len(x.p.c) < 3 {
+		return fmt.Errorf("realalg: invalid defining polynomial: %v", x.p)
+	}
+	s := x.p.Subst(x.low, 0).Sign()
+	if s == 0 || s != x.sgn || x.p.Subst(x.upper(), 0).Sign() != -s {
+		return fmt.Errorf("realalg: invalid isolating interval: %v", x)
+	}
+	return nil
+}
+
+func (x *RealAlg) Cmp(yy NObj) int {
+	switch y := yy.(type) {
+	case *RealAlg:
+		var g RObj
+		for {
+			if x.upper().Cmp(y.low) <= 0 {
+				return -1
+			} else if y.upper().Cmp(x.low) <= 0 {
+				return 1
+			}
+			if g == nil {
+				g = uniGcd(x.p, y.p)
+			}
+			if gp, ok := g.(*Poly); ok {
+				// 区間の共通部分に gcd の根があれば等しい
+				lo, hi := x.low, x.upper()
+				if lo.Cmp(y.low) < 0 {
+					lo = y.low
+				}
+				if hi.Cmp(y.upper()) > 0 {
+					hi = y.upper()
+				}
+				if gp.Subst(lo, 0).Sign()*gp.Subst(hi, 0).Sign() < 0 {
+					return 0
+				}
+			}
+			x.refine()
+			y.refine()
+		}
+	case *Int, *Rat, *BinInt:
+		for {
+			if y.Cmp(x.low) <= 0 {
+				return 1
+			} else if y.Cmp(x.upper()) >= 0 {
+				return -1
+			}
+			x.refine()
+		}
+	}
+	panic(fmt.Sprintf("realalg: cmp %v", yy))
+}
+
+func (x *RealAlg) CmpAbs(y NObj) int {
+	return x.Abs().Cmp(y.Abs())
+}
+
+func (x *RealAlg) Abs() NObj {
+	if x.Sign() < 0 {
+		return x.Neg().(NObj)
+	}
+	return x
+}
+
+func (x *RealAlg) Float() float64 {
+	y := *x
+	for y.low.n.BitLen() < 60 && y.upper().n.BitLen() < 60 {
+		y.refine()
+	}
+	return y.low.Float()
+}
+
+func (x *RealAlg) subst_poly(p *Poly, lv Level) RObj {
+	return p.Subst(x, lv)
+}
+
+func (x *RealAlg) mul_2exp(m uint) RObj {
+	return x.Mul(one.mul_2exp(m))
+}
+
+func (x *RealAlg) toIntv(prec uint) RObj {
+	x.Refine(-int(prec))
+	z := x.low.toIntv(prec).(*Interval)
+	z.sup = x.upper().toIntv(prec).(*Interval).sup
+	return z
+}
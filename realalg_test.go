@@ -0,0 +1,204 @@
+package ganrac
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestRealAlg(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"A = realalg(x^2-2, 1, 2);", "realalg(x^2-2, 11/8, 3/2)"},
+		{"B = realalg(x^2-3, 1, 2);", "realalg(x^2-3, 13/8, 7/4)"},
+		{"realalg(2*x^2-4, 0, 5) == A;", "true"},
+		{"A^2;", "2"},
+		{"A*A-2;", "0"},
+		{"A-A;", "0"},
+		{"A*B;", "realalg(x^2-6, 19/8, 5/2)"},
+		{"A+B;", "realalg(x^4-10*x^2+1, 25/8, 13/4)"},
+		{"A-B;", "realalg(x^4-10*x^2+1, -3/8, -1/4)"},
+		{"(A+B)^2 == 5+2*A*B;", "true"},
+		{"1/A == A/2;", "true"},
+		{"A/B;", "realalg(3*x^2-2, 13/16, 27/32)"},
+		{"-A;", "realalg(x^2-2, -3/2, -11/8)"},
+		{"A < B;", "true"},
+		{"A > 1.4142;", "true"},
+		{"A < 1.4143;", "true"},
+		{"realroot(x^2-2)[0] == -A;", "true"},
+		{"realroot((x^2-2)^2*(3*x-1))[1];", "1/3"},
+		{"len(realroot(x^3-2, -10));", "1"},
+		{"subst(x^2-2*x-1, x, A);", "realalg(x^2-2*x-7, -2, -1)"},
+		{"subst(x^3-2*x, x, A);", "0"},
+		{"subst(x^3-2*x+1 > 0 && x^2 < 3, x, A);", "true"},
+		{"subst([x^2, x+1], x, A);", "[2, realalg(x^2-2*x-1, 19/8, 5/2)]"},
+	} {
+		u, err := g.Eval(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		v, err := g.Eval(strings.NewReader(s.expect + ";"))
+		if err != nil {
+			t.Errorf("%d: expect=%s: err=%s", i, s.expect, err)
+			continue
+		}
+		if str := u.(GObj).String(); str != v.(GObj).String() {
+			t.Errorf("%d: input=%s: expect=%s, actual=%s", i, s.input, s.expect, str)
+		}
+	}
+
+	for i, s := range []string{
+		"realalg(x^2-2, 2, 1);",
+		"realalg(x^2-2, -2, 2);",
+		"realalg(x^2-2, 2, 3);",
+		"realalg(x*y-2, 1, 2);",
+		"subst(x+y, x, realalg(x^2-2, 1, 2));",
+		// 多項式や区間とは演算できない
+		"A = realalg(x^2-2, 1, 2); B = A*x;",
+		"A = realalg(x^2-2, 1, 2); A + intv(1, 2);",
+		"A = realalg(x^2-2, 1, 2); x - A > 0;",
+		"A = realalg(x^2-2, 1, 2); A < sqrt(2);",
+		"A = realalg(x^2-2, 1, 2); 0 < x < A;",
+		"A = realalg(x^2-2, 1, 2); x/A;",
+		"A = realalg(x^2-2, 1, 2); max(1, x, A);",
+	} {
+		if _, err := g.Eval(strings.NewReader(s)); err == nil {
+			t.Errorf("%d: input=%s: error is expected", i, s)
+		}
+	}
+}
+
+func TestResultant(t *testing.T) {
+	x := NewPolyVar(0)
+	for i, s := range []struct {
+		f, g   *Poly
+		expect RObj
+	}{
+		{
+			NewPolyCoef(1, -2, 0, 1), // y^2-2
+			NewPolyCoef(1, x, -1),    // x-y
+			NewPolyCoef(0, -2, 0, 1), // x^2-2
+		}, {
+			NewPolyCoef(1, -2, 0, 1), // y^2-2
+			NewPolyCoef(1, NewPolyCoef(0, -3, 0, 1), NewPolyCoef(0, 0, -2), 1), // (x-y)^2-3
+			NewPolyCoef(0, 1, 0, -10, 0, 1),
+		}, {
+			NewPolyCoef(1, -1, 0, 1), // y^2-1
+			NewPolyCoef(1, 1, 1),     // y+1
+			zero,
+		},
+	} {
+		r := s.f.resultant(s.g)
+		if !r.Equals(s.expect) && !r.Neg().Equals(s.expect) {
+			t.Errorf("%d: f=%v, g=%v: expect=%v, actual=%v", i, s.f, s.g, s.expect, r)
+		}
+	}
+}
+
+func TestSimplestRat(t *testing.T) {
+	for i, s := range []struct {
+		lo, hi, expect string
+	}{
+		{"1/3", "1/2", "1/2"},
+		{"-1/2", "1/3", "0"},
+		{"11/8", "3/2", "3/2"},
+		{"11/8", "23/16", "7/5"},
+		{"-23/16", "-11/8", "-7/5"},
+		{"5/2", "7/2", "3"},
+	} {
+		lo, _ := new(big.Rat).SetString(s.lo)
+		hi, _ := new(big.Rat).SetString(s.hi)
+		r := simplestRat(lo, hi)
+		if r.RatString() != s.expect {
+			t.Errorf("%d: [%s, %s]: expect=%s, actual=%s", i, s.lo, s.hi, s.expect, r.RatString())
+		}
+	}
+
+	for i, s := range []struct {
+		lo, hi, expect string
+	}{
+		{"1/3", "1/2", "2/5"},
+		{"-1/2", "1/3", "0"},
+		{"0", "1", "1/2"},
+		{"0", "1/3", "1/4"},
+		{"2", "3", "5/2"},
+		{"2", "4", "3"},
+		{"-3", "-2", "-5/2"},
+		{"12345/4096", "12346/4096", "214/71"},
+		{"3/2", "", "2"},
+		{"-3/2", "", "0"},
+		{"-7", "", "0"},
+		{"2", "", "3"},
+	} {
+		lo, _ := new(big.Rat).SetString(s.lo)
+		var hi *big.Rat
+		if s.hi != "" {
+			hi, _ = new(big.Rat).SetString(s.hi)
+		}
+		r := simplestRatOpen(lo, hi)
+		if r.RatString() != s.expect {
+			t.Errorf("%d: (%s, %s): expect=%s, actual=%s", i, s.lo, s.hi, s.expect, r.RatString())
+		}
+	}
+}
+
+func TestRealAlgPolyFormat(t *testing.T) {
+	a, err := NewRealAlg(NewPolyInts(0, -2, 0, 1), NewInt(1), NewInt(2))
+	if err != nil {
+		t.Fatalf("realalg: %v", err)
+	}
+	ra := a.(*RealAlg)
+	p := NewPoly(0, 2)
+	p.c[0] = ra.Neg()
+	p.c[1] = one
+	q := NewPoly(0, 2)
+	q.c[0] = zero
+	q.c[1] = ra
+	for i, s := range []struct {
+		p      *Poly
+		expect string
+	}{
+		{p, fmt.Sprintf("x+(%v)", ra.Neg())},
+		{q, fmt.Sprintf("(%v)*x", ra)},
+	} {
+		if str := s.p.String(); str != s.expect {
+			t.Errorf("%d: expect=%s, actual=%s", i, s.expect, str)
+		}
+		if s.p.valid() == nil {
+			t.Errorf("%d: %v: error is expected", i, s.p)
+		}
+	}
+}
+
+func TestRealAlgFloatFormat(t *testing.T) {
+	sqrt2, err := NewRealAlg(NewPolyInts(0, -2, 0, 1), NewInt(1), NewInt(2))
+	if err != nil {
+		t.Fatalf("realalg: %v", err)
+	}
+	// 1/(1000*sqrt(2)) の定義多項式は 2000000*x^2-1
+	small, err := NewRealAlg(NewPolyInts(0, -1, 0, 2000000), NewInt(0), NewInt(1))
+	if err != nil {
+		t.Fatalf("realalg: %v", err)
+	}
+	for i, s := range []struct {
+		x      NObj
+		format string
+		expect string
+	}{
+		{sqrt2, "%.20f", "1.41421356237309504880"},
+		{sqrt2, "%.30f", "1.414213562373095048801688724210"},
+		{sqrt2.Neg().(NObj), "%.25e", "-1.4142135623730950488016887e+00"},
+		{sqrt2, "%.25g", "1.414213562373095048801689"},
+		{sqrt2, "%f", "1.414214"},
+		{small, "%.20e", "7.07106781186547524401e-04"},
+	} {
+		if str := fmt.Sprintf(s.format, s.x); str != s.expect {
+			t.Errorf("%d: %s: expect=%s, actual=%s", i, s.format, s.expect, str)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package ganrac
+
+// 0 次元の多項式系の実数解.
+//
+// 一次形式 t = x1 + c*x2 + c^2*x3 + ... を加えて辞書式順序 (t が最小) の
+// グレブナー基底を計算する. t が解を分離すれば基底は
+//   [h(t), a1*x1 - g1(t), ..., an*xn - gn(t)]
+// の形 (shape lemma) になり, 実数解は h の実根 r で (g1(r)/a1, ..., gn(r)/an)
+// と表せる. 基底がこの形にならない場合は, 各変数の消去多項式の無平方部分を
+// 加えてイデアルを根基にしてから c を変えて再計算する.
+
+import (
+	"fmt"
+)
+
+// realSolveShape はグレブナー基底 gb が shape lemma の形なら
+// h と xi = gs[i] を返す. 解がない場合は h = nil, ok = true.
+func realSolveShape(gb *List, vars []Level, t Level) (*Poly, []RObj, bool, error) {
+	var h *Poly
+	gs := make([]RObj, len(vars))
+	ok := gb.Len() == len(vars)+1
+	for _, e := range gb.v {
+		switch ee := e.(type) {
+		case NObj:
+			if !ee.IsZero() {
+				return nil, nil, true, nil
+			}
+		case *Poly:
+			b := make([]bool, len(varlist))
+			ee.Indets(b)
+			b[t] = false
+			k := -1
+			for i, v := range vars {
+				if b[v] {
+					if k >= 0 {
+						k = -2
+						break
+					}
+					k = i
+				}
+			}
+			if k == -1 {
+				h = ee
+			} else if k >= 0 && ee.Deg(vars[k]) == 1 && ee.Coef(vars[k], 1).IsNumeric() {
+				a := ee.Coef(vars[k], 1).(NObj)
+				gs[k] = Mul(ee.Coef(vars[k], 0).Neg(), one.Div(a))
+			} else {
+				ok = false
+			}
+		}
+	}
+	if h == nil {
+		return nil, nil, false, fmt.Errorf("not zero-dimensional")
+	}
+	for _, g := range gs {
+		if g == nil {
+			ok = false
+		}
+	}
+	return h, gs, ok, nil
+}
+
+// realSolveRadical は fs の各変数の消去多項式の無平方部分を返す.
+func (g *Ganrac) realSolveRadical(fs *List, vars []Level) ([]RObj, error) {
+	ret := make([]RObj, 0, len(vars))
+	for i, v := range vars {
+		// v を最後にしたブロック順序で v 以外を消去する
+		vl := NewList()
+		for j, u := range vars {
+			if j != i {
+				vl.Append(NewPolyVar(u))
+			}
+		}
+		vl.Append(NewPolyVar(v))
+		gb := g.ox.GB(fs, vl, 1)
+		var h *Poly
+		for _, e := range gb.v {
+			if p, ok := e.(*Poly); ok && p.lv == v && p.isUnivariate() {
+				h = p
+				break
+			}
+		}
+		if h == nil {
+			return nil, fmt.Errorf("not zero-dimensional")
+		}
+		ret = append(ret, uniSqfree(h))
+	}
+	return ret, nil
+}
+
+// RealSolve は vars について 0 次元の多項式系 fs = 0 の実数解のリストを返す.
+// 各解は座標のリストで, 座標は有理数か realalg. 解は辞書式に小さい順に並べる.
+func (g *Ganrac) RealSolve(fs []RObj, vars []Level) (*List, error) {
+	if g.ox == nil {
+		return nil, fmt.Errorf("required OX server")
+	}
+
+	ps := NewList()
+	for _, f := range fs {
+		if f.IsZero() {
+			continue
+		} else if f.IsNumeric() {
+			return NewList(), nil
+		}
+		ps.Append(f)
+	}
+	if ps.Len() < len(vars) {
+		return nil, fmt.Errorf("not zero-dimensional")
+	}
+
+	// OX に渡すため, t に名前をつけておく. 終了時に削除する
+	n := len(varlist)
+	defer func() {
+		for _, v := range varlist[n:] {
+			delete(varstr2lv, v.v)
+		}
+		varlist = varlist[:n]
+	}()
+	// 冪根のレベルと重ならないようにする
+	t := radbase + Level(len(radlist))
+	for k := n; int(t) >= len(varlist); k++ {
+		name := fmt.Sprintf("t_%d", k)
+		if _, ok := varstr2lv[name]; !ok {
+			lv := Level(len(varlist))
+			varlist = append(varlist, varInfo{name, newPolyVarn(lv, 1)})
+			varstr2lv[name] = lv
+		}
+	}
+
+	vl := NewList()
+	for _, v := range vars {
+		vl.Append(NewPolyVar(v))
+	}
+	vl.Append(NewPolyVar(t))
+
+	radical := false
+	for k := int64(1); k < 20; k++ {
+		var lin RObj = NewPolyVar(t)
+		var c RObj = one
+		for _, v := range vars {
+			lin = Sub(lin, Mul(c, NewPolyVar(v)))
+			c = Mul(c, NewInt(k))
+		}
+		qs := NewList()
+		for _, p := range ps.v {
+			qs.Append(p)
+		}
+		qs.Append(lin)
+		h, gs, ok, err := realSolveShape(g.ox.GBLex(qs, vl), vars, t)
+		if err != nil {
+			return nil, err
+		} else if ok && h == nil {
+			return NewList(), nil
+		} else if ok {
+			return realSolvePoints(h, gs, t)
+		}
+		if !radical {
+			hs, err := g.realSolveRadical(ps, vars)
+			if err != nil {
+				return nil, err
+			}
+			for _, h := range hs {
+				ps.Append(h)
+			}
+			radical = true
+		}
+	}
+	return nil, fmt.Errorf("no separating linear form is found")
+}
+
+// realSolvePoints は h(r) = 0 となる実数 r について (gs[0](r), ...) を返す
+func realSolvePoints(h *Poly, gs []RObj, t Level) (*List, error) {
+	rs, err := h.RealRoots()
+	if err != nil {
+		return nil, err
+	}
+	pts := make([][]NObj, len(rs))
+	for i, r := range rs {
+		pts[i] = make([]NObj, len(gs))
+		for j, g := range gs {
+			if p, ok := g.(*Poly); ok {
+				pts[i][j] = p.Subst(r, t).(NObj)
+			} else {
+				pts[i][j] = g.(NObj)
+			}
+		}
+	}
+	// 辞書式順序で整列
+	for i := 1; i < len(pts); i++ {
+		for j := i; j > 0 && realSolveLess(pts[j], pts[j-1]); j-- {
+			pts[j], pts[j-1] = pts[j-1], pts[j]
+		}
+	}
+	ret := NewList()
+	for _, pt := range pts {
+		p := NewList()
+		for _, x := range pt {
+			p.Append(x)
+		}
+		ret.Append(p)
+	}
+	return ret, nil
+}
+
+func realSolveLess(p, q []NObj) bool {
+	for i := range p {
+		if c := p[i].Cmp(q[i]); c != 0 {
+			return c < 0
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package ganrac
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// realSolveEval は p に pt[i] を x[vars[i]] として代入した値を返す
+func realSolveEval(p RObj, vars []Level, pt *List) NObj {
+	q, ok := p.(*Poly)
+	if !ok {
+		return p.(NObj)
+	}
+	var x NObj
+	for i, v := range vars {
+		if v == q.lv {
+			x = pt.v[i].(NObj)
+		}
+	}
+	var ret NObj = zero
+	for i := len(q.c) - 1; i >= 0; i-- {
+		ret = Add(Mul(ret, x), realSolveEval(q.c[i], vars, pt)).(NObj)
+	}
+	return ret
+}
+
+func TestRealSolveShape(t *testing.T) {
+	x := NewPolyVar(0)
+	y := NewPolyVar(1)
+	u := NewPolyVar(2)
+	vars := []Level{0, 1}
+
+	// [t^2-2, x-t, 2*y-t]
+	gb := NewList(Sub(Mul(u, u), two), Sub(x, u), Sub(Mul(two, y), u))
+	h, gs, ok, err := realSolveShape(gb, vars, 2)
+	if err != nil || !ok || h == nil {
+		t.Errorf("shape: h=%v, ok=%v, err=%v", h, ok, err)
+		return
+	}
+	pts, err := realSolvePoints(h, gs, 2)
+	if err != nil || pts.Len() != 2 {
+		t.Errorf("points: %v, err=%v", pts, err)
+		return
+	}
+	for i, pt := range pts.v {
+		for _, f := range []RObj{Sub(Mul(x, x), two), Sub(Mul(Mul(two, x), y), two)} {
+			if v := realSolveEval(f, vars, pt.(*List)); !v.IsZero() {
+				t.Errorf("%d: f=%v, pt=%v: %v", i, f, pt, v)
+			}
+		}
+	}
+	if pts.v[0].(*List).v[0].(NObj).Sign() >= 0 {
+		t.Errorf("not sorted: %v", pts)
+	}
+
+	// 形が違う
+	gb = NewList(Sub(Mul(u, u), two), Sub(Mul(x, x), u), Sub(y, u))
+	if _, _, ok, err = realSolveShape(gb, vars, 2); ok || err != nil {
+		t.Errorf("non-shape: ok=%v, err=%v", ok, err)
+	}
+
+	// 0 次元でない
+	gb = NewList(Sub(x, u), Sub(y, u))
+	if _, _, _, err = realSolveShape(gb, vars, 2); err == nil {
+		t.Errorf("not zero-dim: err is expected")
+	}
+}
+
+func TestRealSolve(t *testing.T) {
+	g := NewGANRAC()
+	connc, connd := testConnectOx(g)
+	if g.ox == nil {
+		fmt.Printf("skip TestRealSolve... (no ox)\n")
+		return
+	}
+	defer connc.Close()
+	defer connd.Close()
+
+	for i, s := range []struct {
+		fs     string
+		vars   string
+		expect int
+	}{
+		{"[x^2+y^2-4, x*y-1]", "[x, y]", 4},
+		{"[x^2+y^2-1, x-y]", "[x, y]", 2},
+		{"[x^2+y^2+1, x-y]", "[x, y]", 0},
+		{"[(x-1)^2, y^2-x]", "[x, y]", 2},
+		{"[x^2-2, y^2-2]", "[x, y]", 4},
+		{"[x^2+y^2+z^2-3, x*y*z-1, x-y]", "[x, y, z]", 2},
+		{"[1, x]", "[x]", 0},
+	} {
+		fs, err := g.Eval(strings.NewReader(s.fs + ";"))
+		if err != nil {
+			t.Errorf("%d: %s: %v", i, s.fs, err)
+			continue
+		}
+		xs, _ := g.Eval(strings.NewReader(s.vars + ";"))
+		vars := make([]Level, 0)
+		for _, x := range xs.(*List).v {
+			vars = append(vars, x.(*Poly).lv)
+		}
+		ps := make([]RObj, 0)
+		for _, f := range fs.(*List).v {
+			ps = append(ps, f.(RObj))
+		}
+		pts, err := g.RealSolve(ps, vars)
+		if err != nil {
+			t.Errorf("%d: %s: %v", i, s.fs, err)
+			continue
+		}
+		if pts.Len() != s.expect {
+			t.Errorf("%d: %s: expect=%d, actual=%v", i, s.fs, s.expect, pts)
+			continue
+		}
+		for j, pt := range pts.v {
+			for _, p := range ps {
+				if v := realSolveEval(p, vars, pt.(*List)); !v.IsZero() {
+					t.Errorf("%d,%d: %s: p=%v, pt=%v: %v", i, j, s.fs, p, pt, v)
+				}
+			}
+		}
+	}
+
+	if _, err := g.Eval(strings.NewReader("realsolve([x^2+y^2-1], [x, y]);")); err == nil {
+		t.Errorf("not zero-dim: err is expected")
+	}
+
+	// 冪根があっても, 一時変数と重ならない
+	for _, s := range []string{"S = sqrt(x);", "T = sqrt(y);"} {
+		if _, err := g.Eval(strings.NewReader(s)); err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+	}
+	n := len(varlist)
+	u, err := g.Eval(strings.NewReader("realsolve([x^2-2, y-x], [x, y]);"))
+	if err != nil || u.(*List).Len() != 2 {
+		t.Errorf("radical: %v, %v", u, err)
+	}
+	if len(varlist) != n {
+		t.Errorf("radical: varlist %d => %d", n, len(varlist))
+	}
+	if u, err := g.Eval(strings.NewReader("S;")); err != nil || fmt.Sprintf("%v", u) != "sqrt(x)" {
+		t.Errorf("radical: %v, %v", u, err)
+	}
+}
@@ -0,0 +1,466 @@
+package ganrac
+
+// Redlog (REDUCE) の rlqe スクリプト
+//
+//   load_package redlog;
+//   rlset ofsf;
+//   phi := ex(x, a*x^2+b*x+c = 0);
+//   rlqe phi;
+//
+// rlqe などの引数となった論理式を読み込む.
+// REDUCE は大文字と小文字を区別しないので, 識別子は小文字にする.
+// 変数順序は, 自由変数を出現順に並べ, そのあとに束縛変数を並べる.
+
+import (
+	"fmt"
+	"io"
+)
+
+var redlogOps = []string{
+	"(", ")", "{", "}", ",", ";", "$", ":=",
+	"+", "-", "*", "/", "^", "**",
+	"=", "<>", "<", ">", "<=", ">=",
+}
+
+// 読み飛ばす文
+var redlogIgnore = map[string]bool{
+	"load_package": true, "load": true, "rlset": true,
+	"on": true, "off": true, "setkorder": true, "linelength": true,
+	"write": true, "out": true, "shut": true, "showtime": true,
+}
+
+// 論理式を引数にとる文
+var redlogQE = map[string]bool{
+	"rlqe": true, "rlqea": true, "rlposqe": true, "rlgqe": true,
+	"rlcad": true, "rlsimpl": true,
+}
+
+var redlogRelop = map[string]OP{
+	"<": LT, "=": EQ, "<=": LE, ">": GT, "<>": NE, ">=": GE,
+	"lessp": LT, "equal": EQ, "leq": LE, "greaterp": GT, "neq": NE, "geq": GE,
+}
+
+type redlogParser struct {
+	*fmlScanner
+	defs map[string]*fmlNode
+}
+
+// LoadRedlog reads a Redlog script and returns the arguments of
+// rlqe and its friends.  If there is no such command,
+// the last assigned formula is returned.
+func (g *Ganrac) LoadRedlog(r io.Reader) ([]Fof, error) {
+	p := &redlogParser{newFmlScanner(r, "redlog", redlogOps), make(map[string]*fmlNode)}
+	p.comment = '%'
+	p.fold = true
+
+	var last *fmlNode
+	nodes := make([]*fmlNode, 0)
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	for p.tok.kind != fmlTokEOF {
+		if p.isOp(";") || p.isOp("$") {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if p.tok.kind == fmlTokIdent {
+			t := p.tok
+			if t.s == "end" || t.s == "quit" || t.s == "bye" {
+				break
+			}
+			if t.s == "comment" || redlogIgnore[t.s] {
+				if err := p.skipStatement(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if redlogQE[t.s] {
+				n, err := p.equiv()
+				if err != nil {
+					return nil, err
+				}
+				if !n.isFof() {
+					return nil, n.errorf(p.name, "%s: formula is expected", t.s)
+				}
+				nodes = append(nodes, n)
+				if err := p.endStatement(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if p.isOp(":=") {
+				if err := p.next(); err != nil {
+					return nil, err
+				}
+				n, err := p.equiv()
+				if err != nil {
+					return nil, err
+				}
+				p.defs[t.s] = n
+				if n.isFof() {
+					last = n
+				}
+				if err := p.endStatement(); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			p.unget(t)
+		}
+
+		// 評価して表示するだけの文
+		if _, err := p.equiv(); err != nil {
+			return nil, err
+		}
+		if err := p.endStatement(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(nodes) == 0 {
+		if last == nil {
+			return nil, fmt.Errorf("%s: no formula", p.name)
+		}
+		nodes = append(nodes, last)
+	}
+
+	if err := g.InitVarList(fmlVarOrder(nodes...)); err != nil {
+		return nil, err
+	}
+	fofs := make([]Fof, len(nodes))
+	for i, n := range nodes {
+		f, err := n.fof(p.name)
+		if err != nil {
+			return nil, err
+		}
+		fofs[i] = f
+	}
+	return fofs, nil
+}
+
+// skipStatement は ; か $ まで読み飛ばす.
+func (p *redlogParser) skipStatement() error {
+	for {
+		c, err := p.readRune()
+		if err == io.EOF {
+			p.tok = fmlToken{fmlTokEOF, "", p.line}
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if c == ';' || c == '$' {
+			return p.next()
+		}
+	}
+}
+
+func (p *redlogParser) endStatement() error {
+	if p.tok.kind == fmlTokEOF {
+		return nil
+	}
+	if !p.isOp(";") && !p.isOp("$") {
+		return p.unexpected(";")
+	}
+	return p.next()
+}
+
+func (p *redlogParser) isIdent(s string) bool {
+	return p.tok.kind == fmlTokIdent && p.tok.s == s
+}
+
+func (p *redlogParser) equiv() (*fmlNode, error) {
+	n, err := p.impl()
+	if err != nil {
+		return nil, err
+	}
+	for p.isIdent("equiv") {
+		line := p.tok.line
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.impl()
+		if err != nil {
+			return nil, err
+		}
+		n = newFmlNode(fmlNodeEquiv, line, n, m)
+	}
+	return n, nil
+}
+
+func (p *redlogParser) impl() (*fmlNode, error) {
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	for p.isIdent("impl") || p.isIdent("repl") {
+		op := p.tok
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if op.s == "impl" {
+			n = newFmlNode(fmlNodeImpl, op.line, n, m)
+		} else {
+			n = newFmlNode(fmlNodeImpl, op.line, m, n)
+		}
+	}
+	return n, nil
+}
+
+func (p *redlogParser) or() (*fmlNode, error) {
+	n, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isIdent("or") {
+		return n, nil
+	}
+	n = newFmlNode(fmlNodeOr, p.tok.line, n)
+	for p.isIdent("or") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		n.args = append(n.args, m)
+	}
+	return n, nil
+}
+
+func (p *redlogParser) and() (*fmlNode, error) {
+	n, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	if !p.isIdent("and") {
+		return n, nil
+	}
+	n = newFmlNode(fmlNodeAnd, p.tok.line, n)
+	for p.isIdent("and") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		n.args = append(n.args, m)
+	}
+	return n, nil
+}
+
+func (p *redlogParser) not() (*fmlNode, error) {
+	if p.isIdent("not") {
+		line := p.tok.line
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return newFmlNode(fmlNodeNot, line, n), nil
+	}
+	return p.rel()
+}
+
+func (p *redlogParser) rel() (*fmlNode, error) {
+	lhs, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := redlogRelop[p.tok.s]
+	if !ok {
+		return lhs, nil
+	}
+	line := p.tok.line
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	rhs, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	n := newFmlNode(fmlNodeAtom, line, lhs, rhs)
+	n.op = op
+	return n, nil
+}
+
+func (p *redlogParser) sum() (*fmlNode, error) {
+	n, err := p.prod()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("+") || p.isOp("-") {
+		cmd := fmlNodeAdd
+		if p.isOp("-") {
+			cmd = fmlNodeSub
+		}
+		line := p.tok.line
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.prod()
+		if err != nil {
+			return nil, err
+		}
+		n = newFmlNode(cmd, line, n, m)
+	}
+	return n, nil
+}
+
+func (p *redlogParser) prod() (*fmlNode, error) {
+	n, err := p.power()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOp("*") || p.isOp("/") {
+		cmd := fmlNodeMul
+		if p.isOp("/") {
+			cmd = fmlNodeDiv
+		}
+		line := p.tok.line
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.power()
+		if err != nil {
+			return nil, err
+		}
+		n = newFmlNode(cmd, line, n, m)
+	}
+	return n, nil
+}
+
+func (p *redlogParser) power() (*fmlNode, error) {
+	n, err := p.prim()
+	if err != nil {
+		return nil, err
+	}
+	if p.isOp("^") || p.isOp("**") {
+		line := p.tok.line
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		m, err := p.power()
+		if err != nil {
+			return nil, err
+		}
+		n = newFmlNode(fmlNodePow, line, n, m)
+	}
+	return n, nil
+}
+
+func (p *redlogParser) prim() (*fmlNode, error) {
+	line := p.tok.line
+	switch {
+	case p.isOp("-") || p.isOp("+"):
+		neg := p.isOp("-")
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.power()
+		if err != nil {
+			return nil, err
+		}
+		if neg {
+			n = newFmlNode(fmlNodeNeg, line, n)
+		}
+		return n, nil
+	case p.tok.kind == fmlTokNum:
+		n := newFmlNode(fmlNodeNum, line)
+		n.s = p.tok.s
+		return n, p.next()
+	case p.isOp("("):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		n, err := p.equiv()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(")")
+	case p.tok.kind == fmlTokIdent:
+		s := p.tok.s
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		switch s {
+		case "true":
+			return newFmlNode(fmlNodeTrue, line), nil
+		case "false":
+			return newFmlNode(fmlNodeFalse, line), nil
+		case "ex", "all":
+			if p.isOp("(") {
+				return p.quantifier(s, line)
+			}
+		}
+		if n, ok := p.defs[s]; ok {
+			return n, nil
+		}
+		n := newFmlNode(fmlNodeVar, line)
+		n.s = s
+		return n, nil
+	}
+	return nil, p.unexpected("expression")
+}
+
+// ex(x, F), ex({x, y}, F)
+func (p *redlogParser) quantifier(s string, line int) (*fmlNode, error) {
+	n := newFmlNode(fmlNodeEx, line)
+	if s == "all" {
+		n.cmd = fmlNodeAll
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	brace := p.isOp("{")
+	if brace {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		if p.tok.kind != fmlTokIdent {
+			return nil, p.unexpected("variable")
+		}
+		n.vars = append(n.vars, p.tok.s)
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if !brace || !p.isOp(",") {
+			break
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+	}
+	if brace {
+		if err := p.expect("}"); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+	m, err := p.equiv()
+	if err != nil {
+		return nil, err
+	}
+	if !m.isFof() {
+		return nil, m.errorf(p.name, "%s: formula is expected", s)
+	}
+	n.args = []*fmlNode{m}
+	return n, p.expect(")")
+}
@@ -0,0 +1,153 @@
+package ganrac
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+ * 1文を取得.
+ * `;` か `:` (波括弧の外) で終わる. `#` から行末まではコメント.
+ * EOF に達したら, それまでに読んだ文字列と io.EOF を返す.
+ */
+func ReadStatement(in *bufio.Reader) (string, error) {
+	line := make([]rune, 0, 100)
+	in_str := false  // 文字列内
+	in_com := false  // コメント内
+	in_bcom := false // ブロックコメント内
+	depth_curly := 0 // 波括弧の深さ
+	var prev rune
+	for {
+		c, _, err := in.ReadRune()
+		if err != nil {
+			return string(line), err
+		}
+		line = append(line, c)
+		if in_com {
+			if c == '\n' {
+				in_com = false
+			}
+			continue
+		}
+		if in_bcom {
+			if prev == '*' && c == '/' {
+				in_bcom = false
+				c = 0
+			}
+			prev = c
+			continue
+		}
+		if !in_str && prev == '/' && c == '*' {
+			in_bcom = true
+			prev = 0
+			continue
+		}
+		prev = c
+		if c == '"' {
+			in_str = !in_str
+		} else if in_str {
+			//
+		} else if c == '{' {
+			depth_curly++
+		} else if c == '}' && depth_curly > 0 {
+			depth_curly--
+		} else if c == ';' && depth_curly <= 0 { // eol
+			break
+		} else if c == ':' && depth_curly <= 0 { // eolq
+			break
+		} else if c == '#' {
+			// 改行まで skip
+			in_com = true
+		}
+	}
+	return string(line), nil
+}
+
+// stmtOffset は文の先頭の空行, コメント行の数を返す.
+// 空白とコメントだけなら ok=false
+func stmtOffset(stmt string) (int, bool) {
+	for i, s := range strings.Split(stmt, "\n") {
+		s = strings.TrimSpace(s)
+		if s != "" && s[0] != '#' {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// LoadScript evaluates a GaNRAC script and returns the value of
+// the last statement.  name is used in error messages.
+func (g *Ganrac) LoadScript(r io.Reader, name string) (interface{}, error) {
+	in := bufio.NewReader(r)
+	lineno := 1
+	var ret interface{}
+	for {
+		stmt, err := ReadStatement(in)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		offset, ok := stmtOffset(stmt)
+		if ok {
+			if err == io.EOF {
+				return nil, fmt.Errorf("%s:%d: `;` is expected", name, lineno+offset)
+			}
+			p, err := g.Eval(strings.NewReader(stmt))
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", name, lineno+offset, err.Error())
+			}
+			ret = p
+		}
+		if err == io.EOF {
+			return ret, nil
+		}
+		lineno += strings.Count(stmt, "\n")
+	}
+}
+
+func saveable(o interface{}) bool {
+	switch p := o.(type) {
+	case Fof, *Poly, *RatFunc, *Piecewise, *RealAlg, *Int, *Rat, *BinInt, *String:
+		return true
+	case *List:
+		for _, v := range p.v {
+			if !saveable(v) {
+				return false
+			}
+		}
+		return true
+	case *Dict:
+		for _, v := range p.v {
+			if !saveable(v) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Save writes obj as a GaNRAC script.
+// The script initializes the variable order and
+// its last statement is obj, so load() returns obj.
+func (g *Ganrac) Save(w io.Writer, obj interface{}) error {
+	if !saveable(obj) {
+		return fmt.Errorf("unsupported object: %v", obj)
+	}
+
+	colored := coloredFml
+	coloredFml = false
+	defer func() { coloredFml = colored }()
+
+	fmt.Fprintf(w, "%s(", init_var_funcname)
+	for i, v := range varlist {
+		if i != 0 {
+			fmt.Fprintf(w, ",")
+		}
+		fmt.Fprintf(w, "%s", v.v)
+	}
+	fmt.Fprintf(w, ");\n")
+	fmt.Fprintf(w, "%v;\n", obj)
+	return nil
+}
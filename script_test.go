@@ -0,0 +1,113 @@
+package ganrac
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadScript(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"1+2;", "3"},
+		{"X = 3; # comment; here\nY = X + 1:\nX * Y;\n", "12"},
+		{"D = {\"a\": 1, \"b\": x};\nD[\"a\"];  \n# last\n", "1"},
+		{"S = \"a;b:c\";\nS;", "\"a;b:c\""},
+		{"", "nil"},
+		{"def f(X) {\n  Y = X + 1;\n  return Y;\n};\nf(2);\n", "3"},
+		{"X = 2; /* X = 3;\n X = 4; */ X /**/ * 5;", "10"},
+	} {
+		p, err := g.LoadScript(strings.NewReader(s.input), "test")
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		if p == nil {
+			if s.expect != "nil" {
+				t.Errorf("%d: input=%s: expect=%s, actual=nil", i, s.input, s.expect)
+			}
+			continue
+		}
+		if fmt.Sprintf("%v", p) != s.expect {
+			t.Errorf("%d: input=%s: expect=%s, actual=%v", i, s.input, s.expect, p)
+		}
+	}
+
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"1+2;\n\n# comment\n\n  3+;\n", "test:5: "},
+		{"X = 1;\nX +\n 1", "test:2: "},
+		{"X = 1;\nY = X + ;", "test:2: "},
+		{"X = 1;\nif (X) {\n  X = 2;\n};\n", "test:2: "},
+	} {
+		_, err := g.LoadScript(strings.NewReader(s.input), "test")
+		if err == nil {
+			t.Errorf("%d: input=%s: error is expected", i, s.input)
+		} else if !strings.HasPrefix(err.Error(), s.expect) {
+			t.Errorf("%d: input=%s: expect=%s..., actual=%s", i, s.input, s.expect, err)
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []string{
+		"x^2+3*y-1/2",
+		"-3/7",
+		"(x+1)/(y^2-2*z)",
+		"ex([z], z^2+x*z+y==0 && (x>0 || y!=0))",
+		"[1, x, [y>0, -2/3]]",
+		"{\"a\": [1,2], \"b\": x+y}",
+	} {
+		g.InitVarList([]string{"x", "y", "z"})
+		u, err := g.Eval(strings.NewReader(s + ";"))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s, err)
+			continue
+		}
+		b := new(bytes.Buffer)
+		if err := g.Save(b, u); err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s, err)
+			continue
+		}
+		g.InitVarList([]string{"a", "b"})
+		v, err := g.LoadScript(b, "save")
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s, err)
+			continue
+		}
+		if !u.(equaler).Equals(v) {
+			t.Errorf("%d: input=%s: expect=%v, actual=%v", i, s, u, v)
+		}
+	}
+
+	b := new(bytes.Buffer)
+	if err := g.Save(b, NewIntervalInt64(1, 10)); err == nil {
+		t.Errorf("interval: error is expected")
+	}
+
+	// builtin
+	fname := filepath.Join(t.TempDir(), "save.gr")
+	for i, s := range []string{
+		init_var_funcname + "(x,y,z);",
+		fmt.Sprintf("save(x^2>y, \"%s\");", fname),
+		init_var_funcname + "(a,b,c);",
+		fmt.Sprintf("F = load(\"%s\");", fname),
+	} {
+		if _, err := g.Eval(strings.NewReader(s)); err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s, err)
+			return
+		}
+	}
+	u, err := g.Eval(strings.NewReader("F;"))
+	if err != nil || fmt.Sprintf("%v", u) != "y-x^2<0" {
+		t.Errorf("load() failed: %v", u)
+	}
+}
@@ -0,0 +1,810 @@
+package ganrac
+
+// セッションの保存と復元.
+//
+// JSON 形式で, 変数順序, 変数 (CAD を含む), 履歴を保存する.
+// オブジェクトは先頭要素が型を表す配列で表現する.
+//   ["i", "123"]              *Int
+//   ["r", "1/3"]              *Rat
+//   ["b", "5", -3]            *BinInt  5*2^(-3)
+//   ["v", prec, inf, sup]     *Interval
+//   ["p", lv, [c0, c1, ...]]  *Poly
+//   ["f", num, den]           *RatFunc   (version 2 以降)
+//   ["g", poly, bin]          *RealAlg 分離区間 (bin, bin+2^m)  (version 2 以降)
+//   ["w", [c1, ...], [v1, ...]]  *Piecewise  (version 2 以降)
+//   ["s", "str"]              *String
+//   ["l", [...]]              *List
+//   ["d", {"key": ...}]       *Dict
+//   ["T"], ["F"]              true, false
+//   ["a", op, [p1, ...]]      *Atom
+//   ["and", [...]], ["or", [...]]
+//   ["all", [lv, ...], fml], ["ex", [lv, ...], fml]
+//   ["cad", index]            *CAD. 実体は "cads" に保存する
+//
+// 冪根 sqrt(), root() は変数の後ろのレベルの変数なので, "radicals" に
+// 被開方数と次数をレベルの順に保存し, 値より先に復元する (version 3 以降).
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+)
+
+const (
+	SESSION_FORMAT  = "ganrac-session"
+	SESSION_VERSION = 3
+)
+
+// sessionTagVersion は version 1 より後に追加したタグとその version
+var sessionTagVersion = map[string]int{
+	"f": 2, "g": 2, "w": 2,
+}
+
+type sessionJSON struct {
+	Format   string                     `json:"format"`
+	Version  int                        `json:"version"`
+	Vars     []string                   `json:"vars"`
+	Radicals []*radicalJSON             `json:"radicals,omitempty"`
+	Values   map[string]json.RawMessage `json:"values"`
+	History  []json.RawMessage          `json:"history"`
+	CADs     []*cadJSON                 `json:"cads"`
+}
+
+// radicalJSON は冪根 root(P, K)
+type radicalJSON struct {
+	P json.RawMessage `json:"p"`
+	K int             `json:"k"`
+}
+
+type gobjEncoder struct {
+	cad  *CAD         // CAD 内の論理式 (AtomProj) を保存するときに使う
+	cads map[*CAD]int // 同じ CAD は 1 度だけ保存する
+	cadl []*cadJSON
+}
+
+type gobjDecoder struct {
+	g       *Ganrac
+	version int // セッションの version
+	cad     *CAD
+	cads    []*CAD
+	cadj    []*cadJSON
+}
+
+func newGobjEncoder() *gobjEncoder {
+	enc := new(gobjEncoder)
+	enc.cads = make(map[*CAD]int)
+	enc.cadl = make([]*cadJSON, 0)
+	return enc
+}
+
+func (enc *gobjEncoder) encodeList(fs []Fof) ([]interface{}, error) {
+	ret := make([]interface{}, len(fs))
+	for i, f := range fs {
+		v, err := enc.encode(f)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
+
+func (enc *gobjEncoder) encodePolys(ps []*Poly) ([]interface{}, error) {
+	ret := make([]interface{}, len(ps))
+	for i, p := range ps {
+		v, err := enc.encodeRObj(p)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
+
+func (enc *gobjEncoder) encodeRObjs(os ...RObj) ([]interface{}, error) {
+	ret := make([]interface{}, len(os))
+	for i, o := range os {
+		v, err := enc.encodeRObj(o)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
+
+func (enc *gobjEncoder) encodeRObj(o RObj) (interface{}, error) {
+	switch p := o.(type) {
+	case *Int:
+		return []interface{}{"i", p.n.String()}, nil
+	case *Rat:
+		return []interface{}{"r", p.n.String()}, nil
+	case *BinInt:
+		return []interface{}{"b", p.n.String(), p.m}, nil
+	case *Interval:
+		prec := uint(53)
+		var inf, sup interface{}
+		if p.inf != nil {
+			prec = p.inf.Prec()
+			inf = p.inf.Text('p', 0)
+		}
+		if p.sup != nil {
+			prec = p.sup.Prec()
+			sup = p.sup.Text('p', 0)
+		}
+		return []interface{}{"v", prec, inf, sup}, nil
+	case *Poly:
+		cs, err := enc.encodeRObjs(p.c...)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"p", p.lv, cs}, nil
+	case *RatFunc:
+		vs, err := enc.encodeRObjs(p.num, p.den)
+		if err != nil {
+			return nil, err
+		}
+		return append([]interface{}{"f"}, vs...), nil
+	case *RealAlg:
+		vs, err := enc.encodeRObjs(p.p, p.low)
+		if err != nil {
+			return nil, err
+		}
+		return append([]interface{}{"g"}, vs...), nil
+	}
+	return nil, fmt.Errorf("unsupported object: %v", o)
+}
+
+func encodeLevels(q []Level) []int {
+	ret := make([]int, len(q))
+	for i, lv := range q {
+		ret[i] = int(lv)
+	}
+	return ret
+}
+
+func (enc *gobjEncoder) encode(o interface{}) (interface{}, error) {
+	switch p := o.(type) {
+	case nil:
+		return nil, nil
+	case *Int, *Rat, *BinInt, *Interval, *Poly, *RatFunc, *RealAlg:
+		return enc.encodeRObj(p.(RObj))
+	case *Piecewise:
+		cs, err := enc.encodeList(p.cond)
+		if err != nil {
+			return nil, err
+		}
+		vs, err := enc.encodeRObjs(p.val...)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"w", cs, vs}, nil
+	case *String:
+		return []interface{}{"s", p.s}, nil
+	case *List:
+		vs := make([]interface{}, len(p.v))
+		for i, v := range p.v {
+			u, err := enc.encode(v)
+			if err != nil {
+				return nil, err
+			}
+			vs[i] = u
+		}
+		return []interface{}{"l", vs}, nil
+	case *Dict:
+		vs := make(map[string]interface{}, len(p.v))
+		for k, v := range p.v {
+			u, err := enc.encode(v)
+			if err != nil {
+				return nil, err
+			}
+			vs[k] = u
+		}
+		return []interface{}{"d", vs}, nil
+	case *AtomT:
+		return []interface{}{"T"}, nil
+	case *AtomF:
+		return []interface{}{"F"}, nil
+	case *Atom:
+		ps, err := enc.encodePolys(p.p)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"a", p.op, ps}, nil
+	case *AtomProj:
+		if enc.cad == nil {
+			return nil, fmt.Errorf("unsupported object: %v", p)
+		}
+		ps, err := enc.encodePolys(p.p)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"ap", p.op, ps, enc.cad.encodeProjLink(p.pl)}, nil
+	case *FmlAnd:
+		fs, err := enc.encodeList(p.fml)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"and", fs}, nil
+	case *FmlOr:
+		fs, err := enc.encodeList(p.fml)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"or", fs}, nil
+	case *ForAll:
+		f, err := enc.encode(p.fml)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"all", encodeLevels(p.q), f}, nil
+	case *Exists:
+		f, err := enc.encode(p.fml)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"ex", encodeLevels(p.q), f}, nil
+	case *CAD:
+		idx, ok := enc.cads[p]
+		if !ok {
+			cj, err := p.toJSON()
+			if err != nil {
+				return nil, err
+			}
+			idx = len(enc.cadl)
+			enc.cads[p] = idx
+			enc.cadl = append(enc.cadl, cj)
+		}
+		return []interface{}{"cad", idx}, nil
+	}
+	return nil, fmt.Errorf("unsupported object: %v", o)
+}
+
+func (enc *gobjEncoder) marshal(o interface{}) (json.RawMessage, error) {
+	v, err := enc.encode(o)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+////////////////////////////////////////////////////////////
+// decode
+////////////////////////////////////////////////////////////
+
+func decodeInt(v interface{}) (int, error) {
+	switch n := v.(type) {
+	case float64:
+		if n != float64(int(n)) {
+			return 0, fmt.Errorf("integer is expected: %v", v)
+		}
+		return int(n), nil
+	case json.Number:
+		i, err := n.Int64()
+		return int(i), err
+	}
+	return 0, fmt.Errorf("integer is expected: %v", v)
+}
+
+func decodeString(v interface{}) (string, error) {
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("string is expected: %v", v)
+	}
+	return s, nil
+}
+
+func decodeLevels(v interface{}) ([]Level, error) {
+	vs, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("list is expected: %v", v)
+	}
+	ret := make([]Level, len(vs))
+	for i, u := range vs {
+		n, err := decodeInt(u)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = Level(n)
+	}
+	return ret, nil
+}
+
+func decodeBigFloat(v interface{}, prec uint, mode big.RoundingMode) (*big.Float, error) {
+	if v == nil {
+		return nil, nil
+	}
+	s, err := decodeString(v)
+	if err != nil {
+		return nil, err
+	}
+	f := new(big.Float)
+	f.SetPrec(prec)
+	f.SetMode(mode)
+	if _, _, err := f.Parse(s, 0); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (dec *gobjDecoder) decodeRObj(v interface{}) (RObj, error) {
+	o, err := dec.decode(v)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := o.(RObj)
+	if !ok {
+		return nil, fmt.Errorf("robj is expected: %v", v)
+	}
+	return r, nil
+}
+
+func (dec *gobjDecoder) decodePoly(v interface{}) (*Poly, error) {
+	o, err := dec.decode(v)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := o.(*Poly)
+	if !ok {
+		return nil, fmt.Errorf("poly is expected: %v", v)
+	}
+	return p, nil
+}
+
+func (dec *gobjDecoder) decodePolys(v interface{}) ([]*Poly, error) {
+	vs, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("list is expected: %v", v)
+	}
+	ret := make([]*Poly, len(vs))
+	for i, u := range vs {
+		p, err := dec.decodePoly(u)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = p
+	}
+	return ret, nil
+}
+
+func (dec *gobjDecoder) decodeFof(v interface{}) (Fof, error) {
+	o, err := dec.decode(v)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := o.(Fof)
+	if !ok {
+		return nil, fmt.Errorf("fof is expected: %v", v)
+	}
+	return f, nil
+}
+
+func (dec *gobjDecoder) decodeFofs(v interface{}) ([]Fof, error) {
+	vs, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("list is expected: %v", v)
+	}
+	ret := make([]Fof, len(vs))
+	for i, u := range vs {
+		f, err := dec.decodeFof(u)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = f
+	}
+	return ret, nil
+}
+
+func (dec *gobjDecoder) decode(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	vs, ok := v.([]interface{})
+	if !ok || len(vs) == 0 {
+		return nil, fmt.Errorf("invalid object: %v", v)
+	}
+	tag, err := decodeString(vs[0])
+	if err != nil {
+		return nil, err
+	}
+	narg := map[string]int{
+		"i": 2, "r": 2, "b": 3, "v": 4, "p": 3, "f": 3, "g": 3, "w": 3, "s": 2, "l": 2, "d": 2,
+		"T": 1, "F": 1, "a": 3, "ap": 4, "and": 2, "or": 2, "all": 3, "ex": 3, "cad": 2,
+	}
+	if n, ok := narg[tag]; !ok || n != len(vs) {
+		return nil, fmt.Errorf("invalid object: %v", v)
+	}
+	if sessionTagVersion[tag] > dec.version {
+		return nil, fmt.Errorf("invalid object for session version %d: %v", dec.version, v)
+	}
+
+	switch tag {
+	case "i", "r", "b":
+		s, err := decodeString(vs[1])
+		if err != nil {
+			return nil, err
+		}
+		if tag == "r" {
+			r := newRat()
+			if _, ok := r.n.SetString(s); !ok {
+				return nil, fmt.Errorf("invalid rational: %s", s)
+			}
+			return r, nil
+		}
+		n, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer: %s", s)
+		}
+		if tag == "i" {
+			z := newInt()
+			z.n = n
+			return z, nil
+		}
+		m, err := decodeInt(vs[2])
+		if err != nil {
+			return nil, err
+		}
+		return NewBinInt(n, m), nil
+	case "v":
+		prec, err := decodeInt(vs[1])
+		if err != nil {
+			return nil, err
+		}
+		z := new(Interval)
+		if z.inf, err = decodeBigFloat(vs[2], uint(prec), big.ToNegativeInf); err != nil {
+			return nil, err
+		}
+		if z.sup, err = decodeBigFloat(vs[3], uint(prec), big.ToPositiveInf); err != nil {
+			return nil, err
+		}
+		return z, nil
+	case "p":
+		lv, err := decodeInt(vs[1])
+		if err != nil {
+			return nil, err
+		}
+		// 冪根のレベルも使える
+		n := len(varlist)
+		if m := int(radbase) + len(radlist); n < m {
+			n = m
+		}
+		cs, ok := vs[2].([]interface{})
+		if !ok || len(cs) < 2 || lv < 0 || lv >= n {
+			return nil, fmt.Errorf("invalid polynomial: %v", v)
+		}
+		p := NewPoly(Level(lv), len(cs))
+		for i, c := range cs {
+			if p.c[i], err = dec.decodeRObj(c); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.valid(); err != nil {
+			return nil, err
+		}
+		return p, nil
+	case "f":
+		num, err := dec.decodeRObj(vs[1])
+		if err != nil {
+			return nil, err
+		}
+		den, err := dec.decodePoly(vs[2])
+		if err != nil {
+			return nil, err
+		}
+		return NewRatFunc(num, den), nil
+	case "g":
+		p, err := dec.decodePoly(vs[1])
+		if err != nil {
+			return nil, err
+		}
+		low, err := dec.decodeRObj(vs[2])
+		if err != nil {
+			return nil, err
+		}
+		b, ok := low.(*BinInt)
+		if !ok {
+			return nil, fmt.Errorf("invalid realalg: %v", v)
+		}
+		// 有理数や区間内に根が複数ある場合もあるので, 作り直す
+		z, err := NewRealAlg(p, toNObj(b), toNObj(b.upperBound()))
+		if err != nil {
+			return nil, fmt.Errorf("invalid realalg: %v: %w", v, err)
+		}
+		return z, nil
+	case "w":
+		cs, err := dec.decodeFofs(vs[1])
+		if err != nil {
+			return nil, err
+		}
+		us, ok := vs[2].([]interface{})
+		if !ok || len(us) != len(cs) || len(cs) == 0 {
+			return nil, fmt.Errorf("invalid piecewise: %v", v)
+		}
+		vals := make([]RObj, len(us))
+		for i, u := range us {
+			if vals[i], err = dec.decodeRObj(u); err != nil {
+				return nil, err
+			}
+		}
+		z := &Piecewise{cond: cs, val: vals}
+		if err := z.valid(); err != nil {
+			return nil, err
+		}
+		return z, nil
+	case "s":
+		s, err := decodeString(vs[1])
+		if err != nil {
+			return nil, err
+		}
+		return NewString(s), nil
+	case "l":
+		us, ok := vs[1].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid list: %v", v)
+		}
+		l := NewList()
+		for _, u := range us {
+			o, err := dec.decode(u)
+			if err != nil {
+				return nil, err
+			}
+			g, ok := o.(GObj)
+			if !ok {
+				return nil, fmt.Errorf("invalid list element: %v", u)
+			}
+			l.Append(g)
+		}
+		return l, nil
+	case "d":
+		us, ok := vs[1].(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid dict: %v", v)
+		}
+		d := NewDict()
+		for k, u := range us {
+			o, err := dec.decode(u)
+			if err != nil {
+				return nil, err
+			}
+			g, ok := o.(GObj)
+			if !ok {
+				return nil, fmt.Errorf("invalid dict element: %v", u)
+			}
+			d.Set(k, g)
+		}
+		return d, nil
+	case "T":
+		return trueObj, nil
+	case "F":
+		return falseObj, nil
+	case "a", "ap":
+		op, err := decodeInt(vs[1])
+		if err != nil {
+			return nil, err
+		}
+		if op <= 0 || OP(op) >= OP_TRUE {
+			return nil, fmt.Errorf("invalid atom: %v", v)
+		}
+		ps, err := dec.decodePolys(vs[2])
+		if err != nil {
+			return nil, err
+		}
+		if tag == "a" {
+			a := new(Atom)
+			a.p = ps
+			a.op = OP(op)
+			return a, nil
+		}
+		if dec.cad == nil {
+			return nil, fmt.Errorf("invalid atom: %v", v)
+		}
+		a := new(AtomProj)
+		a.p = ps
+		a.op = OP(op)
+		if a.pl, err = dec.cad.decodeProjLink(vs[3]); err != nil {
+			return nil, err
+		}
+		return a, nil
+	case "and", "or":
+		fs, err := dec.decodeFofs(vs[1])
+		if err != nil {
+			return nil, err
+		}
+		if tag == "and" {
+			return &FmlAnd{fml: fs}, nil
+		}
+		return &FmlOr{fml: fs}, nil
+	case "all", "ex":
+		q, err := decodeLevels(vs[1])
+		if err != nil {
+			return nil, err
+		}
+		f, err := dec.decodeFof(vs[2])
+		if err != nil {
+			return nil, err
+		}
+		if tag == "all" {
+			return &ForAll{q: q, fml: f}, nil
+		}
+		return &Exists{q: q, fml: f}, nil
+	case "cad":
+		idx, err := decodeInt(vs[1])
+		if err != nil {
+			return nil, err
+		}
+		if idx < 0 || idx >= len(dec.cadj) {
+			return nil, fmt.Errorf("invalid cad index: %d", idx)
+		}
+		if dec.cads[idx] == nil {
+			c, err := dec.g.cadFromJSON(dec.cadj[idx])
+			if err != nil {
+				return nil, err
+			}
+			dec.cads[idx] = c
+		}
+		return dec.cads[idx], nil
+	}
+	return nil, fmt.Errorf("invalid object: %v", v)
+}
+
+func (dec *gobjDecoder) unmarshal(raw json.RawMessage) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return dec.decode(v)
+}
+
+////////////////////////////////////////////////////////////
+// session
+////////////////////////////////////////////////////////////
+
+// SaveSession writes the variable order, the variables and the history.
+func (g *Ganrac) SaveSession(w io.Writer) error {
+	s := new(sessionJSON)
+	s.Format = SESSION_FORMAT
+	s.Version = SESSION_VERSION
+	s.Vars = make([]string, len(varlist))
+	for i, v := range varlist {
+		s.Vars[i] = v.v
+	}
+
+	enc := newGobjEncoder()
+	for _, r := range radlist {
+		raw, err := enc.marshal(r.p)
+		if err != nil {
+			return fmt.Errorf("%v: %w", &r, err)
+		}
+		s.Radicals = append(s.Radicals, &radicalJSON{raw, r.k})
+	}
+	s.Values = make(map[string]json.RawMessage, len(g.varmap))
+	for k, v := range g.varmap {
+		raw, err := enc.marshal(v)
+		if err != nil {
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		s.Values[k] = raw
+	}
+	s.History = make([]json.RawMessage, len(g.history))
+	for i, v := range g.history {
+		raw, err := enc.marshal(v)
+		if err != nil {
+			return fmt.Errorf("history: %w", err)
+		}
+		s.History[i] = raw
+	}
+	s.CADs = enc.cadl
+
+	b := json.NewEncoder(w)
+	return b.Encode(s)
+}
+
+// LoadSession restores a session saved by SaveSession.
+// The variables and the history are replaced.
+func (g *Ganrac) LoadSession(r io.Reader) error {
+	s := new(sessionJSON)
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return err
+	}
+	if s.Format != SESSION_FORMAT {
+		return fmt.Errorf("not a session file")
+	}
+	if s.Version > SESSION_VERSION || s.Version <= 0 {
+		return fmt.Errorf("unsupported session version %d", s.Version)
+	}
+
+	// 失敗したら元に戻す
+	old := make([]string, len(varlist))
+	for i, v := range varlist {
+		old[i] = v.v
+	}
+	oldrad := radlist
+	restore := func() {
+		g.InitVarList(old)
+		radlist = oldrad
+	}
+	if err := g.InitVarList(s.Vars); err != nil {
+		return err
+	}
+
+	dec := &gobjDecoder{g: g, version: s.Version, cads: make([]*CAD, len(s.CADs)), cadj: s.CADs}
+	if len(s.Radicals) > 0 && s.Version < 3 {
+		restore()
+		return fmt.Errorf("radicals for session version %d", s.Version)
+	}
+	// 被開方数には前の冪根が現れうるので, 1 つずつ追加する
+	for i, r := range s.Radicals {
+		v, err := dec.unmarshal(r.P)
+		if err != nil {
+			restore()
+			return fmt.Errorf("radicals[%d]: %w", i, err)
+		}
+		switch v.(type) {
+		case *Poly, *Int, *Rat:
+		default:
+			restore()
+			return fmt.Errorf("radicals[%d]: unsupported radicand: %v", i, v)
+		}
+		if r.K < 2 {
+			restore()
+			return fmt.Errorf("radicals[%d]: invalid index: %d", i, r.K)
+		}
+		radlist = append(radlist, radInfo{v.(RObj), r.K})
+	}
+	varmap := make(map[string]interface{}, len(s.Values))
+	for k, raw := range s.Values {
+		v, err := dec.unmarshal(raw)
+		if err != nil {
+			restore()
+			return fmt.Errorf("%s: %w", k, err)
+		}
+		varmap[k] = v
+	}
+	history := make([]interface{}, len(s.History))
+	for i, raw := range s.History {
+		v, err := dec.unmarshal(raw)
+		if err != nil {
+			restore()
+			return fmt.Errorf("history: %w", err)
+		}
+		history[i] = v
+	}
+	g.varmap = varmap
+	g.history = history
+	return nil
+}
+
+// SaveSessionFile writes the session to fname.
+// A temporary file is renamed to fname, so that
+// the previous session survives a failure.
+func (g *Ganrac) SaveSessionFile(fname string) error {
+	fp, err := os.CreateTemp(filepath.Dir(fname), filepath.Base(fname)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := fp.Name()
+	err = g.SaveSession(fp)
+	if cerr := fp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, fname)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// LoadSessionFile restores the session saved in fname.
+func (g *Ganrac) LoadSessionFile(fname string) error {
+	fp, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	return g.LoadSession(fp)
+}
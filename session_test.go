@@ -0,0 +1,274 @@
+package ganrac
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSession(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []string{
+		init_var_funcname + "(x,y,z);",
+		"A = x^2+3*y-1/2;",
+		"B = [1, -3/7, [y>0, \"str\"]];",
+		"C = {\"a\": [1,2], \"b\": x+y};",
+		"D = ex([z], z^2+x*z+y==0 && (x>0 || y!=0));",
+		"E = intv(1, 3/2);",
+		"R = (x+1)/(y^2-2*z);",
+		"W = abs(x-y)+z;",
+		"S = realalg(x^3-2, 1, 2);",
+		"A*y;",
+	} {
+		if _, err := g.Eval(strings.NewReader(s)); err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s, err)
+			return
+		}
+	}
+
+	b := new(bytes.Buffer)
+	if err := g.SaveSession(b); err != nil {
+		t.Errorf("save: err=%s", err)
+		return
+	}
+	saved := b.String()
+
+	h := NewGANRAC()
+	if _, err := h.Eval(strings.NewReader(init_var_funcname + "(a,b);")); err != nil {
+		t.Errorf("err=%s", err)
+		return
+	}
+	if err := h.LoadSession(strings.NewReader(saved)); err != nil {
+		t.Errorf("load: err=%s", err)
+		return
+	}
+	// 読み込んだセッションを保存すると同じになる
+	b.Reset()
+	if err := h.SaveSession(b); err != nil {
+		t.Errorf("save: err=%s", err)
+	} else if b.String() != saved {
+		t.Errorf("save after load\nexpect=%s\nactual=%s", saved, b.String())
+	}
+
+	for _, s := range []string{"A;", "B;", "C;", "D;", "E;", "R;", "W;", "x+z;"} {
+		u, err := g.Eval(strings.NewReader(s))
+		if err != nil {
+			t.Errorf("input=%s: err=%s", s, err)
+			continue
+		}
+		v, err := h.Eval(strings.NewReader(s))
+		if err != nil {
+			t.Errorf("input=%s: err=%s", s, err)
+			continue
+		}
+		if d, ok := u.(*Dict); ok && !d.Equals(v) || !ok && fmt.Sprintf("%v", u) != fmt.Sprintf("%v", v) {
+			t.Errorf("input=%s: expect=%v, actual=%v", s, u, v)
+		}
+	}
+
+	// builtin
+	fname := filepath.Join(t.TempDir(), "session.json")
+	for i, s := range []string{
+		fmt.Sprintf("savesession(\"%s\");", fname),
+		init_var_funcname + "(a,b,c);",
+		"A = 0;",
+		fmt.Sprintf("loadsession(\"%s\");", fname),
+	} {
+		if _, err := g.Eval(strings.NewReader(s)); err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s, err)
+			return
+		}
+	}
+	u, err := g.Eval(strings.NewReader("A;"))
+	if err != nil || fmt.Sprintf("%v", u) != "3*y+x^2-1/2" {
+		t.Errorf("loadsession() failed: %v", u)
+	}
+
+	for i, s := range []string{
+		"",
+		"[]",
+		`{"format": "foo", "version": 1}`,
+		`{"format": "ganrac-session", "version": 999}`,
+		`{"format": "ganrac-session", "version": 1, "vars": ["x", "x"]}`,
+		`{"format": "ganrac-session", "version": 1, "vars": ["x"], "values": {"A": ["p", 3, [["i", "1"], ["i", "1"]]]}}`,
+		`{"format": "ganrac-session", "version": 1, "vars": ["x"], "values": {"A": ["cad", 0]}}`,
+		`{"format": "ganrac-session", "version": 1, "vars": ["x"], "values": {"A": ["zzz"]}}`,
+		`{"format": "ganrac-session", "version": 1, "vars": ["x"], "values": {"A": ["f", ["i", "1"], ["p", 0, [["i", "0"], ["i", "1"]]]]}}`,
+		`{"format": "ganrac-session", "version": 2, "vars": ["x"], "values": {"A": ["zzz", 1]}}`,
+		`{"format": "ganrac-session", "version": 2, "vars": ["x"], "radicals": [{"p": ["i", "2"], "k": 2}]}`,
+		`{"format": "ganrac-session", "version": 3, "vars": ["x"], "radicals": [{"p": ["i", "2"], "k": 1}]}`,
+		`{"format": "ganrac-session", "version": 3, "vars": ["x"], "radicals": [{"p": ["s", "2"], "k": 2}]}`,
+		`{"format": "ganrac-session", "version": 3, "vars": ["x"], "radicals": [{"p": ["p", 1, [["i", "1"], ["i", "1"]]], "k": 2}]}`,
+		`{"format": "ganrac-session", "version": 3, "vars": ["x"], "radicals": [{"p": ["i", "2"], "k": 2}], "values": {"A": ["p", 2, [["i", "1"], ["i", "1"]]]}}`,
+	} {
+		if err := h.LoadSession(strings.NewReader(s)); err == nil {
+			t.Errorf("%d: input=%s: error is expected", i, s)
+		}
+	}
+	// 失敗しても変数は壊れない
+	u, err = h.Eval(strings.NewReader("A;"))
+	if err != nil || fmt.Sprintf("%v", u) != "3*y+x^2-1/2" {
+		t.Errorf("broken after failure: %v", u)
+	}
+}
+
+func TestSessionRadical(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []string{
+		init_var_funcname + "(x,y);",
+		"A = sqrt(2)*x;",
+		"B = root(y^2+1, 3)+sqrt(sqrt(2)+1);",
+		"C = [sqrt(2), x > sqrt(3)];",
+	} {
+		if _, err := g.Eval(strings.NewReader(s)); err != nil {
+			t.Fatalf("%d: input=%s: err=%s", i, s, err)
+		}
+	}
+	b := new(bytes.Buffer)
+	if err := g.SaveSession(b); err != nil {
+		t.Fatalf("save: err=%s", err)
+	}
+	saved := b.String()
+	expect := make([]string, 0)
+	for _, s := range []string{"A;", "B;", "C;", "A^2;", "A*sqrt(2);"} {
+		u, err := g.Eval(strings.NewReader(s))
+		if err != nil {
+			t.Fatalf("input=%s: err=%s", s, err)
+		}
+		expect = append(expect, fmt.Sprintf("%v", u))
+	}
+
+	// 新しいプロセスと同じく, 冪根のない状態から読み込む
+	h := NewGANRAC()
+	if _, err := h.Eval(strings.NewReader(init_var_funcname + "(a);")); err != nil {
+		t.Fatalf("err=%s", err)
+	}
+	if len(radlist) != 0 {
+		t.Fatalf("radicals are not reset: %v", radlist)
+	}
+	if err := h.LoadSession(strings.NewReader(saved)); err != nil {
+		t.Fatalf("load: err=%s\n%s", err, saved)
+	}
+	// 読み込んだセッションを保存すると同じになる
+	b.Reset()
+	if err := h.SaveSession(b); err != nil {
+		t.Errorf("save: err=%s", err)
+	} else if b.String() != saved {
+		t.Errorf("save after load\nexpect=%s\nactual=%s", saved, b.String())
+	}
+	for i, s := range []string{"A;", "B;", "C;", "A^2;", "A*sqrt(2);"} {
+		v, err := h.Eval(strings.NewReader(s))
+		if err != nil {
+			t.Errorf("input=%s: err=%s", s, err)
+		} else if fmt.Sprintf("%v", v) != expect[i] {
+			t.Errorf("input=%s: expect=%s, actual=%v", s, expect[i], v)
+		}
+	}
+
+	// 失敗しても冪根は壊れない
+	bad := `{"format": "ganrac-session", "version": 3, "vars": ["x"], "radicals": [{"p": ["i", "5"], "k": 2}], "values": {"A": ["zzz"]}}`
+	if err := h.LoadSession(strings.NewReader(bad)); err == nil {
+		t.Errorf("error is expected")
+	}
+	if v, err := h.Eval(strings.NewReader("A*sqrt(2);")); err != nil || fmt.Sprintf("%v", v) != expect[4] {
+		t.Errorf("broken after failure: %v, %v", v, err)
+	}
+}
+
+func TestSessionCAD(t *testing.T) {
+	g := NewGANRAC()
+	connc, connd := testConnectOx(g)
+	if g.ox == nil {
+		fmt.Printf("skip TestSessionCAD... (no ox)\n")
+		return
+	}
+	defer connc.Close()
+	defer connd.Close()
+
+	for _, proj := range []int{0, 1} {
+		for i, s := range []string{
+			init_var_funcname + "(c,b,a,x);",
+			"C = cadinit(ex([x], a*x^2+b*x+c==0));",
+			fmt.Sprintf("cadproj(C, %d);", proj),
+			"cadlift(C, 1);", // 途中まで
+		} {
+			if _, err := g.Eval(strings.NewReader(s)); err != nil {
+				t.Errorf("%d,%d: input=%s: err=%s", proj, i, s, err)
+				return
+			}
+		}
+
+		b := new(bytes.Buffer)
+		if err := g.SaveSession(b); err != nil {
+			t.Errorf("%d: save: err=%s", proj, err)
+			return
+		}
+		saved := b.String()
+
+		h := NewGANRAC()
+		h.ox = g.ox
+		if err := h.LoadSession(strings.NewReader(saved)); err != nil {
+			t.Errorf("%d: load: err=%s", proj, err)
+			return
+		}
+		b.Reset()
+		if err := h.SaveSession(b); err != nil || b.String() != saved {
+			t.Errorf("%d: save after load: err=%v", proj, err)
+		}
+
+		// 続きを持ち上げる
+		for _, gg := range []*Ganrac{g, h} {
+			for _, s := range []string{"cadlift(C);", "F = cadsfc(C);"} {
+				if _, err := gg.Eval(strings.NewReader(s)); err != nil {
+					t.Errorf("%d: input=%s: err=%s", proj, s, err)
+					return
+				}
+			}
+		}
+		u, _ := g.Eval(strings.NewReader("F;"))
+		v, _ := h.Eval(strings.NewReader("F;"))
+		if fmt.Sprintf("%v", u) != fmt.Sprintf("%v", v) {
+			t.Errorf("%d: expect=%v, actual=%v", proj, u, v)
+		}
+	}
+}
+
+func TestSessionCodec(t *testing.T) {
+	g := NewGANRAC()
+
+	// 未対応のオブジェクトは panic せずにエラーにする
+	w := &Piecewise{cond: []Fof{trueObj}, val: []RObj{one}}
+	if v, err := newGobjEncoder().encodeRObj(w); err == nil {
+		t.Errorf("encode: error is expected: %v", v)
+	}
+
+	// 有理数の realalg は正規化する
+	dec := &gobjDecoder{g: g, version: SESSION_VERSION}
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{`["g", ["p", 0, [["i", "-1"], ["i", "3"]]], ["b", "0", 0]]`, "1/3"},
+		{`["g", ["p", 0, [["i", "-2"], ["i", "0"], ["i", "2"]]], ["b", "0", 1]]`, "1"},
+		{`["g", ["p", 0, [["i", "-4"], ["i", "0"], ["i", "2"]]], ["b", "0", 1]]`, "realalg(x^2-2, 11/8, 3/2)"},
+	} {
+		u, err := dec.unmarshal([]byte(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		if str := fmt.Sprintf("%v", u); str != s.expect {
+			t.Errorf("%d: input=%s: expect=%s, actual=%s", i, s.input, s.expect, str)
+		}
+	}
+	for i, s := range []string{
+		`["g", ["p", 0, [["i", "2"], ["i", "-3"], ["i", "1"]]], ["b", "0", 2]]`, // 根が 2 つ
+		`["g", ["p", 0, [["i", "-1"], ["i", "3"]]], ["b", "1", 0]]`,             // 根がない
+	} {
+		if u, err := dec.unmarshal([]byte(s)); err == nil {
+			t.Errorf("%d: input=%s: error is expected: %v", i, s, u)
+		}
+	}
+}
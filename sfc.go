@@ -461,6 +461,12 @@ func (sfc *CADSfc) simplesf(la []*sfcAtom) Fof {
 }
 
 func (cad *CAD) Sfc() (Fof, error) {
+	return cad.sfc(true)
+}
+
+// sfc は解の論理式を構成する.
+// pdf = false なら射影因子を追加せず, projection definable でなければエラーを返す
+func (cad *CAD) sfc(pdf bool) (Fof, error) {
 	if cad.root.truth == t_false {
 		return falseObj, nil
 	} else if cad.root.truth == t_true {
@@ -480,6 +486,9 @@ func (cad *CAD) Sfc() (Fof, error) {
 	}
 	for ccc := 0; t != SFC_PROJ_DEFINABLE; ccc++ {
 		if t == SFC_PROJ_UNDEFINABLE && ccc != 0 {
+			if !pdf {
+				return nil, CAD_NO_PDF
+			}
 			return sfc.make_pdf()
 		} else {
 			// partial CAD な部分でひかかったので，
@@ -0,0 +1,844 @@
+package ganrac
+
+// SMT-LIB 2.6
+// Clark Barrett, Pascal Fontaine, and Cesare Tinelli.
+// The SMT-LIB Standard: Version 2.6
+// http://smtlib.cs.uiowa.edu/
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+type smt2Sexp struct {
+	atom string // list なら ""
+	str  bool   // string literal
+	list []*smt2Sexp
+	line int
+}
+
+type smt2Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+type smt2Script struct {
+	g       *Ganrac
+	names   []string               // 変数名. index = level
+	lvmap   map[string]Level       // declare-fun で宣言された変数
+	bound   map[string]Level       // 量化子で束縛された変数. 同名なら同じレベルを使う
+	defs    map[string]interface{} // define-fun
+	asserts []Fof
+	scopes  []int // push/pop
+	logic   string
+}
+
+func newSmt2Reader(r io.Reader) *smt2Reader {
+	sr := new(smt2Reader)
+	sr.r = bufio.NewReader(r)
+	sr.line = 1
+	return sr
+}
+
+func (sr *smt2Reader) peek() (rune, error) {
+	c, _, err := sr.r.ReadRune()
+	if err != nil {
+		return 0, err
+	}
+	sr.r.UnreadRune()
+	return c, nil
+}
+
+func (sr *smt2Reader) readRune() (rune, error) {
+	c, _, err := sr.r.ReadRune()
+	if c == '\n' {
+		sr.line++
+	}
+	return c, err
+}
+
+func (sr *smt2Reader) skipSpace() error {
+	for {
+		c, err := sr.peek()
+		if err != nil {
+			return err
+		}
+		if c == ';' { // 改行までコメント
+			for c != '\n' {
+				if c, err = sr.readRune(); err != nil {
+					return err
+				}
+			}
+		} else if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			sr.readRune()
+		} else {
+			return nil
+		}
+	}
+}
+
+// next returns the next s-expression. io.EOF at the end of input.
+func (sr *smt2Reader) next() (*smt2Sexp, error) {
+	if err := sr.skipSpace(); err != nil {
+		return nil, err
+	}
+	e := &smt2Sexp{line: sr.line}
+	c, _ := sr.readRune()
+	switch c {
+	case '(':
+		e.list = make([]*smt2Sexp, 0)
+		for {
+			if err := sr.skipSpace(); err != nil {
+				return nil, fmt.Errorf("smt2:%d: unexpected EOF", e.line)
+			}
+			if c, _ := sr.peek(); c == ')' {
+				sr.readRune()
+				return e, nil
+			}
+			v, err := sr.next()
+			if err != nil {
+				return nil, err
+			}
+			e.list = append(e.list, v)
+		}
+	case ')':
+		return nil, fmt.Errorf("smt2:%d: unexpected ')'", e.line)
+	case '"':
+		e.str = true
+		var sb strings.Builder
+		for {
+			c, err := sr.readRune()
+			if err != nil {
+				return nil, fmt.Errorf("smt2:%d: unterminated string", e.line)
+			}
+			if c == '"' {
+				if c2, _ := sr.peek(); c2 != '"' {
+					break
+				}
+				sr.readRune() // "" は " のエスケープ
+			}
+			sb.WriteRune(c)
+		}
+		e.atom = sb.String()
+		return e, nil
+	case '|':
+		var sb strings.Builder
+		for {
+			c, err := sr.readRune()
+			if err != nil {
+				return nil, fmt.Errorf("smt2:%d: unterminated symbol", e.line)
+			}
+			if c == '|' {
+				break
+			}
+			sb.WriteRune(c)
+		}
+		e.atom = sb.String()
+		return e, nil
+	}
+
+	var sb strings.Builder
+	sb.WriteRune(c)
+	for {
+		c, err := sr.peek()
+		if err != nil || c == '(' || c == ')' || c == ';' || c == '"' ||
+			c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			break
+		}
+		sr.readRune()
+		sb.WriteRune(c)
+	}
+	e.atom = sb.String()
+	return e, nil
+}
+
+func (e *smt2Sexp) isList() bool {
+	return e.list != nil
+}
+
+func (e *smt2Sexp) head() string {
+	if len(e.list) == 0 || e.list[0].isList() {
+		return ""
+	}
+	return e.list[0].atom
+}
+
+func (e *smt2Sexp) String() string {
+	if !e.isList() {
+		if e.str {
+			return "\"" + e.atom + "\""
+		}
+		return e.atom
+	}
+	s := make([]string, len(e.list))
+	for i, v := range e.list {
+		s[i] = v.String()
+	}
+	return "(" + strings.Join(s, " ") + ")"
+}
+
+func newSmt2Script(g *Ganrac) *smt2Script {
+	s := new(smt2Script)
+	s.g = g
+	s.lvmap = make(map[string]Level)
+	s.bound = make(map[string]Level)
+	s.defs = make(map[string]interface{})
+	return s
+}
+
+func (s *smt2Script) errorf(e *smt2Sexp, format string, a ...interface{}) error {
+	return fmt.Errorf("smt2:%d: %s", e.line, fmt.Sprintf(format, a...))
+}
+
+// declare は自由変数 name を宣言する
+func (s *smt2Script) declare(e *smt2Sexp, name string) (Level, error) {
+	if _, ok := s.lvmap[name]; ok {
+		return 0, s.errorf(e, "`%s` is already declared", name)
+	}
+	if _, ok := s.defs[name]; ok {
+		return 0, s.errorf(e, "`%s` is already defined", name)
+	}
+	lv, ok := s.bound[name]
+	if !ok {
+		var err error
+		if lv, err = s.newVar(e, name); err != nil {
+			return 0, err
+		}
+	}
+	s.lvmap[name] = lv
+	return lv, nil
+}
+
+// newVar は変数順序の末尾に name を追加する
+func (s *smt2Script) newVar(e *smt2Sexp, name string) (Level, error) {
+	names := append(s.names, name)
+	if err := s.g.InitVarList(names); err != nil {
+		return 0, s.errorf(e, "%s", err.Error())
+	}
+	s.names = names
+	return Level(len(s.names) - 1), nil
+}
+
+func (s *smt2Script) sortReal(e *smt2Sexp) error {
+	if e.isList() || e.atom != "Real" {
+		return s.errorf(e, "unsupported sort `%v`", e)
+	}
+	return nil
+}
+
+func (s *smt2Script) robj(e *smt2Sexp, env map[string]interface{}) (RObj, error) {
+	v, err := s.term(e, env)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := v.(RObj)
+	if !ok {
+		return nil, s.errorf(e, "expected a real term: %v", e)
+	}
+	return r, nil
+}
+
+func (s *smt2Script) fof(e *smt2Sexp, env map[string]interface{}) (Fof, error) {
+	v, err := s.term(e, env)
+	if err != nil {
+		return nil, err
+	}
+	f, ok := v.(Fof)
+	if !ok {
+		return nil, s.errorf(e, "expected a formula: %v", e)
+	}
+	return f, nil
+}
+
+func (s *smt2Script) args(e *smt2Sexp, env map[string]interface{}, min int) ([]interface{}, error) {
+	if len(e.list)-1 < min {
+		return nil, s.errorf(e, "too few arguments: %v", e)
+	}
+	ret := make([]interface{}, len(e.list)-1)
+	for i, a := range e.list[1:] {
+		v, err := s.term(a, env)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
+
+func (s *smt2Script) robjs(e *smt2Sexp, env map[string]interface{}, min int) ([]RObj, error) {
+	ret := make([]RObj, len(e.list)-1)
+	if len(ret) < min {
+		return nil, s.errorf(e, "too few arguments: %v", e)
+	}
+	for i, a := range e.list[1:] {
+		v, err := s.robj(a, env)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
+
+func (s *smt2Script) fofs(e *smt2Sexp, env map[string]interface{}, min int) ([]Fof, error) {
+	ret := make([]Fof, len(e.list)-1)
+	if len(ret) < min {
+		return nil, s.errorf(e, "too few arguments: %v", e)
+	}
+	for i, a := range e.list[1:] {
+		v, err := s.fof(a, env)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
+
+// numeral は <numeral> と <decimal> を数に変換する.
+//
+//	<numeral> ::= 0 | [1-9][0-9]*
+//	<decimal> ::= <numeral>.0*<numeral>
+func (s *smt2Script) numeral(str string) (RObj, bool) {
+	ip, fp, dec := strings.Cut(str, ".")
+	if ip == "" || len(ip) > 1 && ip[0] == '0' || dec && fp == "" {
+		return nil, false
+	}
+	for _, c := range ip + fp {
+		if c < '0' || '9' < c {
+			return nil, false
+		}
+	}
+	r, ok := new(big.Rat).SetString(str)
+	if !ok {
+		return nil, false
+	}
+	if r.IsInt() {
+		return NewIntZ(r.Num()), true
+	}
+	v := newRat()
+	v.n = r
+	return v, true
+}
+
+func (s *smt2Script) symbol(e *smt2Sexp, env map[string]interface{}) (interface{}, error) {
+	if v, ok := env[e.atom]; ok {
+		return v, nil
+	}
+	if v, ok := s.defs[e.atom]; ok {
+		return v, nil
+	}
+	if lv, ok := s.lvmap[e.atom]; ok {
+		return NewPolyVar(lv), nil
+	}
+	switch e.atom {
+	case "true":
+		return trueObj, nil
+	case "false":
+		return falseObj, nil
+	}
+	if v, ok := s.numeral(e.atom); ok {
+		return v, nil
+	}
+	return nil, s.errorf(e, "unknown symbol `%s`", e.atom)
+}
+
+func (s *smt2Script) compare(e *smt2Sexp, env map[string]interface{}, op OP) (Fof, error) {
+	rs, err := s.robjs(e, env, 2)
+	if err != nil {
+		return nil, err
+	}
+	// chainable: (< a b c) <==> a < b && b < c
+	var f Fof = trueObj
+	for i := 1; i < len(rs); i++ {
+		f = NewFmlAnd(f, NewAtom(Sub(rs[i-1], rs[i]), op))
+	}
+	return f, nil
+}
+
+func (s *smt2Script) quantifier(e *smt2Sexp, env map[string]interface{}, forex bool) (Fof, error) {
+	if len(e.list) != 3 || !e.list[1].isList() || len(e.list[1].list) == 0 {
+		return nil, s.errorf(e, "invalid quantifier")
+	}
+	lvs := make([]Level, 0, len(e.list[1].list))
+	// 束縛変数はスコープ内でのみ参照できる
+	env2 := make(map[string]interface{}, len(env)+len(e.list[1].list))
+	for k, v := range env {
+		env2[k] = v
+	}
+	for _, b := range e.list[1].list {
+		if len(b.list) != 2 || b.list[0].isList() {
+			return nil, s.errorf(b, "invalid sorted var: %v", b)
+		}
+		if err := s.sortReal(b.list[1]); err != nil {
+			return nil, err
+		}
+		name := b.list[0].atom
+		if _, ok := env2[name]; ok {
+			return nil, s.errorf(b, "shadowing `%s` is not supported", name)
+		}
+		if _, ok := s.lvmap[name]; ok {
+			return nil, s.errorf(b, "shadowing `%s` is not supported", name)
+		}
+		if _, ok := s.defs[name]; ok {
+			return nil, s.errorf(b, "shadowing `%s` is not supported", name)
+		}
+		lv, ok := s.bound[name]
+		if !ok {
+			var err error
+			if lv, err = s.newVar(b, name); err != nil {
+				return nil, err
+			}
+			s.bound[name] = lv
+		}
+		env2[name] = NewPolyVar(lv)
+		lvs = append(lvs, lv)
+	}
+	f, err := s.fof(e.list[2], env2)
+	if err != nil {
+		return nil, err
+	}
+	return NewQuantifier(forex, lvs, f), nil
+}
+
+func (s *smt2Script) let(e *smt2Sexp, env map[string]interface{}) (interface{}, error) {
+	if len(e.list) != 3 || !e.list[1].isList() {
+		return nil, s.errorf(e, "invalid let")
+	}
+	env2 := make(map[string]interface{}, len(env)+len(e.list[1].list))
+	for k, v := range env {
+		env2[k] = v
+	}
+	// 並列束縛なので，元の env で評価する
+	for _, b := range e.list[1].list {
+		if len(b.list) != 2 || b.list[0].isList() {
+			return nil, s.errorf(b, "invalid binding: %v", b)
+		}
+		v, err := s.term(b.list[1], env)
+		if err != nil {
+			return nil, err
+		}
+		env2[b.list[0].atom] = v
+	}
+	return s.term(e.list[2], env2)
+}
+
+func (s *smt2Script) term(e *smt2Sexp, env map[string]interface{}) (interface{}, error) {
+	if !e.isList() {
+		if e.str {
+			return nil, s.errorf(e, "unexpected string")
+		}
+		return s.symbol(e, env)
+	}
+	if len(e.list) == 0 {
+		return nil, s.errorf(e, "unexpected ()")
+	}
+
+	switch e.head() {
+	case "+":
+		rs, err := s.robjs(e, env, 1)
+		if err != nil {
+			return nil, err
+		}
+		var r RObj = zero
+		for _, x := range rs {
+			r = Add(r, x)
+		}
+		return r, nil
+	case "-":
+		rs, err := s.robjs(e, env, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(rs) == 1 {
+			return rs[0].Neg(), nil
+		}
+		r := rs[0]
+		for _, x := range rs[1:] {
+			r = Sub(r, x)
+		}
+		return r, nil
+	case "*":
+		rs, err := s.robjs(e, env, 1)
+		if err != nil {
+			return nil, err
+		}
+		var r RObj = one
+		for _, x := range rs {
+			r = Mul(r, x)
+		}
+		return r, nil
+	case "/":
+		rs, err := s.robjs(e, env, 2)
+		if err != nil {
+			return nil, err
+		}
+		r := rs[0]
+		for i, x := range rs[1:] {
+			c, ok := x.(NObj)
+			if !ok {
+				return nil, s.errorf(e.list[i+2], "division by a non-constant is not supported")
+			}
+			if c.IsZero() {
+				return nil, s.errorf(e.list[i+2], "divide by zero")
+			}
+			r = r.Div(c)
+		}
+		return r, nil
+	case "to_real":
+		if len(e.list) != 2 {
+			return nil, s.errorf(e, "invalid to_real")
+		}
+		return s.robj(e.list[1], env)
+	case "<":
+		return s.compare(e, env, LT)
+	case "<=":
+		return s.compare(e, env, LE)
+	case ">":
+		return s.compare(e, env, GT)
+	case ">=":
+		return s.compare(e, env, GE)
+	case "=":
+		as, err := s.args(e, env, 2)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := as[0].(Fof); ok {
+			var f Fof = trueObj
+			for i := 1; i < len(as); i++ {
+				f0, ok0 := as[i-1].(Fof)
+				f1, ok1 := as[i].(Fof)
+				if !ok0 || !ok1 {
+					return nil, s.errorf(e, "sort mismatch: %v", e)
+				}
+				f = NewFmlAnd(f, FofEquiv(f0, f1))
+			}
+			return f, nil
+		}
+		return s.compare(e, env, EQ)
+	case "distinct":
+		rs, err := s.robjs(e, env, 2)
+		if err != nil {
+			return nil, err
+		}
+		var f Fof = trueObj
+		for i := 0; i < len(rs); i++ {
+			for j := i + 1; j < len(rs); j++ {
+				f = NewFmlAnd(f, NewAtom(Sub(rs[i], rs[j]), NE))
+			}
+		}
+		return f, nil
+	case "and":
+		fs, err := s.fofs(e, env, 1)
+		if err != nil {
+			return nil, err
+		}
+		return newFmlAnds(fs...), nil
+	case "or":
+		fs, err := s.fofs(e, env, 1)
+		if err != nil {
+			return nil, err
+		}
+		return newFmlOrs(fs...), nil
+	case "not":
+		fs, err := s.fofs(e, env, 1)
+		if err != nil {
+			return nil, err
+		} else if len(fs) != 1 {
+			return nil, s.errorf(e, "invalid not")
+		}
+		return fs[0].Not(), nil
+	case "=>":
+		fs, err := s.fofs(e, env, 2)
+		if err != nil {
+			return nil, err
+		}
+		// right assoc
+		f := fs[len(fs)-1]
+		for i := len(fs) - 2; i >= 0; i-- {
+			f = FofImpl(fs[i], f)
+		}
+		return f, nil
+	case "xor":
+		fs, err := s.fofs(e, env, 2)
+		if err != nil {
+			return nil, err
+		}
+		f := fs[0]
+		for _, f1 := range fs[1:] {
+			f = FofEquiv(f, f1).Not()
+		}
+		return f, nil
+	case "ite":
+		fs, err := s.args(e, env, 3)
+		if err != nil {
+			return nil, err
+		} else if len(fs) != 3 {
+			return nil, s.errorf(e, "invalid ite")
+		}
+		c, ok0 := fs[0].(Fof)
+		f1, ok1 := fs[1].(Fof)
+		f2, ok2 := fs[2].(Fof)
+		if !ok0 || !ok1 || !ok2 {
+			return nil, s.errorf(e, "ite over terms is not supported")
+		}
+		return NewFmlOr(NewFmlAnd(c, f1), NewFmlAnd(c.Not(), f2)), nil
+	case "let":
+		return s.let(e, env)
+	case "forall":
+		return s.quantifier(e, env, true)
+	case "exists":
+		return s.quantifier(e, env, false)
+	case "!":
+		if len(e.list) < 2 {
+			return nil, s.errorf(e, "invalid annotation")
+		}
+		return s.term(e.list[1], env)
+	}
+	return nil, s.errorf(e, "unsupported term: %v", e)
+}
+
+func (s *smt2Script) fml() Fof {
+	return newFmlAnds(s.asserts...)
+}
+
+// command executes an SMT-LIB2 command.
+// check-sat is delegated to checksat. returns io.EOF on (exit).
+func (s *smt2Script) command(e *smt2Sexp, checksat func(f Fof) error) error {
+	if !e.isList() || len(e.list) == 0 {
+		return s.errorf(e, "command is expected: %v", e)
+	}
+	switch e.head() {
+	case "set-logic":
+		if len(e.list) != 2 {
+			return s.errorf(e, "invalid set-logic")
+		}
+		s.logic = e.list[1].atom
+	case "set-info", "set-option", "get-info", "echo":
+		// ignore
+	case "declare-const":
+		if len(e.list) != 3 || e.list[1].isList() {
+			return s.errorf(e, "invalid declare-const")
+		}
+		if err := s.sortReal(e.list[2]); err != nil {
+			return err
+		}
+		if _, err := s.declare(e, e.list[1].atom); err != nil {
+			return err
+		}
+	case "declare-fun":
+		if len(e.list) != 4 || e.list[1].isList() {
+			return s.errorf(e, "invalid declare-fun")
+		}
+		if !e.list[2].isList() || len(e.list[2].list) != 0 {
+			return s.errorf(e, "uninterpreted function is not supported")
+		}
+		if err := s.sortReal(e.list[3]); err != nil {
+			return err
+		}
+		if _, err := s.declare(e, e.list[1].atom); err != nil {
+			return err
+		}
+	case "define-fun":
+		if len(e.list) != 5 || e.list[1].isList() {
+			return s.errorf(e, "invalid define-fun")
+		}
+		if !e.list[2].isList() || len(e.list[2].list) != 0 {
+			return s.errorf(e, "define-fun with arguments is not supported")
+		}
+		name := e.list[1].atom
+		if _, ok := s.lvmap[name]; ok {
+			return s.errorf(e, "`%s` is already declared", name)
+		}
+		v, err := s.term(e.list[4], nil)
+		if err != nil {
+			return err
+		}
+		switch e.list[3].atom {
+		case "Real":
+			if _, ok := v.(RObj); !ok {
+				return s.errorf(e, "sort mismatch: %s", name)
+			}
+		case "Bool":
+			if _, ok := v.(Fof); !ok {
+				return s.errorf(e, "sort mismatch: %s", name)
+			}
+		default:
+			return s.errorf(e, "unsupported sort `%v`", e.list[3])
+		}
+		s.defs[name] = v
+	case "assert":
+		if len(e.list) != 2 {
+			return s.errorf(e, "invalid assert")
+		}
+		f, err := s.fof(e.list[1], nil)
+		if err != nil {
+			return err
+		}
+		s.asserts = append(s.asserts, f)
+	case "push":
+		n, err := s.level(e)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n; i++ {
+			s.scopes = append(s.scopes, len(s.asserts))
+		}
+	case "pop":
+		n, err := s.level(e)
+		if err != nil {
+			return err
+		}
+		if n > len(s.scopes) {
+			return s.errorf(e, "pop: too many levels")
+		}
+		m := len(s.scopes) - n
+		s.asserts = s.asserts[:s.scopes[m]]
+		s.scopes = s.scopes[:m]
+	case "check-sat":
+		if checksat != nil {
+			return checksat(s.fml())
+		}
+	case "exit":
+		return io.EOF
+	default:
+		return s.errorf(e, "unsupported command: %s", e.head())
+	}
+	return nil
+}
+
+func (s *smt2Script) level(e *smt2Sexp) (int, error) {
+	if len(e.list) == 1 {
+		return 1, nil
+	}
+	if len(e.list) == 2 {
+		if v, ok := s.numeral(e.list[1].atom); ok {
+			if n, ok := v.(*Int); ok && n.IsInt64() {
+				return int(n.Int64()), nil
+			}
+		}
+	}
+	return 0, s.errorf(e, "invalid %s", e.head())
+}
+
+func (s *smt2Script) run(r io.Reader, checksat func(f Fof) error) error {
+	sr := newSmt2Reader(r)
+	for {
+		e, err := sr.next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		err = s.command(e, checksat)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// LoadSMT2 reads an SMT-LIB2 script and returns the conjunction of
+// its assertions.  The variable order is reset to the declared
+// constants followed by the quantified variables.
+func (g *Ganrac) LoadSMT2(r io.Reader) (Fof, error) {
+	s := newSmt2Script(g)
+	if err := s.run(r, nil); err != nil {
+		return nil, err
+	}
+	return s.fml(), nil
+}
+
+// ExecSMT2 runs an SMT-LIB2 script and writes the answer of each
+// (check-sat) to w.
+func (g *Ganrac) ExecSMT2(r io.Reader, w io.Writer) error {
+	s := newSmt2Script(g)
+	return s.run(r, func(f Fof) error {
+		ans, err := g.CheckSat(f)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\n", ans)
+		return nil
+	})
+}
+
+// CheckSat decides whether fof is satisfiable over the reals.
+// returns "sat" or "unsat".
+func (g *Ganrac) CheckSat(fof Fof) (string, error) {
+	if g.ox == nil {
+		return "", fmt.Errorf("ox is required")
+	}
+	lvs := make([]Level, 0)
+	for lv := Level(0); lv < fof.maxVar(); lv++ {
+		if fof.hasFreeVar(lv) {
+			lvs = append(lvs, lv)
+		}
+	}
+	fml := NewQuantifier(false, lvs, fof)
+	switch g.QE(fml, NewQEopt()).(type) {
+	case *AtomT:
+		return "sat", nil
+	case *AtomF:
+		return "unsat", nil
+	}
+	return "unknown", nil
+}
+
+// FprintSMT2 writes fof as an SMT-LIB2 script.
+func FprintSMT2(b io.Writer, fof Fof) {
+	logic := "NRA"
+	if fof.IsQff() {
+		logic = "QF_NRA"
+	}
+	fmt.Fprintf(b, "(set-logic %s)\n", logic)
+	for lv := Level(0); lv < fof.maxVar(); lv++ {
+		if fof.hasFreeVar(lv) {
+			fmt.Fprintf(b, "(declare-fun %s () Real)\n", varstr(lv))
+		}
+	}
+	fmt.Fprintf(b, "(assert %M)\n", fof)
+	fmt.Fprintf(b, "(check-sat)\n")
+}
+
+func (z *Poly) write_smt2(b io.Writer) {
+	terms := 0
+	for _, c := range z.c {
+		if !c.IsZero() {
+			terms++
+		}
+	}
+	if terms > 1 {
+		fmt.Fprintf(b, "(+")
+	}
+	for i := len(z.c) - 1; i >= 0; i-- {
+		c := z.c[i]
+		if c.IsZero() {
+			continue
+		}
+		if terms > 1 {
+			fmt.Fprintf(b, " ")
+		}
+		if i == 0 {
+			fmt.Fprintf(b, "%M", c)
+		} else if i == 1 && c.IsOne() {
+			fmt.Fprintf(b, "%s", varstr(z.lv))
+		} else {
+			fmt.Fprintf(b, "(*")
+			if !c.IsOne() {
+				fmt.Fprintf(b, " %M", c)
+			}
+			for j := 0; j < i; j++ {
+				fmt.Fprintf(b, " %s", varstr(z.lv))
+			}
+			fmt.Fprintf(b, ")")
+		}
+	}
+	if terms > 1 {
+		fmt.Fprintf(b, ")")
+	}
+}
@@ -0,0 +1,161 @@
+package ganrac
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSMT2Load(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{`(declare-fun x () Real)
+		  (declare-fun y () Real)
+		  (assert (> (+ (* x x) y) 1))`, "x^2+y>1"},
+		{`(set-logic QF_NRA)
+		  (declare-const x Real)
+		  (assert (< 0 x 1))
+		  (check-sat)
+		  (exit)`, "0<x && x<1"},
+		{`(declare-fun x () Real) ; comment
+		  (declare-fun y () Real)
+		  (assert (=> (>= x 0) (distinct y (- x 2.5))))`, "x<0 || y!=x-5/2"},
+		{`(declare-fun x () Real)
+		  (define-fun two () Real 2)
+		  (assert (let ((z (* two x)) (w 3)) (<= z (/ w 4))))`, "2*x<=3/4"},
+		{`(declare-fun a () Real)
+		  (declare-fun b () Real)
+		  (assert (exists ((x Real)) (= (+ (* x x) (* a x) b) 0)))`, "ex([x], x^2+a*x+b==0)"},
+		{`(declare-fun x () Real)
+		  (assert (> x 0))
+		  (push 1)
+		  (assert (< x (- 1)))
+		  (pop 1)
+		  (assert (not (= x 3)))`, "x>0 && x!=3"},
+		{`(declare-fun |x| () Real)
+		  (assert (! (and true (or (> x 0) false)) :named a1))`, "x>0"},
+		{`(declare-fun x () Real)
+		  (assert (< 0.05 x 10.50 11))`, "1/20<x && x<21/2"},
+		{`(declare-fun a () Real)
+		  (assert (exists ((x Real)) (> (* a x) 1)))
+		  (assert (forall ((x Real)) (>= (* x x) a)))`, "ex([x], a*x>1) && all([x], x^2>=a)"},
+		{`(assert (exists ((x Real)) (> x 1)))
+		  (declare-fun x () Real)
+		  (assert (< x 0))`, "ex([x], x>1) && x<0"},
+	} {
+		f, err := g.LoadSMT2(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		u, err := g.Eval(strings.NewReader(s.expect + ";"))
+		if err != nil {
+			t.Errorf("%d: expect=%s: err=%s", i, s.expect, err)
+			continue
+		}
+		if !f.Equals(u) {
+			t.Errorf("%d: input=%s\nexpect=%v\nactual=%v", i, s.input, u, f)
+		}
+	}
+}
+
+func TestSMT2LoadError(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []string{
+		`(declare-fun x () Int)`,
+		`(declare-fun f (Real) Real)`,
+		`(declare-fun x () Real) (declare-fun x () Real)`,
+		`(declare-fun x () Real) (assert (> y 0))`,
+		`(declare-fun x () Real) (assert (> (/ 1 x) 0))`,
+		`(declare-fun x () Real) (assert (> (/ x 0) 0))`,
+		`(declare-fun x () Real) (assert x)`,
+		`(declare-fun x () Real) (assert (> x 0)`,
+		`(declare-fun x () Real) (assert (exists ((x Real)) (> x 0)))`,
+		`(declare-fun a () Real) (assert (and (exists ((x Real)) (> x a)) (> x 0)))`,
+		`(declare-fun a () Real) (assert (exists ((x Real)) (> x a))) (assert (> x 0))`,
+		`(assert (exists ((x Real)) (> x 0))) (assert (forall ((y Real)) (> y x)))`,
+		`(declare-fun x () Real) (assert (> x 1e5))`,
+		`(declare-fun x () Real) (assert (> x 1/2))`,
+		`(declare-fun x () Real) (assert (> x 01))`,
+		`(declare-fun x () Real) (assert (> x 1.))`,
+		`(declare-fun x () Real) (assert (> x .5))`,
+		`(declare-fun x () Real) (assert (> x 1.5.2))`,
+		`(declare-fun x () Real) (assert (> x 0x10))`,
+		`(pop 1)`,
+		`(get-model)`,
+	} {
+		_, err := g.LoadSMT2(strings.NewReader(s))
+		if err == nil {
+			t.Errorf("%d: input=%s: error is expected", i, s)
+		}
+	}
+}
+
+func TestSMT2Print(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []string{
+		"x^2+y>1",
+		"-3*x*y+1/2<=0 && y != 0",
+		"x>=0 || y<-2/3",
+		"ex([z], z^2+x*z+y==0)",
+		"all([z], z^2+x*z+y>0)",
+		"true",
+	} {
+		g.InitVarList([]string{"x", "y", "z"})
+		u, err := g.Eval(strings.NewReader(s + ";"))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s, err)
+			continue
+		}
+		f := u.(Fof)
+		b := new(bytes.Buffer)
+		FprintSMT2(b, f)
+		v, err := g.LoadSMT2(b)
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s\n%s", i, s, err, b.String())
+			continue
+		}
+
+		// 変数順序がかわるので文字列で比較
+		if fmt.Sprintf("%v", v) != fmt.Sprintf("%v", f) {
+			t.Errorf("%d: input=%s\nexpect=%v\nactual=%v", i, s, f, v)
+		}
+	}
+}
+
+func TestSMT2CheckSat(t *testing.T) {
+	g := NewGANRAC()
+	connc, connd := testConnectOx(g)
+	if g.ox == nil {
+		fmt.Printf("skip TestSMT2CheckSat... (no ox)\n")
+		return
+	}
+	defer connc.Close()
+	defer connd.Close()
+
+	input := `
+	(set-logic QF_NRA)
+	(declare-fun x () Real)
+	(declare-fun y () Real)
+	(assert (< (+ (* x x) (* y y)) 1))
+	(check-sat)
+	(push 1)
+	(assert (> (* x y) 1))
+	(check-sat)
+	(pop 1)
+	(assert (> (+ x y) 1))
+	(check-sat)
+	(exit)`
+	b := new(bytes.Buffer)
+	if err := g.ExecSMT2(strings.NewReader(input), b); err != nil {
+		t.Errorf("err=%s", err)
+		return
+	}
+	if b.String() != "sat\nunsat\nsat\n" {
+		t.Errorf("expect=sat,unsat,sat actual=%s", b.String())
+	}
+}
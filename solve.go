@@ -0,0 +1,175 @@
+package ganrac
+
+// 1変数の限量子のない論理式の解集合を区間の和で求める.
+// 1 次元の CAD と同じことを行うが, 解を区間の列で返す.
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// solvePolys は f の原子論理式の多項式を返す. 限量子の中もみる
+func solvePolys(f Fof) []*Poly {
+	ret := make([]*Poly, 0)
+	stack := []Fof{f}
+	for len(stack) > 0 {
+		g := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		switch q := g.(type) {
+		case *Atom:
+			ret = append(ret, q.p...)
+		case FofAO:
+			stack = append(stack, q.Fmls()...)
+		case FofQ:
+			stack = append(stack, q.Fml())
+		}
+	}
+	return ret
+}
+
+// ratBetween は a < b を満たす a, b の間の簡単な有理数を返す
+func ratBetween(a, b NObj) NObj {
+	for {
+		lo, hi := a, b
+		if x, ok := a.(*RealAlg); ok {
+			_, lo = x.Interval()
+		}
+		if x, ok := b.(*RealAlg); ok {
+			hi, _ = x.Interval()
+		}
+		if lo.Cmp(hi) < 0 {
+			d := Sub(hi, lo).Div(four)
+			return newRatBig(simplestRat(toBigRat(Add(lo, d).(NObj)), toBigRat(Sub(hi, d).(NObj))))
+		}
+		if x, ok := a.(*RealAlg); ok {
+			x.refine()
+		}
+		if x, ok := b.(*RealAlg); ok {
+			x.refine()
+		}
+	}
+}
+
+// solveRoots は ps の実根を重複なく小さい順に返す
+func solveRoots(ps []*Poly) ([]NObj, error) {
+	roots := make([]NObj, 0)
+	for _, p := range ps {
+		rs, err := p.RealRoots()
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rs {
+			i := 0
+			c := 1
+			for ; i < len(roots); i++ {
+				if c = r.Cmp(roots[i]); c <= 0 {
+					break
+				}
+			}
+			if c == 0 {
+				continue
+			}
+			roots = append(roots, nil)
+			copy(roots[i+1:], roots[i:])
+			roots[i] = r
+		}
+	}
+	return roots, nil
+}
+
+func solveApprox(x NObj, inf string) string {
+	if x == nil {
+		return inf
+	}
+	return strconv.FormatFloat(x.Float(), 'g', 6, 64)
+}
+
+// Solve は1変数 x[lv] の限量子のない論理式 f を満たす区間のリストを返す.
+// 区間は [下端, 上端, "[)", "近似"] で表し, 無限大は "-inf", "inf".
+func Solve(f Fof, lv Level) (*List, error) {
+	if !f.IsQff() {
+		return nil, fmt.Errorf("quantifier-free formula is expected")
+	}
+	if hasOtherVar(f, lv) {
+		return nil, fmt.Errorf("univariate formula in %s is expected", varstr(lv))
+	}
+
+	roots, err := solveRoots(solvePolys(f))
+	if err != nil {
+		return nil, err
+	}
+
+	// セル 2i+1 が roots[i], セル 2i が roots[i] の左の区間
+	samples := make([]NObj, 2*len(roots)+1)
+	for i, r := range roots {
+		samples[2*i+1] = r
+		if i > 0 {
+			samples[2*i] = ratBetween(roots[i-1], r)
+		}
+	}
+	if len(roots) == 0 {
+		samples[0] = zero
+	} else {
+		lo := roots[0]
+		hi := roots[len(roots)-1]
+		if x, ok := lo.(*RealAlg); ok {
+			lo, _ = x.Interval()
+		}
+		if x, ok := hi.(*RealAlg); ok {
+			_, hi = x.Interval()
+		}
+		samples[0] = Sub(lo, one).(NObj)
+		samples[len(samples)-1] = Add(hi, one).(NObj)
+	}
+
+	truth := make([]bool, len(samples))
+	for i, s := range samples {
+		_, truth[i] = f.Subst(s, lv).(*AtomT)
+	}
+
+	ret := NewList()
+	for i := 0; i < len(samples); i++ {
+		if !truth[i] {
+			continue
+		}
+		j := i
+		for j+1 < len(samples) && truth[j+1] {
+			j++
+		}
+		// セル i からセル j まで
+		var lo, hi NObj
+		var lb, ub GObj
+		flag := ""
+		if i == 0 {
+			lb = NewString("-inf")
+			flag = "("
+		} else if i%2 == 0 {
+			lo = samples[i-1]
+			flag = "("
+		} else {
+			lo = samples[i]
+			flag = "["
+		}
+		if j == len(samples)-1 {
+			ub = NewString("inf")
+			flag += ")"
+		} else if j%2 == 0 {
+			hi = samples[j+1]
+			flag += ")"
+		} else {
+			hi = samples[j]
+			flag += "]"
+		}
+		if lo != nil {
+			lb = lo
+		}
+		if hi != nil {
+			ub = hi
+		}
+		approx := fmt.Sprintf("%c%s, %s%c", flag[0],
+			solveApprox(lo, "-inf"), solveApprox(hi, "inf"), flag[1])
+		ret.Append(NewList(lb, ub, NewString(flag), NewString(approx)))
+		i = j
+	}
+	return ret, nil
+}
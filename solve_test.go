@@ -0,0 +1,65 @@
+package ganrac
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSolve(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"solve(x^2 < 2, x);", `[[realalg(x^2-2, -3/2, -11/8), realalg(x^2-2, 11/8, 3/2), "()", "(-1.41421, 1.41421)"]]`},
+		{"solve(x^2 >= 1, x);", `[["-inf", -1, "(]", "(-inf, -1]"], [1, "inf", "[)", "[1, inf)"]]`},
+		{"solve(x^2 <= 0, x);", `[[0, 0, "[]", "[0, 0]"]]`},
+		{"solve(x^2 != 0, x);", `[["-inf", 0, "()", "(-inf, 0)"], [0, "inf", "()", "(0, inf)"]]`},
+		{"solve(3*x-1 > 0 && x < 2 || x == 5, x);", `[[1/3, 2, "()", "(0.333333, 2)"], [5, 5, "[]", "[5, 5]"]]`},
+		{"solve(x^2 < 0, x);", `[]`},
+		{"solve(true, x);", `[["-inf", "inf", "()", "(-inf, inf)"]]`},
+		{"solve(x^2 > 2 && x^2 < 3, x);", `[[realalg(x^2-3, -7/4, -13/8), realalg(x^2-2, -3/2, -11/8), "()", "(-1.73205, -1.41421)"], [realalg(x^2-2, 11/8, 3/2), realalg(x^2-3, 13/8, 7/4), "()", "(1.41421, 1.73205)"]]`},
+		{"solve(y^2-2*y <= 0, y);", `[[0, 2, "[]", "[0, 2]"]]`},
+	} {
+		u, err := g.Eval(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		if str := strings.ReplaceAll(u.(GObj).String(), " ", ""); str != strings.ReplaceAll(s.expect, " ", "") {
+			t.Errorf("%d: input=%s:\nexpect=%s\nactual=%s", i, s.input, s.expect, str)
+		}
+	}
+
+	for i, s := range []string{
+		"solve(x+y > 0, x);",
+		"solve(ex([y], x*y > 1), x);",
+		"solve(x > 0, 1);",
+		"solve(x, x);",
+	} {
+		if _, err := g.Eval(strings.NewReader(s)); err == nil {
+			t.Errorf("%d: input=%s: error is expected", i, s)
+		}
+	}
+}
+
+func TestSolveRootsErr(t *testing.T) {
+	// 多変数多項式はエラーを返す
+	p := NewPolyCoef(1, NewPolyCoef(0, 0, 1), 1)
+	if rs, err := solveRoots([]*Poly{p}); err == nil {
+		t.Errorf("error is expected: %v", rs)
+	}
+}
+
+func TestSolvePolys(t *testing.T) {
+	g := NewGANRAC()
+	// 論理和の中の限量子
+	f, err := g.Eval(strings.NewReader("x > 0 || ex([y], y^2 < x && all([z], z^2+y >= 0));"))
+	if err != nil {
+		t.Fatalf("eval: %v", err)
+	}
+	ps := solvePolys(f.(Fof))
+	if len(ps) != 3 {
+		t.Errorf("expect 3 polys, actual=%v", ps)
+	}
+}
@@ -0,0 +1,280 @@
+package ganrac
+
+// Sturm 列, Sturm-Habicht 列, Cauchy 指数と実根の個数.
+//
+// 多項式は係数にパラメータを含んでもよい.
+// 剰余は主係数の偶数乗を掛けた擬剰余で計算するので, 主係数が 0 でない限り
+// 符号は変わらない.
+
+import (
+	"fmt"
+)
+
+// sturmMain は x[lv] が主変数となるように変数を付け替えた p と,
+// 元に戻す関数を返す.
+func sturmMain(p *Poly, lv Level) (*Poly, func(RObj) RObj, error) {
+	if p.lv == lv {
+		return p, func(r RObj) RObj { return r }, nil
+	}
+	if !p.hasVar(lv) {
+		return nil, nil, fmt.Errorf("not a polynomial in %s", varstr(lv))
+	}
+	t := radbase + Level(len(radlist))
+	if t < Level(len(varlist)) {
+		t = Level(len(varlist))
+	}
+	q := p.Subst(NewPolyVar(t), lv).(*Poly)
+	back := func(r RObj) RObj {
+		return r.Subst(NewPolyVar(lv), t)
+	}
+	return q, back, nil
+}
+
+// sturmPrem は a > 0 となる a で a*f = q*g + r を満たす r を返す.
+// a は g の主係数の偶数乗.
+func sturmPrem(f, g *Poly) RObj {
+	if len(f.c) < len(g.c) {
+		return f
+	}
+	_, _, r := f.pquorem(g)
+	if (len(f.c)-len(g.c)+1)%2 != 0 {
+		r = Mul(r, g.lc())
+	}
+	if rp, ok := r.(*Poly); ok {
+		r, _ = rp.pp()
+	}
+	return r
+}
+
+// sRem は符号付き剰余列 [f, g, -rem(f, g), ...] を返す.
+func sRem(f *Poly, g RObj) []RObj {
+	ret := []RObj{f, g}
+	for {
+		gp, ok := g.(*Poly)
+		if !ok || gp.lv != f.lv {
+			return ret
+		}
+		r := sturmPrem(f, gp)
+		if r.IsZero() {
+			return ret
+		}
+		f = gp
+		g = r.Neg()
+		ret = append(ret, g)
+	}
+}
+
+// Sturm は p の x[lv] に関する Sturm 列を返す.
+func (p *Poly) Sturm(lv Level) ([]RObj, error) {
+	q, back, err := sturmMain(p, lv)
+	if err != nil {
+		return nil, err
+	}
+	seq := sRem(q, q.diff(q.lv))
+	for i, s := range seq {
+		seq[i] = back(s)
+	}
+	return seq, nil
+}
+
+// sturmSign は1変数多項式 s の x での符号を返す.
+// x が nil なら inf, -inf (neg=true) での符号
+func sturmSign(s RObj, x NObj, neg bool) int {
+	p, ok := s.(*Poly)
+	if !ok {
+		return s.Sign()
+	} else if x != nil {
+		return p.Subst(x, p.lv).Sign()
+	} else if neg && p.deg()%2 == 1 {
+		return -p.Sign()
+	}
+	return p.Sign()
+}
+
+// sturmVar は列 seq の x での符号の変化の数を返す
+func sturmVar(seq []RObj, x NObj, neg bool) int {
+	n := 0
+	prev := 0
+	for _, s := range seq {
+		sgn := sturmSign(s, x, neg)
+		if sgn == 0 {
+			continue
+		}
+		if prev*sgn < 0 {
+			n++
+		}
+		prev = sgn
+	}
+	return n
+}
+
+// CauchyIndex は x[lv] の1変数多項式 p, q について
+// 区間 (a, b] での q/p の Cauchy 指数を返す.
+// a, b が nil なら -inf, inf
+func CauchyIndex(q RObj, p *Poly, lv Level, a, b NObj) (int, error) {
+	if !p.isUnivariate() || p.lv != lv {
+		return 0, fmt.Errorf("univariate polynomial in %s is expected", varstr(lv))
+	}
+	// 正の定数倍しても指数は変わらない
+	p, _ = p.pp()
+	g := q
+	if qp, ok := q.(*Poly); ok {
+		if qp.lv != lv || !qp.isUnivariate() {
+			return 0, fmt.Errorf("univariate polynomial in %s is expected", varstr(lv))
+		}
+		qp, _ = qp.pp()
+		g = sturmPrem(qp, p)
+	} else if !q.IsNumeric() {
+		return 0, fmt.Errorf("univariate polynomial in %s is expected", varstr(lv))
+	}
+	seq := sRem(p, g)
+	return sturmVar(seq, a, true) - sturmVar(seq, b, false), nil
+}
+
+// NumRealRoots は p の区間 (a, b] にある相異なる実根の数を返す.
+// a, b が nil なら -inf, inf
+func (p *Poly) NumRealRoots(lv Level, a, b NObj) (int, error) {
+	if p.lv != lv || !p.isUnivariate() {
+		return 0, fmt.Errorf("univariate polynomial in %s is expected", varstr(lv))
+	}
+	if a != nil && b != nil && a.Cmp(b) >= 0 {
+		return 0, nil
+	}
+	switch d := p.diff(lv).(type) {
+	case *Poly:
+		return CauchyIndex(d, p, lv, a, b)
+	default:
+		// 1 次式
+		r := p.c[0].Neg().(NObj).Div(p.c[1].(NObj)).(NObj)
+		if (a == nil || r.Cmp(a) > 0) && (b == nil || r.Cmp(b) <= 0) {
+			return 1, nil
+		}
+		return 0, nil
+	}
+}
+
+// detBareiss は行列式を返す. m は破壊される.
+func detBareiss(m [][]RObj) RObj {
+	n := len(m)
+	sgn := 1
+	var prev RObj = one
+	for k := 0; k < n-1; k++ {
+		if m[k][k].IsZero() {
+			i := k + 1
+			for ; i < n && m[i][k].IsZero(); i++ {
+			}
+			if i == n {
+				return zero
+			}
+			m[k], m[i] = m[i], m[k]
+			sgn = -sgn
+		}
+		for i := k + 1; i < n; i++ {
+			for j := k + 1; j < n; j++ {
+				m[i][j] = divExact(Sub(Mul(m[k][k], m[i][j]), Mul(m[i][k], m[k][j])), prev)
+			}
+		}
+		prev = m[k][k]
+	}
+	if n == 0 {
+		return one
+	} else if sgn < 0 {
+		return m[n-1][n-1].Neg()
+	}
+	return m[n-1][n-1]
+}
+
+// psc は f, g の j 次の主部分終結式係数を返す.
+// fc, gc は f, g の係数で, 次数はそれぞれ len(fc)-1, len(gc)-1 とみなす.
+func psc(fc, gc []RObj, j int) RObj {
+	m := len(fc) - 1
+	n := len(gc) - 1
+	l := m + n - 2*j
+	mat := make([][]RObj, l)
+	row := func(c []RObj, s int) []RObj {
+		// x^s * f の x^(m+n-j-1), ..., x^j の係数
+		r := make([]RObj, l)
+		for k := range r {
+			e := m + n - j - 1 - k - s
+			if 0 <= e && e < len(c) {
+				r[k] = c[e]
+			} else {
+				r[k] = zero
+			}
+		}
+		return r
+	}
+	for i := 0; i < n-j; i++ {
+		mat[i] = row(fc, n-j-1-i)
+	}
+	for i := 0; i < m-j; i++ {
+		mat[n-j+i] = row(gc, m-j-1-i)
+	}
+	return detBareiss(mat)
+}
+
+// SturmHabicht は p, q の x[lv] に関する Sturm-Habicht 列の主係数
+// [stha_d, ..., stha_0] を返す. d は p の次数.
+// 主係数が 0 でなければ, 主係数の列の符号の (一般化された) 持続と変化の差は
+// #{x | p(x)=0, q(x)>0} - #{x | p(x)=0, q(x)<0}
+// に等しい.
+func SturmHabicht(p *Poly, q RObj, lv Level) ([]RObj, error) {
+	pm, back, err := sturmMain(p, lv)
+	if err != nil {
+		return nil, err
+	}
+	t := pm.lv
+	if qp, ok := q.(*Poly); ok && qp.hasVar(lv) {
+		q = qp.Subst(NewPolyVar(t), lv)
+	}
+	g := Mul(pm.diff(t), q)
+	if gp, ok := g.(*Poly); ok && gp.lv == t && len(gp.c) >= len(pm.c) {
+		g = sturmPrem(gp, pm)
+	}
+
+	d := pm.deg()
+	gc := make([]RObj, d)
+	for i := range gc {
+		gc[i] = zero
+	}
+	if gp, ok := g.(*Poly); ok && gp.lv == t {
+		copy(gc, gp.c)
+	} else {
+		gc[0] = g
+	}
+
+	ret := make([]RObj, d+1)
+	ret[0] = pm.c[d]
+	ret[1] = gc[d-1]
+	for j := d - 2; j >= 0; j-- {
+		r := psc(pm.c, gc, j)
+		if k := d - j; (k*(k-1)/2)%2 != 0 {
+			r = r.Neg()
+		}
+		ret[d-j] = r
+	}
+	for i, r := range ret {
+		ret[i] = back(r)
+	}
+	return ret, nil
+}
+
+// pmv は符号の列 s の一般化された持続と変化の差を返す.
+func pmv(s []int) int {
+	ret := 0
+	i := -1
+	for j, v := range s {
+		if v == 0 {
+			continue
+		}
+		if k := j - i; i >= 0 && k%2 == 1 {
+			if (k*(k-1)/2)%2 == 0 {
+				ret += s[i] * v
+			} else {
+				ret -= s[i] * v
+			}
+		}
+		i = j
+	}
+	return ret
+}
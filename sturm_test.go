@@ -0,0 +1,94 @@
+package ganrac
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSturm(t *testing.T) {
+	g := NewGANRAC()
+	for i, s := range []struct {
+		input  string
+		expect string
+	}{
+		{"nroots(x^3-2*x, x);", "3"},
+		{"nroots(x^3-2*x, x, 0, 2);", "1"},
+		{"nroots(x^3-2*x, x, -2, 0);", "2"},
+		{"nroots(x^3-2*x, x, \"-inf\", 0);", "2"},
+		{"nroots((x^2-2)^2*(x-1)^3, x);", "3"},
+		{"nroots(x^2+1, x);", "0"},
+		{"nroots(3*x-1, x, 0, 1/3);", "1"},
+		{"nroots(3*x-1, x, 1/3, 1);", "0"},
+		{"nroots(x^2-2, x, realalg(x^2-2, 1, 2), 2);", "0"},
+		{"nroots(x^2-2, x, 1, realalg(x^2-2, 1, 2));", "1"},
+		{"sturm(x^3-2*x, x);", "[x^3-2*x, 3*x^2-2, x, 2]"},
+		{"sturm(x^2+a, x);", "[x^2+a, 2*x, -a]"},
+		{"sturmhabicht(x^2+b*x+c, 1, x);", "[1, 2, b^2-4*c]"},
+	} {
+		u, err := g.Eval(strings.NewReader(s.input))
+		if err != nil {
+			t.Errorf("%d: input=%s: err=%s", i, s.input, err)
+			continue
+		}
+		v, err := g.Eval(strings.NewReader(s.expect + ";"))
+		if err != nil {
+			t.Errorf("%d: expect=%s: err=%s", i, s.expect, err)
+			continue
+		}
+		if str := u.(GObj).String(); str != v.(GObj).String() {
+			t.Errorf("%d: input=%s: expect=%s, actual=%s", i, s.input, s.expect, str)
+		}
+	}
+
+	for i, s := range []string{
+		"nroots(x^2-2, y);",
+		"nroots(x*y-2, x);",
+		"nroots(x^2-2, x, 1);",
+		"sturm(x^2-2, y);",
+	} {
+		if _, err := g.Eval(strings.NewReader(s)); err == nil {
+			t.Errorf("%d: input=%s: error is expected", i, s)
+		}
+	}
+}
+
+func TestSturmHabichtPmV(t *testing.T) {
+	x := NewPolyVar(0)
+	for i, s := range []struct {
+		p, q RObj
+	}{
+		{Sub(Mul(x, x), two), one},
+		{Sub(Mul(x, x), two), x},
+		{Mul(Sub(Mul(x, x), two), Sub(x, one)), x},
+		{Mul(Sub(Mul(x, x), two), Sub(x, one)), Add(Mul(x, x), NewInt(-3))},
+		{Mul(Sub(Mul(Mul(x, x), x), two), Add(x, NewInt(5))), Sub(x, one)},
+		{Mul(Sub(Mul(Mul(x, x), x), Mul(two, x)), Add(Mul(x, x), one)), one},
+		{Mul(Mul(Sub(x, one), Sub(x, one)), Add(x, two)), one},
+		{Mul(Mul(Sub(x, one), Add(x, NewInt(3))), Add(x, two)), Sub(Mul(x, x), NewInt(5))},
+	} {
+		p := s.p.(*Poly)
+		stha, err := SturmHabicht(p, s.q, 0)
+		if err != nil {
+			t.Errorf("%d: p=%v, q=%v: err=%s", i, p, s.q, err)
+			continue
+		}
+		sgns := make([]int, len(stha))
+		for j, c := range stha {
+			sgns[j] = c.Sign()
+		}
+
+		// Tarski query
+		rs, _ := p.RealRoots()
+		expect := 0
+		for _, r := range rs {
+			var v RObj = s.q
+			if q, ok := s.q.(*Poly); ok {
+				v = q.Subst(r, 0)
+			}
+			expect += v.Sign()
+		}
+		if actual := pmv(sgns); actual != expect {
+			t.Errorf("%d: p=%v, q=%v: stha=%v: expect=%d, actual=%d", i, p, s.q, stha, expect, actual)
+		}
+	}
+}